@@ -0,0 +1,4 @@
+// Package pushpb 是 gRPC 接口的 protobuf 定义与生成代码
+package pushpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative push.proto
@@ -0,0 +1,205 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.3
+// source: push.proto
+
+// Swap 事件与通知记录，供其他服务通过 gRPC 订阅和查询
+
+package pushpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SwapFeed_Subscribe_FullMethodName          = "/messagepush.v1.SwapFeed/Subscribe"
+	SwapFeed_QuerySwaps_FullMethodName         = "/messagepush.v1.SwapFeed/QuerySwaps"
+	SwapFeed_QueryNotifications_FullMethodName = "/messagepush.v1.SwapFeed/QueryNotifications"
+)
+
+// SwapFeedClient is the client API for SwapFeed service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SwapFeedClient interface {
+	// 订阅新的 Swap，服务停止时流结束
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SwapEvent], error)
+	QuerySwaps(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QuerySwapsResponse, error)
+	QueryNotifications(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryNotificationsResponse, error)
+}
+
+type swapFeedClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSwapFeedClient(cc grpc.ClientConnInterface) SwapFeedClient {
+	return &swapFeedClient{cc}
+}
+
+func (c *swapFeedClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SwapEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SwapFeed_ServiceDesc.Streams[0], SwapFeed_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, SwapEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SwapFeed_SubscribeClient = grpc.ServerStreamingClient[SwapEvent]
+
+func (c *swapFeedClient) QuerySwaps(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QuerySwapsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuerySwapsResponse)
+	err := c.cc.Invoke(ctx, SwapFeed_QuerySwaps_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *swapFeedClient) QueryNotifications(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryNotificationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueryNotificationsResponse)
+	err := c.cc.Invoke(ctx, SwapFeed_QueryNotifications_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SwapFeedServer is the server API for SwapFeed service.
+// All implementations must embed UnimplementedSwapFeedServer
+// for forward compatibility.
+type SwapFeedServer interface {
+	// 订阅新的 Swap，服务停止时流结束
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[SwapEvent]) error
+	QuerySwaps(context.Context, *QueryRequest) (*QuerySwapsResponse, error)
+	QueryNotifications(context.Context, *QueryRequest) (*QueryNotificationsResponse, error)
+	mustEmbedUnimplementedSwapFeedServer()
+}
+
+// UnimplementedSwapFeedServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSwapFeedServer struct{}
+
+func (UnimplementedSwapFeedServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[SwapEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedSwapFeedServer) QuerySwaps(context.Context, *QueryRequest) (*QuerySwapsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QuerySwaps not implemented")
+}
+func (UnimplementedSwapFeedServer) QueryNotifications(context.Context, *QueryRequest) (*QueryNotificationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryNotifications not implemented")
+}
+func (UnimplementedSwapFeedServer) mustEmbedUnimplementedSwapFeedServer() {}
+func (UnimplementedSwapFeedServer) testEmbeddedByValue()                  {}
+
+// UnsafeSwapFeedServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SwapFeedServer will
+// result in compilation errors.
+type UnsafeSwapFeedServer interface {
+	mustEmbedUnimplementedSwapFeedServer()
+}
+
+func RegisterSwapFeedServer(s grpc.ServiceRegistrar, srv SwapFeedServer) {
+	// If the following call pancis, it indicates UnimplementedSwapFeedServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SwapFeed_ServiceDesc, srv)
+}
+
+func _SwapFeed_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SwapFeedServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, SwapEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SwapFeed_SubscribeServer = grpc.ServerStreamingServer[SwapEvent]
+
+func _SwapFeed_QuerySwaps_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SwapFeedServer).QuerySwaps(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SwapFeed_QuerySwaps_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SwapFeedServer).QuerySwaps(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SwapFeed_QueryNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SwapFeedServer).QueryNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SwapFeed_QueryNotifications_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SwapFeedServer).QueryNotifications(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SwapFeed_ServiceDesc is the grpc.ServiceDesc for SwapFeed service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SwapFeed_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "messagepush.v1.SwapFeed",
+	HandlerType: (*SwapFeedServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "QuerySwaps",
+			Handler:    _SwapFeed_QuerySwaps_Handler,
+		},
+		{
+			MethodName: "QueryNotifications",
+			Handler:    _SwapFeed_QueryNotifications_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _SwapFeed_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "push.proto",
+}
@@ -0,0 +1,89 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// 自适应轮询配置。graph_task 仍按 1 秒调度，未到下次轮询时间的调度直接跳过：
+// 有新成交后按 minSeconds 轮询，超过 quietSeconds 没有成交后每轮间隔翻倍，直到 maxSeconds
+type PollingConfig struct {
+	Adaptive     bool `json:"adaptive"`     // 开启自适应轮询，关闭时每秒轮询
+	MinSeconds   int  `json:"minSeconds"`   // 最短间隔，默认 1
+	MaxSeconds   int  `json:"maxSeconds"`   // 最长间隔，默认 30
+	QuietSeconds int  `json:"quietSeconds"` // 多久没有成交后开始放慢，默认 120
+}
+
+func getPollingConfig() PollingConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Polling
+}
+
+// 轮询间隔的状态
+type adaptivePoller struct {
+	mu           sync.Mutex
+	interval     time.Duration
+	next         time.Time // 下次轮询的时间
+	lastActivity time.Time // 最近一次获取到成交的时间
+}
+
+var graphPoller adaptivePoller
+
+// 是否到了下次轮询的时间
+func (p *adaptivePoller) due(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !now.Before(p.next)
+}
+
+// 记录本轮获取到的成交数
+func (p *adaptivePoller) record(now time.Time, swaps int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if swaps > 0 || p.lastActivity.IsZero() {
+		p.lastActivity = now
+	}
+}
+
+// 按最近的成交情况计算下次轮询时间
+func (p *adaptivePoller) advance(now time.Time, cfg PollingConfig) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	minInterval := secondsOrDefault(cfg.MinSeconds, 1)
+	maxInterval := max(secondsOrDefault(cfg.MaxSeconds, 30), minInterval)
+	quiet := secondsOrDefault(cfg.QuietSeconds, 120)
+
+	previous := p.interval
+	switch {
+	case p.lastActivity.IsZero() || now.Sub(p.lastActivity) < quiet:
+		p.interval = minInterval
+	case p.interval < minInterval:
+		p.interval = minInterval
+	default:
+		p.interval = min(p.interval*2, maxInterval)
+	}
+	if p.interval != previous {
+		slog.Debug("Polling interval changed", "interval", p.interval, "lastActivity", p.lastActivity)
+	}
+	p.next = now.Add(p.interval)
+	return p.interval
+}
+
+// 包装 graph_task，未开启自适应轮询时每次调度都执行
+func pollAdaptively(fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		cfg := getPollingConfig()
+		if !cfg.Adaptive {
+			return fn(ctx)
+		}
+		if !graphPoller.due(time.Now()) {
+			return nil
+		}
+		err := fn(ctx)
+		graphPoller.advance(time.Now(), cfg)
+		return err
+	}
+}
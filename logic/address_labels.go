@@ -0,0 +1,149 @@
+package logic
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// ENS 注册表合约地址（主网）
+	ensRegistryAddress = "0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"
+	// resolver(bytes32) 方法选择器
+	ensResolverSelector = "0x0178b8bf"
+	// name(bytes32) 方法选择器
+	ensNameSelector = "0x691f3431"
+)
+
+// ENS 解析配置
+type ENSConfig struct {
+	Enabled bool `json:"enabled"` // 是否通过 RPC 反向解析 ENS 名称
+}
+
+// ENS 解析缓存，没有名称的地址同样缓存
+var ensCache = newLookupCache[string]("ens", true)
+
+func getAddressLabels() map[string]string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.AddressLabels
+}
+
+func getENSConfig() ENSConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.ENS
+}
+
+// 地址的显示名称：优先使用配置的标签，其次使用 ENS 名称，都没有时返回空字符串
+func lookupAddressName(ctx context.Context, address string) string {
+	for labeled, label := range getAddressLabels() {
+		if strings.EqualFold(labeled, address) {
+			return label
+		}
+	}
+	if !getENSConfig().Enabled {
+		return ""
+	}
+	return resolveENSName(ctx, address)
+}
+
+// 地址的显示文本，没有名称时使用缩写地址
+func displayAddress(ctx context.Context, address string) string {
+	if name := lookupAddressName(ctx, address); name != "" {
+		return name
+	}
+	return shortAddress(address)
+}
+
+// 反向解析 ENS 名称，结果会被缓存。解析失败同样缓存，避免每条通知都等待超时
+func resolveENSName(ctx context.Context, address string) string {
+	address = strings.ToLower(address)
+	hours := getCacheConfig().ENSHours
+	if hours <= 0 {
+		hours = 24
+	}
+	// 格式化消息时同步解析，在调用方的上下文上再加短超时，避免拖慢通知，任务取消时立即放弃
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	name, _ := ensCache.get(ctx, address, time.Duration(hours)*time.Hour, func() (string, error) {
+		name, err := reverseENS(ctx, address)
+		if err != nil {
+			slog.Debug("Failed to resolve ENS name", "address", address, "error", err)
+		}
+		return name, nil
+	})
+	return name
+}
+
+// 通过 {addr}.addr.reverse 查询名称
+func reverseENS(ctx context.Context, address string) (string, error) {
+	node := ensNamehash(strings.TrimPrefix(address, "0x") + ".addr.reverse")
+	resolver, err := ethCall(ctx, ensRegistryAddress, ensResolverSelector+node)
+	if err != nil {
+		return "", err
+	}
+	resolverAddress, err := decodeAddress(resolver)
+	if err != nil || resolverAddress == "0x0000000000000000000000000000000000000000" {
+		return "", err
+	}
+
+	result, err := ethCall(ctx, resolverAddress, ensNameSelector+node)
+	if err != nil {
+		return "", err
+	}
+	return decodeString(result)
+}
+
+// 计算 ENS namehash，返回不带 0x 前缀的十六进制字符串
+func ensNamehash(name string) string {
+	node := make([]byte, 32)
+	if name != "" {
+		labels := strings.Split(name, ".")
+		for i := len(labels) - 1; i >= 0; i-- {
+			node = keccak256(append(node, keccak256([]byte(labels[i]))...))
+		}
+	}
+	return hex.EncodeToString(node)
+}
+
+func keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// 解析 ABI 编码的 address 返回值
+func decodeAddress(result string) (string, error) {
+	raw := strings.TrimPrefix(result, "0x")
+	if len(raw) < 64 {
+		return "", fmt.Errorf("invalid address result %q", result)
+	}
+	return "0x" + raw[24:64], nil
+}
+
+// 解析 ABI 编码的 string 返回值
+func decodeString(result string) (string, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < 64 {
+		return "", nil
+	}
+	offset := new(big.Int).SetBytes(raw[:32]).Int64()
+	if offset+32 > int64(len(raw)) {
+		return "", fmt.Errorf("invalid string offset %d", offset)
+	}
+	length := new(big.Int).SetBytes(raw[offset : offset+32]).Int64()
+	if offset+32+length > int64(len(raw)) {
+		return "", fmt.Errorf("invalid string length %d", length)
+	}
+	return string(raw[offset+32 : offset+32+length]), nil
+}
@@ -0,0 +1,226 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 通知暂停状态，只在本次运行期间有效
+var (
+	notifyPausedUntil time.Time
+	notifyPauseMutex  sync.Mutex
+)
+
+// 暂停推送 Swap 通知，duration 为 0 时一直暂停到手动恢复。暂停期间的交易仍视为已处理
+func pauseNotifications(now time.Time, duration time.Duration) time.Time {
+	until := now.Add(duration)
+	if duration <= 0 {
+		until = now.AddDate(100, 0, 0)
+	}
+	notifyPauseMutex.Lock()
+	notifyPausedUntil = until
+	notifyPauseMutex.Unlock()
+	slog.Warn("Notifications paused", "until", until)
+	return until
+}
+
+func resumeNotifications() {
+	notifyPauseMutex.Lock()
+	notifyPausedUntil = time.Time{}
+	notifyPauseMutex.Unlock()
+	slog.Info("Notifications resumed")
+}
+
+// 返回通知是否处于暂停状态以及恢复时间
+func notificationsPaused(now time.Time) (time.Time, bool) {
+	notifyPauseMutex.Lock()
+	defer notifyPauseMutex.Unlock()
+	return notifyPausedUntil, now.Before(notifyPausedUntil)
+}
+
+// 服务状态
+type ServiceStatus struct {
+	StartedAt    time.Time                 `json:"startedAt"`
+	Uptime       string                    `json:"uptime"`
+	Leader       bool                      `json:"leader"`
+	LogLevel     string                    `json:"logLevel"`
+	StoreDriver  string                    `json:"storeDriver"`
+	Cursor       string                    `json:"cursor"` // 当前数据源的进度名称
+	LastBlock    string                    `json:"lastBlock"`
+	LastQuery    *time.Time                `json:"lastQuery,omitempty"` // 最近一次成功的 Graph 查询
+	Subgraphs    map[string]subgraphStatus `json:"subgraphs"`           // 各数据源子图的索引状态
+	NotifyPaused bool                      `json:"notifyPaused"`
+	PausedUntil  *time.Time                `json:"pausedUntil,omitempty"`
+	TasksFailing []string                  `json:"tasksFailing"` // 最近一次执行失败的任务
+	TasksPaused  []string                  `json:"tasksPaused"`
+	Queues       []DeliveryQueueStatus     `json:"queues"` // 各渠道推送队列
+}
+
+func serviceStatus(ctx context.Context, now time.Time) ServiceStatus {
+	status := ServiceStatus{
+		StartedAt:    startedAt,
+		Uptime:       now.Sub(startedAt).Round(time.Second).String(),
+		Leader:       leading(),
+		LogLevel:     logLevel.Level().String(),
+		StoreDriver:  getStorageConfig().Driver,
+		Cursor:       swapCursorName(),
+		Subgraphs:    listSubgraphStatuses(),
+		Queues:       listDeliveryQueues(),
+		TasksFailing: []string{},
+		TasksPaused:  []string{},
+	}
+	if cursor, err := state.Get(ctx, status.Cursor); err == nil {
+		status.LastBlock = cursor.LastBlockNumber
+	}
+	if lastQuery, ok := watchdog.lastSuccess(); ok {
+		status.LastQuery = &lastQuery
+	}
+	if until, paused := notificationsPaused(now); paused {
+		status.NotifyPaused, status.PausedUntil = true, &until
+	}
+	for _, task := range listTasks() {
+		if task.LastError != "" {
+			status.TasksFailing = append(status.TasksFailing, task.Name)
+		}
+		if task.Paused {
+			status.TasksPaused = append(status.TasksPaused, task.Name)
+		}
+	}
+	return status
+}
+
+// 注册查询与控制接口
+//
+//	GET  /status                       服务状态
+//	GET  /cursors                      所有处理进度
+//	GET  /swaps?since=24h&limit=50     最近的 Swap，按时间倒序
+//	GET  /notifications?since=&limit=  最近发送的通知，按时间倒序
+//	GET  /rules                        当前生效的过滤规则
+//	GET  /prices?since=24h             价格历史，按时间正序
+//	GET  /channels                     各推送渠道最近的推送结果
+//	GET  /config                       隐去密钥后的当前配置
+//	GET  /stream                       以 Server-Sent Events 推送新的 Swap，见 serveSwapStream，令牌也可以用 ?token= 传入
+//	POST /notifications/pause?for=2h   暂停推送 Swap 通知，不传 for 时一直暂停到恢复
+//	POST /notifications/resume         恢复推送
+//
+// 订阅者接口见 registerSubscriberHandlers
+func registerAdminAPIHandlers(mux *http.ServeMux) {
+	registerSubscriberHandlers(mux)
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, serviceStatus(r.Context(), time.Now()), nil)
+	})
+	mux.HandleFunc("GET /cursors", func(w http.ResponseWriter, r *http.Request) {
+		cursors, err := getStore().ListCursors(r.Context())
+		writeAdminJSON(w, cursors, err)
+	})
+	mux.HandleFunc("GET /swaps", func(w http.ResponseWriter, r *http.Request) {
+		since, limit, err := recentQuery(r, "24h")
+		if err != nil {
+			writeAdminJSON(w, nil, err)
+			return
+		}
+		now := time.Now()
+		records, err := getStore().QuerySwaps(r.Context(), now.Add(-since), now.Add(time.Second))
+		writeAdminJSON(w, newestFirst(records, limit, func(r swapRecord) time.Time { return r.Time }), err)
+	})
+	mux.HandleFunc("GET /notifications", func(w http.ResponseWriter, r *http.Request) {
+		since, limit, err := recentQuery(r, "7d")
+		if err != nil {
+			writeAdminJSON(w, nil, err)
+			return
+		}
+		now := time.Now()
+		records, err := getStore().QueryNotifications(r.Context(), now.Add(-since), now.Add(time.Second))
+		writeAdminJSON(w, newestFirst(records, limit, func(r NotificationRecord) time.Time { return r.Time }), err)
+	})
+	mux.HandleFunc("GET /rules", func(w http.ResponseWriter, r *http.Request) {
+		active := []string{}
+		compiled, err := getConditions()
+		for _, condition := range compiled {
+			active = append(active, condition.source)
+		}
+		result := map[string]interface{}{
+			"limitPrice":       getLimitPrice(),
+			"rules":            getRulesConfig(),
+			"activeConditions": active,
+			"whaleTiers":       getWhaleTiers(),
+		}
+		if err != nil {
+			result["conditionsError"] = err.Error()
+		}
+		writeAdminJSON(w, result, nil)
+	})
+	mux.HandleFunc("GET /prices", func(w http.ResponseWriter, r *http.Request) {
+		since, _, err := recentQuery(r, "24h")
+		if err != nil {
+			writeAdminJSON(w, nil, err)
+			return
+		}
+		now := time.Now()
+		records := priceHistory.query(now.Add(-since), now.Add(time.Second))
+		if records == nil {
+			records = []pricePointRecord{}
+		}
+		writeAdminJSON(w, records, nil)
+	})
+	mux.HandleFunc("GET /channels", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, listChannelHealth(), nil)
+	})
+	mux.HandleFunc("GET /config", func(w http.ResponseWriter, r *http.Request) {
+		config, err := redactedConfig()
+		writeAdminJSON(w, config, err)
+	})
+	mux.HandleFunc("POST /notifications/pause", func(w http.ResponseWriter, r *http.Request) {
+		var duration time.Duration
+		if value := r.URL.Query().Get("for"); value != "" {
+			var err error
+			if duration, err = parseDays(value); err != nil {
+				writeAdminJSON(w, nil, err)
+				return
+			}
+		}
+		until := pauseNotifications(time.Now(), duration)
+		writeAdminJSON(w, map[string]interface{}{"paused": true, "until": until}, nil)
+	})
+	mux.HandleFunc("POST /notifications/resume", func(w http.ResponseWriter, r *http.Request) {
+		resumeNotifications()
+		writeAdminJSON(w, map[string]interface{}{"paused": false}, nil)
+	})
+}
+
+// 解析 since 与 limit 参数，limit 默认 50，最多 1000
+func recentQuery(r *http.Request, defaultSince string) (time.Duration, int, error) {
+	since := r.URL.Query().Get("since")
+	if since == "" {
+		since = defaultSince
+	}
+	window, err := parseDays(since)
+	if err != nil {
+		return 0, 0, err
+	}
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			return 0, 0, fmt.Errorf("invalid limit %q", value)
+		}
+	}
+	return window, min(limit, 1000), nil
+}
+
+// 按时间倒序返回最多 limit 条记录
+func newestFirst[T any](records []T, limit int, at func(T) time.Time) []T {
+	sort.SliceStable(records, func(i, j int) bool { return at(records[i]).After(at(records[j])) })
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	if records == nil {
+		records = []T{}
+	}
+	return records
+}
@@ -0,0 +1,173 @@
+package logic
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 管理接口配置
+type AdminConfig struct {
+	Listen string `json:"listen"` // 监听地址，为空时不启动，默认只监听本机
+	Token  string `json:"token"`  // admin 范围的访问令牌，以 Authorization: Bearer <token> 传入，与 tokens、basicAuth 都为空时不校验
+
+	Tokens    []AccessToken   `json:"tokens"`    // 带范围的访问令牌
+	BasicAuth []BasicAuthUser `json:"basicAuth"` // Basic 认证用户
+	TLS       TLSConfig       `json:"tls"`       // HTTPS，管理接口、调试接口与 gRPC 共用
+
+	Dashboard bool `json:"dashboard"` // 在 /dashboard/ 提供管理页面
+}
+
+// 已启动的 HTTP 服务，停止时统一关闭
+var (
+	httpServers      []*http.Server
+	httpServersMutex sync.Mutex
+)
+
+func getAdminConfig() AdminConfig {
+	configMutex.RLock()
+	cfg := configData.Admin
+	configMutex.RUnlock()
+	cfg.Token = secretValue(cfg.Token)
+	return cfg
+}
+
+// 启动管理接口
+//
+//	GET  /tasks                         列出任务
+//	POST /tasks/{name}/pause            暂停任务
+//	POST /tasks/{name}/resume           恢复任务
+//	POST /tasks/{name}/run              立即执行一次
+//	POST /tasks/{name}/interval?every=  修改执行间隔，例如 every=5s
+//	GET  /ha                            多实例部署时的主备状态
+//	GET  /log/level                     当前日志级别
+//	POST /log/level?level=debug         修改日志级别，重新加载配置后恢复为配置中的级别
+//
+// 以及 registerAdminAPIHandlers 中的状态查询与通知暂停接口
+func startAdminServer() {
+	cfg := getAdminConfig()
+	if cfg.Listen == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	registerAdminAPIHandlers(mux)
+	mux.HandleFunc("GET /tasks", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, listTasks(), nil)
+	})
+	mux.HandleFunc("GET /ha", func(w http.ResponseWriter, r *http.Request) {
+		cfg := getHAConfig()
+		writeAdminJSON(w, map[string]interface{}{
+			"enabled":  cfg.Enabled,
+			"backend":  cfg.Backend,
+			"instance": cfg.InstanceID,
+			"leader":   leading(),
+		}, nil)
+	})
+	mux.HandleFunc("GET /log/level", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, map[string]string{"level": logLevel.Level().String()}, nil)
+	})
+	mux.HandleFunc("POST /log/level", func(w http.ResponseWriter, r *http.Request) {
+		level, err := setLogLevel(r.URL.Query().Get("level"))
+		if err == nil {
+			slog.Warn("Log level changed via admin API", "level", level)
+		}
+		writeAdminJSON(w, map[string]string{"level": level.String()}, err)
+	})
+	actions := map[string]func(name string, r *http.Request) (TaskInfo, error){
+		"pause":  func(name string, _ *http.Request) (TaskInfo, error) { return pauseTask(name) },
+		"resume": func(name string, _ *http.Request) (TaskInfo, error) { return resumeTask(name) },
+		"run":    func(name string, _ *http.Request) (TaskInfo, error) { return triggerTask(name) },
+		"interval": func(name string, r *http.Request) (TaskInfo, error) {
+			interval, err := time.ParseDuration(r.URL.Query().Get("every"))
+			if err != nil {
+				return TaskInfo{}, err
+			}
+			return setTaskInterval(name, interval)
+		},
+	}
+	mux.HandleFunc("POST /tasks/{name}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		action, ok := actions[r.PathValue("action")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		task, err := action(r.PathValue("name"), r)
+		writeAdminJSON(w, task, err)
+	})
+
+	tlsConfig, err := loadServerTLS()
+	if err != nil {
+		slog.Error("Failed to start admin server", "error", err)
+		return
+	}
+
+	// 健康检查供探针调用，不校验；管理页面的静态文件不包含数据，只在配置了 Basic 认证时校验；
+	// 订阅流、RSS 订阅和外部告警单独校验
+	root := http.NewServeMux()
+	if getHealthConfig().Listen == "" {
+		registerHealthHandlers(root)
+	}
+	if cfg.Dashboard {
+		registerDashboard(root)
+	}
+	if getFeedConfig().Enabled {
+		registerFeedHandler(root)
+	}
+	if getInboundConfig().Enabled {
+		registerInboundWebhook(root)
+	}
+	root.Handle("GET /stream", requireScope(scopeRead, true, http.HandlerFunc(serveSwapStream)))
+	root.Handle("/", requireAuth(mux))
+	serveHTTP("admin", cfg.Listen, root, tlsConfig)
+}
+
+// 在后台启动 HTTP 服务，tlsConfig 不为空时使用 HTTPS
+func serveHTTP(name, addr string, handler http.Handler, tlsConfig *tls.Config) {
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second, TLSConfig: tlsConfig}
+	httpServersMutex.Lock()
+	httpServers = append(httpServers, server)
+	httpServersMutex.Unlock()
+	go func() {
+		slog.Info("HTTP server listening", "name", name, "addr", addr, "tls", tlsConfig != nil)
+		var err error
+		if tlsConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server stopped", "name", name, "error", err)
+		}
+	}()
+}
+
+func stopHTTPServers(ctx context.Context) {
+	httpServersMutex.Lock()
+	servers := httpServers
+	httpServers = nil
+	httpServersMutex.Unlock()
+	for _, server := range servers {
+		server.Shutdown(ctx)
+	}
+}
+
+func writeAdminJSON(w http.ResponseWriter, v interface{}, err error) {
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,73 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Alert 一笔需要通知的交易，已通过过滤规则、成交额下限、小额刷单合并与脚本钩子
+type Alert struct {
+	*SwapEvent
+	Level   string // Bark 推送级别，大额交易按分级为 timeSensitive 或 critical
+	Message string // 默认语言的纯文本消息，脚本钩子修改过时为修改后的内容
+	MEV     string // 夹子交易标记，不是夹子交易时为空
+}
+
+// AlertHandler 自定义的通知方式，与 Bark、Telegram 一起在每笔通知时调用。
+// 调用是同步的，耗时的操作应自行异步执行；返回的错误只记录到日志与渠道状态，不影响其他渠道
+type AlertHandler func(ctx context.Context, alert Alert) error
+
+var (
+	alertHandlers      = make(map[string]AlertHandler)
+	alertHandlersMutex sync.RWMutex
+)
+
+// RegisterAlertHandler 注册自定义通知方式，同名的会被替换
+func RegisterAlertHandler(name string, handler AlertHandler) {
+	alertHandlersMutex.Lock()
+	defer alertHandlersMutex.Unlock()
+	alertHandlers[name] = handler
+}
+
+// UnregisterAlertHandler 取消注册
+func UnregisterAlertHandler(name string) {
+	alertHandlersMutex.Lock()
+	defer alertHandlersMutex.Unlock()
+	delete(alertHandlers, name)
+}
+
+// 按名称顺序调用已注册的通知方式，单个 panic 或失败不影响其他通知方式
+func notifyAlertHandlers(ctx context.Context, alert Alert) {
+	alertHandlersMutex.RLock()
+	names := make([]string, 0, len(alertHandlers))
+	for name := range alertHandlers {
+		names = append(names, name)
+	}
+	handlers := make(map[string]AlertHandler, len(alertHandlers))
+	for name, handler := range alertHandlers {
+		handlers[name] = handler
+	}
+	alertHandlersMutex.RUnlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		err := callAlertHandler(ctx, handlers[name], alert)
+		recordChannelResult("handler", name, time.Now(), err)
+		if err != nil {
+			slog.Error("Alert handler failed", "handler", name, "transactionHash", alert.Swap.TransactionHash, "error", err)
+		}
+	}
+}
+
+func callAlertHandler(ctx context.Context, handler AlertHandler, alert Alert) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, alert)
+}
@@ -0,0 +1,211 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Alertmanager 转发配置，Swap 通知与系统告警以告警形式发送到 Alertmanager，由其负责分组与静默。
+// 反方向可以把 Alertmanager 的 webhook_config 指向 /webhook/alertmanager，见 InboundConfig
+type AlertmanagerConfig struct {
+	URLs           []string          `json:"urls"`           // 例如 http://alertmanager:9093，多个地址时全部发送，为空时不转发
+	Token          string            `json:"token"`          // Bearer 令牌，支持密钥引用
+	ResolveMinutes int               `json:"resolveMinutes"` // 告警的结束时间，默认 30 分钟后自动恢复
+	Labels         map[string]string `json:"labels"`         // 附加到所有告警上的标签，例如 env
+}
+
+func getAlertmanagerConfig() AlertmanagerConfig {
+	configMutex.RLock()
+	cfg := configData.Alertmanager
+	configMutex.RUnlock()
+	cfg.URLs = secretValues(cfg.URLs)
+	cfg.Token = secretValue(cfg.Token)
+	if cfg.ResolveMinutes <= 0 {
+		cfg.ResolveMinutes = 30
+	}
+	return cfg
+}
+
+// Alertmanager v2 接口中的告警
+type alertmanagerAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// Bark 推送级别对应的告警级别
+func alertmanagerSeverity(level string) string {
+	switch level {
+	case barkLevelCritical:
+		return "critical"
+	case barkLevelTimeSensitive:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// Swap 通知对应的告警，同一笔交易的 alertname 与标签相同，重复发送时由 Alertmanager 去重。
+// 通知有标题时标题为 summary、正文为 description
+func swapAlertmanagerAlert(event *SwapEvent, notice notification, level string) alertmanagerAlert {
+	annotations := map[string]string{
+		"summary":    notice.Body,
+		"volume_usd": fmt.Sprintf("%.2f", event.VolumeUSD()),
+	}
+	if notice.Title != "" {
+		annotations["summary"] = notice.Title
+		annotations["description"] = strings.TrimSpace(notice.Subtitle + "\n" + notice.Body)
+	}
+	return alertmanagerAlert{
+		Labels: map[string]string{
+			"alertname": "WhaleSwap",
+			"severity":  alertmanagerSeverity(level),
+			"direction": event.Direction,
+			"pool":      strings.ToLower(getPoolConfig().Address),
+			"tx":        event.Swap.TransactionHash,
+		},
+		Annotations:  annotations,
+		StartsAt:     event.Time,
+		GeneratorURL: notice.URL,
+	}
+}
+
+// 系统告警，alertname 相同的告警在 Alertmanager 中合并
+func systemAlertmanagerAlert(message, level string) alertmanagerAlert {
+	return alertmanagerAlert{
+		Labels:      map[string]string{"alertname": "MessagePushAlert", "severity": alertmanagerSeverity(level)},
+		Annotations: map[string]string{"summary": message},
+		StartsAt:    time.Now(),
+	}
+}
+
+// 发送告警到所有 Alertmanager，补充公共标签和结束时间
+func forwardAlertmanager(ctx context.Context, alerts ...alertmanagerAlert) {
+	cfg := getAlertmanagerConfig()
+	if len(cfg.URLs) == 0 {
+		return
+	}
+	for i := range alerts {
+		for key, value := range cfg.Labels {
+			if _, ok := alerts[i].Labels[key]; !ok {
+				alerts[i].Labels[key] = value
+			}
+		}
+		if alerts[i].EndsAt.IsZero() {
+			alerts[i].EndsAt = time.Now().Add(time.Duration(cfg.ResolveMinutes) * time.Minute)
+		}
+	}
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		slog.Error("Failed to encode alertmanager alerts", "error", err)
+		return
+	}
+	for _, baseURL := range cfg.URLs {
+		err := postAlertmanager(ctx, cfg, baseURL, body)
+		recordChannelResult("alertmanager", urlHost(baseURL), time.Now(), err)
+		if err != nil {
+			slog.Error("Failed to forward alerts to alertmanager", "target", urlHost(baseURL), "error", err)
+		}
+	}
+}
+
+func postAlertmanager(ctx context.Context, cfg AlertmanagerConfig, baseURL string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(baseURL, "/")+"/api/v2/alerts", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("alertmanager returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Alertmanager webhook 的请求体，只取推送需要的字段
+type alertmanagerWebhook struct {
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	Alerts            []struct {
+		Status      string            `json:"status"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"alerts"`
+}
+
+// 解析 Alertmanager webhook，一组告警合并为一条推送：标题为 [FIRING:2] alertname，内容为每条告警的摘要
+func parseAlertmanagerWebhook(source string, body []byte) (inboundAlert, bool) {
+	var hook alertmanagerWebhook
+	if err := json.Unmarshal(body, &hook); err != nil || hook.Receiver == "" || len(hook.Alerts) == 0 {
+		return inboundAlert{}, false
+	}
+	alertname := hook.CommonLabels["alertname"]
+	if alertname == "" {
+		alertname = hook.Receiver
+	}
+	firing := 0
+	lines := make([]string, 0, len(hook.Alerts))
+	for _, a := range hook.Alerts {
+		if a.Status == "firing" {
+			firing++
+		}
+		summary := firstNonEmpty(a.Annotations["summary"], a.Annotations["description"], a.Annotations["message"])
+		if summary == "" {
+			summary = alertmanagerLabelSummary(a.Labels, hook.CommonLabels)
+		}
+		lines = append(lines, summary)
+	}
+	alert := inboundAlert{Source: source, Severity: strings.ToLower(hook.CommonLabels["severity"])}
+	if hook.Status == "resolved" {
+		alert.Title = fmt.Sprintf("[RESOLVED] %s", alertname)
+		alert.Severity = "resolved"
+	} else {
+		alert.Title = fmt.Sprintf("[FIRING:%d] %s", firing, alertname)
+	}
+	alert.Message = strings.Join(lines, "\n")
+	if summary := hook.CommonAnnotations["summary"]; summary != "" && len(hook.Alerts) > 1 {
+		alert.Message = summary + "\n" + alert.Message
+	}
+	return alert, true
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// 告警没有摘要时，用与公共标签不同的标签描述，例如 instance=node1
+func alertmanagerLabelSummary(labels, common map[string]string) string {
+	var parts []string
+	for key, value := range labels {
+		if common[key] != value {
+			parts = append(parts, key+"="+value)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
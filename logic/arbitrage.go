@@ -0,0 +1,133 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CEX 价差告警配置
+type ArbitrageConfig struct {
+	Enabled         bool    `json:"enabled"`
+	TickerURL       string  `json:"tickerURL"`       // Binance 兼容的行情接口，默认 https://api.binance.com/api/v3/ticker/price
+	Symbol          string  `json:"symbol"`          // token0 的交易对，例如 UNIBTCUSDT
+	QuoteSymbol     string  `json:"quoteSymbol"`     // token1 的交易对，例如 BTCUSDT，两者相除得到 token1 / token0 参考价
+	ThresholdBps    float64 `json:"thresholdBps"`    // 价差超过该基点数时告警
+	CacheSeconds    int     `json:"cacheSeconds"`    // CEX 价格缓存时间
+	CooldownMinutes int     `json:"cooldownMinutes"` // 告警最小间隔
+}
+
+// CEX 参考价的告警状态
+type cexPriceFeed struct {
+	mu          sync.Mutex
+	lastAlerted time.Time
+}
+
+var (
+	cexFeed       = &cexPriceFeed{}
+	cexPriceCache = newLookupCache[float64]("cex_price", false) // 行情接口与交易对 -> 参考价
+)
+
+func getArbitrageConfig() ArbitrageConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Arbitrage
+	if cfg.TickerURL == "" {
+		cfg.TickerURL = "https://api.binance.com/api/v3/ticker/price"
+	}
+	return cfg
+}
+
+// 观察每笔 Swap 的成交价与 CEX 价格的价差
+func observeArbitrage(ctx context.Context, event *SwapEvent) {
+	cfg := getArbitrageConfig()
+	if !cfg.Enabled || cfg.Symbol == "" || event.ExecutionPrice == nil {
+		return
+	}
+	cexPrice, err := cexFeed.get(ctx, cfg)
+	if err != nil {
+		slog.Error("Failed to fetch CEX price", "symbol", cfg.Symbol, "error", err)
+		return
+	}
+	poolPrice := decimalFloat(*event.ExecutionPrice)
+	spreadBps := (poolPrice/cexPrice - 1) * 10000
+	if math.Abs(spreadBps) <= cfg.ThresholdBps || !cexFeed.allow(cfg, event.Time) {
+		return
+	}
+
+	message := i18nText("alert.arbitrage", spreadBps, poolPrice, cexPrice)
+	slog.Warn("Arbitrage spread alert", "message", message)
+	sendAlert(ctx, message, barkLevelTimeSensitive)
+}
+
+// 获取 CEX 参考价，缓存期内直接返回缓存值
+func (f *cexPriceFeed) get(ctx context.Context, cfg ArbitrageConfig) (float64, error) {
+	key := cfg.TickerURL + "|" + cfg.Symbol + "/" + cfg.QuoteSymbol
+	return cexPriceCache.get(ctx, key, secondsOrDefault(cfg.CacheSeconds, 30), func() (float64, error) {
+		price, err := fetchTickerPrice(ctx, cfg.TickerURL, cfg.Symbol)
+		if err != nil {
+			return 0, err
+		}
+		if cfg.QuoteSymbol != "" {
+			quote, err := fetchTickerPrice(ctx, cfg.TickerURL, cfg.QuoteSymbol)
+			if err != nil {
+				return 0, err
+			}
+			if quote == 0 {
+				return 0, fmt.Errorf("quote price of %s is zero", cfg.QuoteSymbol)
+			}
+			price /= quote
+		}
+		return price, nil
+	})
+}
+
+func (f *cexPriceFeed) allow(cfg ArbitrageConfig, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cooldown := time.Duration(cfg.CooldownMinutes) * time.Minute
+	if cooldown <= 0 {
+		cooldown = 30 * time.Minute
+	}
+	if now.Sub(f.lastAlerted) < cooldown {
+		return false
+	}
+	f.lastAlerted = now
+	return true
+}
+
+// 查询单个交易对的最新价格
+func fetchTickerPrice(ctx context.Context, tickerURL, symbol string) (float64, error) {
+	sep := "?"
+	if strings.Contains(tickerURL, "?") {
+		sep = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tickerURL+sep+"symbol="+symbol, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("ticker %s returned %s", symbol, resp.Status)
+	}
+
+	var ticker struct {
+		Symbol string `json:"symbol"`
+		Price  string `json:"price"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ticker); err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(ticker.Price, 64)
+}
@@ -0,0 +1,68 @@
+package logic
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// 原子写入文件：先写入同目录下的临时文件并同步到磁盘，再重命名覆盖目标文件，
+// 写入过程中崩溃不会损坏原文件
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // 重命名成功后删除会失败，可以忽略
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// 同步目录，保证重命名已写入磁盘
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// 切换到新建的临时目录，返回切换回原目录并删除临时目录的函数。
+// 配置文件与数据文件使用相对路径，在临时目录中运行的命令不会修改当前目录的配置和数据
+func enterTempDir(prefix string) (leave func(), err error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", prefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return func() {
+		os.Chdir(wd)
+		os.RemoveAll(dir)
+	}, nil
+}
@@ -0,0 +1,135 @@
+package logic
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 通知决策审计配置
+type AuditConfig struct {
+	Path          string `json:"path"`          // 审计记录文件（JSON Lines），为空时只保存在内存中，audit 命令无法查询
+	RetentionDays int    `json:"retentionDays"` // 保留天数
+}
+
+// 通知决策
+const (
+	auditSent       = "sent"       // 已发送
+	auditSuppressed = "suppressed" // 未发送
+)
+
+// 未发送的原因
+const (
+	auditReasonInvalid    = "invalid"     // 无法解析
+	auditReasonRules      = "rules"       // 被过滤规则排除
+	auditReasonBelowLimit = "below_limit" // 成交额低于 limitPrice
+	auditReasonSpam       = "spam"        // 合并到小额刷单汇总
+	auditReasonScript     = "script"      // 被脚本钩子丢弃
+	auditReasonPaused     = "paused"      // 通知已通过管理接口暂停
+	auditReasonDuplicate  = "duplicate"   // 已处理过的交易
+	auditReasonError      = "error"       // 发送失败，下一轮重试
+)
+
+// 单笔 Swap 的通知决策
+type auditRecord struct {
+	Time      time.Time `json:"time"`
+	TxHash    string    `json:"txHash"`
+	Block     string    `json:"block"`
+	Decision  string    `json:"decision"`
+	Reason    string    `json:"reason,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	VolumeUSD string    `json:"volumeUSD,omitempty"`
+}
+
+func (r auditRecord) timestamp() time.Time { return r.Time }
+
+// 通知决策审计记录
+var auditLog = newTimeSeries[auditRecord](func() (string, time.Duration) {
+	cfg := getAuditConfig()
+	return cfg.Path, time.Duration(cfg.RetentionDays) * 24 * time.Hour
+})
+
+func getAuditConfig() AuditConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Audit
+	if cfg.RetentionDays <= 0 {
+		cfg.RetentionDays = 14
+	}
+	return cfg
+}
+
+// 记录已发送的 Swap 通知
+func auditNotified(swap *Swap, event *SwapEvent) {
+	record := auditRecord{Time: time.Now(), TxHash: swap.TransactionHash, Block: swap.BlockNumber, Decision: auditSent}
+	if event != nil {
+		record.VolumeUSD = event.Volume.StringFixed(2)
+	}
+	auditLog.append(record)
+}
+
+// 记录未发送的 Swap 及原因，event 在无法解析时为 nil
+func auditSuppressedSwap(swap *Swap, event *SwapEvent, reason, detail string) {
+	record := auditRecord{
+		Time:     time.Now(),
+		TxHash:   swap.TransactionHash,
+		Block:    swap.BlockNumber,
+		Decision: auditSuppressed,
+		Reason:   reason,
+		Detail:   detail,
+	}
+	if event != nil {
+		record.VolumeUSD = event.Volume.StringFixed(2)
+	}
+	auditLog.append(record)
+}
+
+// RunAudit 查询通知决策，用于排查预期的通知为什么没有收到
+func RunAudit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	since := fs.String("since", "24h", "查询最近多长时间的记录，支持 d 表示天，例如 7d、12h")
+	tx := fs.String("tx", "", "只显示指定交易哈希（支持前缀）")
+	decision := fs.String("decision", "", "只显示指定决策：sent / suppressed")
+	reason := fs.String("reason", "", "只显示指定原因，例如 rules、below_limit、spam")
+	format := fs.String("format", "table", "输出格式：table / jsonl")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	window, err := parseDays(*since)
+	if err != nil {
+		return err
+	}
+	if getAuditConfig().Path == "" {
+		return fmt.Errorf("audit log is not persisted, set audit.path in %s", configFile)
+	}
+
+	now := time.Now()
+	var records []auditRecord
+	for _, r := range auditLog.query(now.Add(-window), now.Add(time.Second)) {
+		if *tx != "" && !strings.HasPrefix(strings.ToLower(r.TxHash), strings.ToLower(*tx)) {
+			continue
+		}
+		if (*decision != "" && r.Decision != *decision) || (*reason != "" && r.Reason != *reason) {
+			continue
+		}
+		records = append(records, r)
+	}
+
+	switch *format {
+	case "jsonl":
+		return writeJSONLines(os.Stdout, records)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tTX\tBLOCK\tDECISION\tREASON\tVOLUME\tDETAIL")
+		for _, r := range records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Time.Format(time.RFC3339), r.TxHash, r.Block,
+				r.Decision, orDash(r.Reason), orDash(r.VolumeUSD), r.Detail)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+}
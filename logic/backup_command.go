@@ -0,0 +1,287 @@
+package logic
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// 备份文件中的条目
+const (
+	backupManifest      = "manifest.json"
+	backupConfig        = "app_config.json"
+	backupCursors       = "state/cursors.json"
+	backupSeen          = "state/seen.json"
+	backupSwaps         = "state/swaps.jsonl"
+	backupNotifications = "state/notifications.jsonl"
+	backupPrices        = "state/prices.jsonl"
+	backupSettings      = "state/settings.json"
+)
+
+// 备份描述信息
+type backupManifestData struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Driver    string    `json:"driver"` // 备份时使用的存储
+}
+
+// RunBackup 将配置、处理进度、已处理交易与历史记录打包为 tar.gz。
+// 状态通过 Store 接口导出，恢复时可以使用不同的存储
+func RunBackup(args []string) error {
+	defer CloseStore()
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := fs.String("out", "snapshot.tar.gz", "备份文件")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, stop := commandContext()
+	defer stop()
+
+	store := getStore()
+	entries := make(map[string][]byte)
+	var err error
+
+	if entries[backupConfig], err = os.ReadFile(configFile); err != nil {
+		return err
+	}
+	cursors, err := store.ListCursors(ctx)
+	if err != nil {
+		return err
+	}
+	if entries[backupCursors], err = json.MarshalIndent(cursors, "", "  "); err != nil {
+		return err
+	}
+	seen, err := store.ListSeen(ctx)
+	if err != nil {
+		return err
+	}
+	if entries[backupSeen], err = json.MarshalIndent(seen, "", "  "); err != nil {
+		return err
+	}
+
+	since, until := time.Unix(0, 0), time.Now().Add(time.Hour)
+	swaps, err := store.QuerySwaps(ctx, since, until)
+	if err != nil {
+		return err
+	}
+	if entries[backupSwaps], err = encodeJSONLines(swaps); err != nil {
+		return err
+	}
+	notifications, err := store.QueryNotifications(ctx, since, until)
+	if err != nil {
+		return err
+	}
+	if entries[backupNotifications], err = encodeJSONLines(notifications); err != nil {
+		return err
+	}
+	if entries[backupPrices], err = encodeJSONLines(priceHistory.query(since, until)); err != nil {
+		return err
+	}
+	settings, err := exportSettings(ctx, store)
+	if err != nil {
+		return err
+	}
+	if entries[backupSettings], err = json.MarshalIndent(settings, "", "  "); err != nil {
+		return err
+	}
+
+	manifest := backupManifestData{Version: 1, CreatedAt: time.Now(), Driver: getStorageConfig().Driver}
+	if entries[backupManifest], err = json.MarshalIndent(manifest, "", "  "); err != nil {
+		return err
+	}
+
+	if err := writeBackup(*out, entries); err != nil {
+		return err
+	}
+	fmt.Printf("backup written to %s: %d cursors, %d seen transactions, %d swaps, %d notifications\n",
+		*out, len(cursors), len(seen), len(swaps), len(notifications))
+	return nil
+}
+
+// RunRestore 从备份恢复配置与状态，状态写入恢复后配置中的存储
+func RunRestore(args []string) error {
+	defer CloseStore()
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	in := fs.String("in", "snapshot.tar.gz", "备份文件")
+	withConfig := fs.Bool("config", true, "同时恢复配置文件，为 false 时保留本机配置")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, stop := commandContext()
+	defer stop()
+
+	entries, err := readBackup(*in)
+	if err != nil {
+		return err
+	}
+	var manifest backupManifestData
+	if err := json.Unmarshal(entries[backupManifest], &manifest); err != nil {
+		return fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	if manifest.Version != 1 {
+		return fmt.Errorf("unsupported backup version %d", manifest.Version)
+	}
+
+	if *withConfig {
+		if err := writeFileAtomic(configFile, entries[backupConfig], 0644); err != nil {
+			return err
+		}
+		loadConfig()
+		CloseStore() // 按恢复后的配置重新打开存储
+	}
+	store := getStore()
+
+	var cursors map[string]Cursor
+	if err := json.Unmarshal(entries[backupCursors], &cursors); err != nil {
+		return err
+	}
+	for name, cursor := range cursors {
+		if err := store.SaveCursor(ctx, name, cursor); err != nil {
+			return err
+		}
+	}
+
+	var seen map[string]time.Time
+	if err := json.Unmarshal(entries[backupSeen], &seen); err != nil {
+		return err
+	}
+	for hash, seenAt := range seen {
+		if err := store.MarkSeen(ctx, []string{hash}, seenAt); err != nil {
+			return err
+		}
+	}
+	if getStorageConfig().Driver == storageDriverJSON {
+		// 配置文件存储的已处理交易只在保存处理进度时写入文件
+		saveConfig()
+	}
+
+	// 旧版备份中没有设置
+	if data, ok := entries[backupSettings]; ok {
+		var settings map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return err
+		}
+		if _, err := importSettings(ctx, store, settings); err != nil {
+			return err
+		}
+	}
+
+	// 历史记录只恢复到空的存储中，避免重复
+	since, until := time.Unix(0, 0), time.Now().Add(time.Hour)
+	existing, err := store.QuerySwaps(ctx, since, until)
+	if err != nil {
+		return err
+	}
+	swaps, notifications := 0, 0
+	if len(existing) == 0 {
+		if swaps, err = decodeJSONLines(entries[backupSwaps], func(r swapRecord) error { return store.AppendSwap(ctx, r) }); err != nil {
+			return err
+		}
+		if notifications, err = decodeJSONLines(entries[backupNotifications], func(r NotificationRecord) error {
+			return store.LogNotification(ctx, r)
+		}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("store already has %d swap records, skipping history\n", len(existing))
+	}
+	if len(priceHistory.query(since, until)) == 0 {
+		if _, err := decodeJSONLines(entries[backupPrices], func(r pricePointRecord) error {
+			priceHistory.append(r)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("restored backup from %s (%s): %d cursors, %d seen transactions, %d swaps, %d notifications\n",
+		manifest.CreatedAt.Format(time.RFC3339), manifest.Driver, len(cursors), len(seen), swaps, notifications)
+	return nil
+}
+
+func encodeJSONLines[T any](records []T) ([]byte, error) {
+	var buf bytes.Buffer
+	err := writeJSONLines(&buf, records)
+	return buf.Bytes(), err
+}
+
+// 逐行解码并交给 fn 处理，返回处理的条数
+func decodeJSONLines[T any](data []byte, fn func(T) error) (int, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	count := 0
+	for {
+		var record T
+		if err := decoder.Decode(&record); err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return count, err
+		}
+		if err := fn(record); err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+func writeBackup(path string, entries map[string][]byte) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range []string{backupManifest, backupConfig, backupCursors, backupSeen, backupSwaps, backupNotifications, backupPrices, backupSettings} {
+		data := entries[name]
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, buf.Bytes(), 0600)
+}
+
+func readBackup(path string) (map[string][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entries[header.Name], err = io.ReadAll(tr); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range []string{backupManifest, backupConfig, backupCursors, backupSeen} {
+		if _, ok := entries[name]; !ok {
+			return nil, fmt.Errorf("backup is missing %s", name)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,147 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Bark 推送级别
+const (
+	barkLevelCritical      = "critical"
+	barkLevelActive        = "active"
+	barkLevelTimeSensitive = "timeSensitive"
+	barkLevelPassive       = "passive"
+)
+
+// Bark 推送选项
+type barkOptions struct {
+	Title    string // 替换地址中的标题，为空时使用地址中配置的标题
+	Subtitle string // 副标题
+	Sound    string // 提示音
+	Level    string // 推送级别
+	URL      string // 点击推送后打开的链接
+	Copy     string // 复制到剪贴板的内容
+	Image    string // 推送中展示的图片地址
+}
+
+// 推送消息到指定的 Bark 地址列表
+func pushBark(ctx context.Context, targets []string, message string, opts barkOptions) {
+	for _, baseURL := range targets {
+		if err := pushBarkTarget(ctx, baseURL, message, opts); err != nil {
+			slog.Error("Notification failed", "target", maskTarget(baseURL), "error", err)
+		}
+	}
+}
+
+// 推送消息到单个 Bark 地址
+func pushBarkTarget(ctx context.Context, baseURL, message string, opts barkOptions) (err error) {
+	ctx, span := startSpan(ctx, "bark.push", attribute.String("server.address", urlHost(baseURL)),
+		attribute.String("bark.level", opts.Level))
+	defer func() {
+		recordChannelResult("bark", maskTarget(baseURL), time.Now(), err)
+		endSpan(span, err)
+	}()
+	if opts.Level == "" {
+		opts.Level = barkLevelActive
+	}
+	if opts.Title != "" {
+		baseURL = barkURLWithTitle(baseURL, opts.Title)
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	query := "?level=" + opts.Level
+	if opts.Level == barkLevelCritical {
+		query += "&call=1"
+	}
+	if opts.Subtitle != "" {
+		query += "&subtitle=" + url.QueryEscape(opts.Subtitle)
+	}
+	if opts.Sound != "" {
+		query += "&sound=" + url.QueryEscape(opts.Sound)
+	}
+	if opts.URL != "" {
+		query += "&url=" + url.QueryEscape(opts.URL)
+	}
+	if opts.Copy != "" {
+		query += "&copy=" + url.QueryEscape(opts.Copy)
+	}
+	if opts.Image != "" {
+		query += "&image=" + url.QueryEscape(opts.Image)
+	}
+	// 地址总长度超出上限时截断消息，消息编码后计算长度
+	if limit := channelLimit(channelBark); limit > 0 {
+		budget := max(limit-len(baseURL)-len(query), 1)
+		message = truncateMessage(message, formatPlain, budget, func(s string) int { return len(escapeBarkSegment(s)) })
+	}
+	// 地址中包含设备密钥，日志和错误中只使用 maskTarget 后的地址
+	pushURL := baseURL + escapeBarkSegment(message) + query
+	req, err := http.NewRequestWithContext(withChaosTarget(ctx, channelBark), http.MethodGet, pushURL, nil)
+	if err != nil {
+		return errors.New("invalid bark url")
+	}
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("bark %s: %w", maskTarget(baseURL), urlErr.Err)
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bark returned %s", resp.Status)
+	}
+	slog.Info("Notification sent successfully", "target", maskTarget(baseURL))
+	return nil
+}
+
+// 编码为单个路径段。除字母数字和 -_.~ 外全部转义，/、?、# 不会截断消息，
+// 空格编码为 %20，$、+、& 等字符也不会被服务端或代理当作分隔符
+func escapeBarkSegment(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// 将 Bark 地址中的标题替换为指定标题，地址格式为 https://api.day.app/{key}/{title}/
+func barkURLWithTitle(baseURL, title string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) == 0 || segments[0] == "" {
+		return baseURL
+	}
+	u.Path = "/" + segments[0] + "/" + title + "/"
+	u.RawPath = "/" + escapeBarkSegment(segments[0]) + "/" + escapeBarkSegment(title) + "/"
+	return u.String()
+}
+
+// 订阅者的语言与默认语言不同时，用语言包中的标题替换地址中按默认语言配置的标题
+func localizedBarkOptions(opts barkOptions, lang, kind string) barkOptions {
+	if opts.Title == "" && lang != defaultLanguage() {
+		opts.Title = translate(lang, "bark.title."+kind)
+	}
+	return opts
+}
+
+// 推送一条系统告警到订阅者、Telegram 会话和 Alertmanager，按订阅者的语言渲染
+func sendAlert(ctx context.Context, message localizedText, level string) {
+	now := time.Now()
+	for _, group := range subscriberGroups(ctx, nil, level, now) {
+		data := messageTemplateData{localeData: group.localeData, Message: message.in(group.Lang), Level: level, Time: now, TimeText: group.FormatTime(now)}
+		notice := renderNotification(templateAlert, channelBark, data)
+		pushBark(ctx, group.Bark, notice.Body, localizedBarkOptions(notice.barkOptions(barkOptions{Level: level}), group.Lang, templateAlert))
+		pushTelegram(ctx, nil, renderNotification(templateAlert, channelTelegram, data).telegramText(channelFormat(channelTelegram)), group.Chats)
+		logNotification(ctx, "", group.Bark, notice.Body)
+	}
+	forwardAlertmanager(ctx, systemAlertmanagerAlert(message.String(), level))
+}
@@ -0,0 +1,134 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// 获取-解析-格式化-推送 热路径的基准测试，获取和推送使用本地模拟的 Graph API 和 Bark 服务，不访问网络。
+// 与之前的结果比较时使用 benchstat：
+//
+//	go test ./logic -run '^$' -bench . -count 10 > new.txt
+//	benchstat old.txt new.txt
+
+// 基准测试与集成测试期间只保留警告以上的日志
+func quietLogs(tb testing.TB) {
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	tb.Cleanup(func() { slog.SetDefault(previous) })
+}
+
+// 内置的示例交易，补上 1:1 的价格以覆盖池子价格的计算
+func benchSwaps(b *testing.B) []Swap {
+	quietLogs(b)
+	swap := sampleSwap(time.Now())
+	swap.SqrtPriceX96 = "79228162514264337593543950336"
+	return []Swap{swap}
+}
+
+func benchEvent(b *testing.B) *SwapEvent {
+	swaps := benchSwaps(b)
+	event, err := normalizeSwap(&swaps[0])
+	if err != nil {
+		b.Fatal(err)
+	}
+	return event
+}
+
+func benchGraphBody(b *testing.B, swaps []Swap) []byte {
+	var page GraphResponse
+	page.Data.Swaps = swaps
+	body, err := json.Marshal(page)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return body
+}
+
+func BenchmarkFetch(b *testing.B) {
+	swaps := benchSwaps(b)
+	body := benchGraphBody(b, swaps)
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	defer graph.Close()
+	ctx := context.Background()
+	query := swapsQuery(len(swaps), 0, false, true)
+	page := new(GraphResponse)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := querySwaps(ctx, graph.URL, query, page); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	body := benchGraphBody(b, benchSwaps(b))
+	page := new(GraphResponse)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		page.reset()
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(page); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNormalize(b *testing.B) {
+	swaps := benchSwaps(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := normalizeSwap(&swaps[i%len(swaps)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFormat(b *testing.B) {
+	swaps := benchSwaps(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := FormatSwap(&swaps[i%len(swaps)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	data := newSwapTemplateData(context.Background(), benchEvent(b))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		renderNotification(templateSwap, channelBark, data)
+		renderNotification(templateSwap, channelTelegram, data)
+	}
+}
+
+func BenchmarkPush(b *testing.B) {
+	event := benchEvent(b)
+	bark := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer bark.Close()
+	ctx := context.Background()
+	message := formatSwapEvent(ctx, event)
+	opts := barkOptions{Level: barkLevelActive, URL: "https://etherscan.io/tx/" + event.Swap.TransactionHash}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := pushBarkTarget(ctx, bark.URL+"/key/", message, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
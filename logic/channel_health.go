@@ -0,0 +1,68 @@
+package logic
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 单个推送渠道最近的推送结果
+type ChannelHealth struct {
+	Channel     string    `json:"channel"` // 渠道类型，例如 bark
+	Target      string    `json:"target"`  // 隐去密钥后的地址
+	LastSuccess time.Time `json:"lastSuccess,omitempty"`
+	LastFailure time.Time `json:"lastFailure,omitempty"`
+	LastError   string    `json:"lastError,omitempty"`
+	Failures    int       `json:"failures"` // 连续失败次数
+	Sent        int64     `json:"sent"`
+}
+
+var (
+	channelHealth      = make(map[string]*ChannelHealth)
+	channelHealthMutex sync.Mutex
+)
+
+// 记录一次推送结果，target 中不能包含密钥
+func recordChannelResult(channel, label string, at time.Time, err error) {
+	channelHealthMutex.Lock()
+	defer channelHealthMutex.Unlock()
+	health, ok := channelHealth[channel+" "+label]
+	if !ok {
+		health = &ChannelHealth{Channel: channel, Target: label}
+		channelHealth[channel+" "+label] = health
+	}
+	if err != nil {
+		health.LastFailure, health.LastError = at, err.Error()
+		health.Failures++
+		return
+	}
+	health.LastSuccess, health.Failures = at, 0
+	health.Sent++
+}
+
+// 返回所有渠道的推送结果
+func listChannelHealth() []ChannelHealth {
+	channelHealthMutex.Lock()
+	defer channelHealthMutex.Unlock()
+	list := make([]ChannelHealth, 0, len(channelHealth))
+	for _, health := range channelHealth {
+		list = append(list, *health)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Channel+list[i].Target < list[j].Channel+list[j].Target })
+	return list
+}
+
+// 地址路径的第一段通常是设备密钥或令牌，只保留前 4 个字符
+func maskTarget(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(invalid url)"
+	}
+	key, _, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if len(key) > 4 {
+		key = key[:4] + "…"
+	}
+	return u.Host + "/" + key
+}
@@ -0,0 +1,152 @@
+package logic
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 故障注入配置，用于验证重试、退避与故障转移。开启后 Graph 查询与推送请求按概率返回模拟的故障，
+// 概率为 0 到 1 之间的小数，只应在测试环境中开启
+type ChaosConfig struct {
+	Enabled        bool    `json:"enabled"`
+	Seed           int64   `json:"seed"`           // 随机数种子，为 0 时使用当前时间
+	GraphTimeout   float64 `json:"graphTimeout"`   // Graph 查询无响应直到请求超时
+	GraphMalformed float64 `json:"graphMalformed"` // Graph 返回截断的 JSON
+	GraphRateLimit float64 `json:"graphRateLimit"` // Graph 返回 429
+	ChannelFailure float64 `json:"channelFailure"` // Bark 与 Telegram 推送返回 503
+}
+
+func getChaosConfig() ChaosConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Chaos
+}
+
+// 注入故障的请求类型：Graph 查询，或 channelBark、channelTelegram 推送
+const chaosGraph = "graph"
+
+// 注入的故障
+const (
+	chaosFaultTimeout   = "timeout"
+	chaosFaultMalformed = "malformed"
+	chaosFaultRateLimit = "rate_limit"
+	chaosFaultFailure   = "failure"
+)
+
+type chaosTargetKey struct{}
+
+// 标记请求类型，只有标记过的请求会注入故障
+func withChaosTarget(ctx context.Context, target string) context.Context {
+	return context.WithValue(ctx, chaosTargetKey{}, target)
+}
+
+var (
+	chaosCounts      = make(map[string]int64) // 请求类型 -> 已注入的故障次数
+	chaosCountsMutex sync.Mutex
+)
+
+// 各请求类型已注入的故障次数
+func chaosFaultCounts() map[string]int64 {
+	chaosCountsMutex.Lock()
+	defer chaosCountsMutex.Unlock()
+	counts := make(map[string]int64, len(chaosCounts))
+	for target, n := range chaosCounts {
+		counts[target] = n
+	}
+	return counts
+}
+
+// 按配置的概率替换请求结果的 Transport，由 getHTTPClient 在开启时包装
+type chaosTransport struct {
+	next http.RoundTripper
+	cfg  ChaosConfig
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newChaosTransport(next http.RoundTripper, cfg ChaosConfig) *chaosTransport {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &chaosTransport{next: next, cfg: cfg, rand: rand.New(rand.NewSource(seed))}
+}
+
+// 按请求类型抽取本次注入的故障，不注入时返回空字符串
+func (t *chaosTransport) pick(target string) string {
+	t.mu.Lock()
+	r := t.rand.Float64()
+	t.mu.Unlock()
+	switch target {
+	case chaosGraph:
+		switch {
+		case r < t.cfg.GraphTimeout:
+			return chaosFaultTimeout
+		case r < t.cfg.GraphTimeout+t.cfg.GraphMalformed:
+			return chaosFaultMalformed
+		case r < t.cfg.GraphTimeout+t.cfg.GraphMalformed+t.cfg.GraphRateLimit:
+			return chaosFaultRateLimit
+		}
+	case channelBark, channelTelegram:
+		if r < t.cfg.ChannelFailure {
+			return chaosFaultFailure
+		}
+	}
+	return ""
+}
+
+func (t *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, _ := req.Context().Value(chaosTargetKey{}).(string)
+	fault := t.pick(target)
+	if fault == "" {
+		return t.next.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	chaosCountsMutex.Lock()
+	chaosCounts[target]++
+	chaosCountsMutex.Unlock()
+	slog.Warn("Chaos fault injected", "target", target, "fault", fault, "host", req.URL.Host)
+
+	switch fault {
+	case chaosFaultTimeout:
+		// 不返回任何内容，直到客户端的请求超时或调用方取消
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	case chaosFaultMalformed:
+		return chaosResponse(req, http.StatusOK, `{"data":{"swaps":[{"id":"0x`), nil
+	case chaosFaultRateLimit:
+		return chaosResponse(req, http.StatusTooManyRequests, "rate limited (chaos)"), nil
+	default:
+		return chaosResponse(req, http.StatusServiceUnavailable, "service unavailable (chaos)"), nil
+	}
+}
+
+// 配置变更时关闭底层 Transport 的空闲连接
+func (t *chaosTransport) CloseIdleConnections() {
+	if c, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+func chaosResponse(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/plain"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,139 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+)
+
+// 价格与成交量图表配置
+type ChartConfig struct {
+	Enabled   bool   `json:"enabled"`
+	OutputDir string `json:"outputDir"` // 图片保存目录
+	PublicURL string `json:"publicURL"` // 图片目录对外访问的地址，为空时不附加到通知
+	Hours     int    `json:"hours"`     // 图表时间范围（小时）
+}
+
+// 同一分钟内复用已生成的图表
+var (
+	lastChartFile   string
+	lastChartMinute int64
+	chartMutex      sync.Mutex
+)
+
+func getChartConfig() ChartConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Chart
+	if cfg.OutputDir == "" {
+		cfg.OutputDir = "charts"
+	}
+	if cfg.Hours <= 0 {
+		cfg.Hours = 24
+	}
+	return cfg
+}
+
+// 生成图表并返回对外访问地址，未启用或失败时返回空字符串
+func chartImageURL(ctx context.Context, now time.Time) string {
+	cfg := getChartConfig()
+	if !cfg.Enabled || cfg.PublicURL == "" {
+		return ""
+	}
+	file, err := renderChart(ctx, cfg, now)
+	if err != nil {
+		slog.Error("Failed to render chart", "error", err)
+		return ""
+	}
+	return strings.TrimRight(cfg.PublicURL, "/") + "/" + filepath.Base(file)
+}
+
+// 渲染最近一段时间的价格与成交量图表
+func renderChart(ctx context.Context, cfg ChartConfig, now time.Time) (string, error) {
+	chartMutex.Lock()
+	defer chartMutex.Unlock()
+
+	minute := now.Unix() / 60
+	if lastChartFile != "" && lastChartMinute == minute {
+		return lastChartFile, nil
+	}
+
+	since := now.Add(-time.Duration(cfg.Hours) * time.Hour)
+	prices := priceHistory.query(since, now.Add(time.Second))
+	swaps := querySwapHistory(ctx, since, now.Add(time.Second))
+	if len(prices) < 2 {
+		return "", fmt.Errorf("not enough price points to render chart")
+	}
+
+	priceSeries := chart.TimeSeries{Name: "Price", Style: chart.Style{StrokeColor: chart.ColorBlue, StrokeWidth: 2}}
+	for _, p := range prices {
+		priceSeries.XValues = append(priceSeries.XValues, p.Time)
+		priceSeries.YValues = append(priceSeries.YValues, p.Price)
+	}
+
+	// 按小时汇总成交额
+	volumeSeries := chart.TimeSeries{
+		Name:  "Volume",
+		YAxis: chart.YAxisSecondary,
+		Style: chart.Style{StrokeColor: chart.ColorAlternateGray, FillColor: chart.ColorAlternateGray.WithAlpha(64)},
+	}
+	for hour := since.Truncate(time.Hour); !hour.After(now); hour = hour.Add(time.Hour) {
+		var volume float64
+		for _, s := range swaps {
+			if !s.Time.Before(hour) && s.Time.Before(hour.Add(time.Hour)) {
+				volume += s.VolumeUSD
+			}
+		}
+		volumeSeries.XValues = append(volumeSeries.XValues, hour)
+		volumeSeries.YValues = append(volumeSeries.YValues, volume)
+	}
+
+	graph := chart.Chart{
+		Width:  800,
+		Height: 400,
+		XAxis:  chart.XAxis{ValueFormatter: chart.TimeHourValueFormatter},
+		YAxis:  chart.YAxis{Name: "Price", ValueFormatter: func(v interface{}) string { return fmt.Sprintf("%.5f", v) }},
+		YAxisSecondary: chart.YAxis{
+			Name:           "Volume (USD)",
+			ValueFormatter: func(v interface{}) string { return fmt.Sprintf("%.0f", v) },
+		},
+		Series: []chart.Series{volumeSeries, priceSeries},
+	}
+
+	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+		return "", err
+	}
+	file := filepath.Join(cfg.OutputDir, fmt.Sprintf("chart-%d.png", minute))
+	out, err := os.Create(file)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	if err := graph.Render(chart.PNG, out); err != nil {
+		return "", err
+	}
+
+	pruneCharts(cfg.OutputDir, now.Add(-24*time.Hour))
+	lastChartFile, lastChartMinute = file, minute
+	return file, nil
+}
+
+// 删除过期的图表文件
+func pruneCharts(dir string, before time.Time) {
+	files, err := filepath.Glob(filepath.Join(dir, "chart-*.png"))
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && info.ModTime().Before(before) {
+			os.Remove(file)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// 表达式中可使用的变量，例如 vol_usd > 50000 && token_in == "WBTC" && hour(ts) >= 9 && actor_kind != "mevbot"
+type conditionEnv struct {
+	VolUSD    float64   `expr:"vol_usd"`
+	AmountIn  float64   `expr:"amount_in"`
+	AmountOut float64   `expr:"amount_out"`
+	TokenIn   string    `expr:"token_in"`
+	TokenOut  string    `expr:"token_out"`
+	Direction string    `expr:"direction"`
+	Sender    string    `expr:"sender"`
+	Recipient string    `expr:"recipient"`
+	Tick      int       `expr:"tick"`
+	Block     int       `expr:"block"`
+	BtcPrice  float64   `expr:"btc_price"`
+	TxHash    string    `expr:"tx_hash"`
+	Ts        time.Time `expr:"ts"`
+	PoolPrice float64   `expr:"pool_price"`
+	ImpactPct float64   `expr:"impact_pct"`
+	ActorKind string    `expr:"actor_kind"`
+}
+
+// 编译后的条件
+type compiledCondition struct {
+	source  string
+	program *vm.Program
+}
+
+var (
+	conditions      []compiledCondition // 当前生效的条件
+	conditionsErr   error               // 条件编译失败时的错误，此时所有交易都被过滤
+	conditionsMutex sync.RWMutex
+)
+
+// 表达式中可用的时间函数，按北京时间计算
+var conditionFunctions = []expr.Option{
+	expr.Function("hour", func(params ...interface{}) (interface{}, error) {
+		return conditionTime(params[0]).Hour(), nil
+	}, new(func(time.Time) int)),
+	expr.Function("minute", func(params ...interface{}) (interface{}, error) {
+		return conditionTime(params[0]).Minute(), nil
+	}, new(func(time.Time) int)),
+	expr.Function("weekday", func(params ...interface{}) (interface{}, error) {
+		return int(conditionTime(params[0]).Weekday()), nil
+	}, new(func(time.Time) int)),
+}
+
+func conditionTime(v interface{}) time.Time {
+	return v.(time.Time).In(defaultLocation())
+}
+
+// 编译条件表达式，任一表达式编译失败时返回全部错误
+func compileConditions(sources []string) ([]compiledCondition, error) {
+	compiled := make([]compiledCondition, 0, len(sources))
+	var errs []error
+	for _, source := range sources {
+		options := append([]expr.Option{expr.Env(conditionEnv{}), expr.AsBool()}, conditionFunctions...)
+		program, err := expr.Compile(source, options...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("condition %q: %w", source, err))
+			continue
+		}
+		compiled = append(compiled, compiledCondition{source: source, program: program})
+	}
+	return compiled, errors.Join(errs...)
+}
+
+// 应用配置中的条件。读取配置文件时已检查过表达式，这里仍编译失败时（例如直接应用的配置）
+// 过滤所有交易并推送告警，避免条件写错时变成全部通知
+func applyConditions(sources []string) {
+	compiled, err := compileConditions(sources)
+	conditionsMutex.Lock()
+	conditions, conditionsErr = compiled, err
+	conditionsMutex.Unlock()
+	if err == nil {
+		return
+	}
+	slog.Error("Invalid rules.conditions, blocking all swaps", "error", err)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		sendAlert(ctx, i18nText("alert.invalidConditions"), barkLevelTimeSensitive)
+	}()
+}
+
+func getConditions() ([]compiledCondition, error) {
+	conditionsMutex.RLock()
+	defer conditionsMutex.RUnlock()
+	return conditions, conditionsErr
+}
+
+// 构造表达式执行环境
+func newConditionEnv(event *SwapEvent) conditionEnv {
+	amountIn := decimalFloat(event.AmountIn)
+	amountOut := decimalFloat(event.AmountOut)
+	env := conditionEnv{
+		VolUSD:    event.VolumeUSD(),
+		AmountIn:  amountIn,
+		AmountOut: amountOut,
+		TokenIn:   event.TokenIn,
+		TokenOut:  event.TokenOut,
+		Direction: event.Direction,
+		Sender:    event.Swap.Sender,
+		Recipient: event.Swap.Recipient,
+		Tick:      int(event.Swap.Tick),
+		TxHash:    event.Swap.TransactionHash,
+		Ts:        event.Time,
+		ActorKind: swapActorKind(event.Swap),
+	}
+	if event.PoolPrice != nil {
+		env.PoolPrice = decimalFloat(*event.PoolPrice)
+	}
+	if event.PriceImpact != nil {
+		impact := decimalFloat(*event.PriceImpact)
+		env.ImpactPct = impact * 100
+	}
+	fmt.Sscan(event.Swap.BlockNumber, &env.Block)
+	fmt.Sscan(event.Swap.BtcPrice, &env.BtcPrice)
+	return env
+}
+
+// 配置了条件时，至少满足其中一个才通知
+func conditionRule(event *SwapEvent, _ RulesConfig) (bool, string) {
+	compiled, err := getConditions()
+	if err != nil {
+		return false, "invalid conditions"
+	}
+	if len(compiled) == 0 {
+		return true, ""
+	}
+
+	env := newConditionEnv(event)
+	for _, c := range compiled {
+		result, err := expr.Run(c.program, env)
+		if err != nil {
+			slog.Error("Failed to evaluate condition", "condition", c.source, "error", err)
+			continue
+		}
+		if matched, _ := result.(bool); matched {
+			return true, ""
+		}
+	}
+	return false, "no condition matched"
+}
@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// RunCursorCommand 查看或回退处理进度
+//
+//	cursor list               列出所有处理进度
+//	cursor set <name> <block> 将处理进度设置为指定区块，下一轮从该区块之后重新处理
+func RunCursorCommand(args []string) error {
+	defer CloseStore()
+	ctx, stop := commandContext()
+	defer stop()
+	if len(args) == 0 {
+		return fmt.Errorf("usage: cursor list | cursor set <name> <block>")
+	}
+
+	switch args[0] {
+	case "list":
+		return listCursors(ctx)
+	case "set":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: cursor set <name> <block>")
+		}
+		return setCursor(ctx, args[1], args[2])
+	default:
+		return fmt.Errorf("unknown cursor command %q", args[0])
+	}
+}
+
+func listCursors(ctx context.Context) error {
+	cursors, err := getStore().ListCursors(ctx)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(cursors))
+	for name := range cursors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tBLOCK\tUPDATED")
+	for _, name := range names {
+		cursor := cursors[name]
+		updated := "-"
+		if !cursor.UpdatedAt.IsZero() {
+			updated = cursor.UpdatedAt.Format(time.RFC3339)
+		}
+		marker := ""
+		if name == swapCursorName() {
+			marker = " (active)"
+		}
+		fmt.Fprintf(w, "%s%s\t%s\t%s\n", name, marker, cursor.LastBlockNumber, updated)
+	}
+	return w.Flush()
+}
+
+func setCursor(ctx context.Context, name, block string) error {
+	if _, err := strconv.ParseUint(block, 10, 64); err != nil {
+		return fmt.Errorf("invalid block number %q", block)
+	}
+	previous, err := state.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := state.Set(ctx, name, Cursor{LastBlockNumber: block}); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s -> %s\n", name, previous.LastBlockNumber, block)
+	return nil
+}
@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// 管理页面，页面本身不包含数据，数据通过管理接口获取，配置了令牌时在页面中输入
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// 挂载管理页面 /dashboard/，静态文件不校验令牌
+func registerDashboard(mux *http.ServeMux) {
+	files, _ := fs.Sub(dashboardFiles, "dashboard")
+	var handler http.Handler = http.StripPrefix("/dashboard/", http.FileServerFS(files))
+	if len(getAdminConfig().BasicAuth) > 0 {
+		handler = requireScope(scopeRead, false, handler)
+	}
+	mux.Handle("GET /dashboard/", handler)
+	mux.Handle("GET /{$}", http.RedirectHandler("/dashboard/", http.StatusFound))
+}
+
+// 配置中不展示的字段，内容较多且与运行状态无关
+var hiddenConfigKeys = map[string]bool{"seenTxHashes": true, "currentTxHashes": true}
+
+// 可能包含密钥的字段
+var secretConfigKey = regexp.MustCompile(`(?i)(token|password|secret|dsn|key|urls?|targets|webhook)`)
+
+// 返回隐去密钥后的当前配置
+func redactedConfig() (map[string]interface{}, error) {
+	configMutex.RLock()
+	data, err := json.Marshal(&configData)
+	configMutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]interface{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	for key := range hiddenConfigKeys {
+		delete(config, key)
+	}
+	return redactValue("", config).(map[string]interface{}), nil
+}
+
+func redactValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = redactValue(k, item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(key, item)
+		}
+		return v
+	case string:
+		if v == "" || !secretConfigKey.MatchString(key) {
+			return v
+		}
+		// 地址只保留主机名和密钥前缀，便于确认配置的是哪个地址
+		if strings.Contains(v, "://") {
+			return maskTarget(v)
+		}
+		return "******"
+	default:
+		return v
+	}
+}
@@ -0,0 +1,122 @@
+// 管理页面：定时从管理接口获取数据，令牌保存在浏览器本地
+const tokenInput = document.getElementById("token");
+tokenInput.value = localStorage.getItem("adminToken") || "";
+document.getElementById("token-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  localStorage.setItem("adminToken", tokenInput.value);
+  refresh();
+});
+
+async function api(path) {
+  const headers = {};
+  const token = localStorage.getItem("adminToken");
+  if (token) {
+    headers.Authorization = "Bearer " + token;
+  }
+  const resp = await fetch(path, { headers });
+  const body = await resp.json();
+  if (!resp.ok) {
+    throw new Error(body.error || resp.statusText);
+  }
+  return body;
+}
+
+function formatTime(value) {
+  if (!value || value.startsWith("0001-")) {
+    return "-";
+  }
+  return new Date(value).toLocaleString();
+}
+
+function shortHash(hash) {
+  return hash ? hash.slice(0, 10) + "…" : "-";
+}
+
+// 以文本方式填充表格，避免消息内容被当作 HTML
+function fillTable(id, rows, cells) {
+  const tbody = document.querySelector("#" + id + " tbody");
+  tbody.replaceChildren(...rows.map((row) => {
+    const tr = document.createElement("tr");
+    for (const [text, className] of cells(row)) {
+      const td = document.createElement("td");
+      td.textContent = text;
+      if (className) {
+        td.className = className;
+      }
+      tr.appendChild(td);
+    }
+    return tr;
+  }));
+}
+
+function renderStatus(status) {
+  const items = {
+    "运行时间": status.uptime,
+    "主实例": status.leader ? "是" : "否",
+    "存储": status.storeDriver,
+    "处理进度": status.cursor + " @ " + status.lastBlock,
+    "最近查询": formatTime(status.lastQuery),
+    "日志级别": status.logLevel,
+    "通知": status.notifyPaused ? "已暂停至 " + formatTime(status.pausedUntil) : "正常",
+    "失败的任务": status.tasksFailing.join(", ") || "-",
+    "暂停的任务": status.tasksPaused.join(", ") || "-",
+  };
+  const dl = document.getElementById("status");
+  dl.replaceChildren();
+  for (const [name, value] of Object.entries(items)) {
+    const dt = document.createElement("dt");
+    dt.textContent = name;
+    const dd = document.createElement("dd");
+    dd.textContent = value;
+    dl.append(dt, dd);
+  }
+  document.getElementById("status-line").textContent = status.notifyPaused ? "通知已暂停" : "";
+}
+
+function renderChart(prices) {
+  const svg = document.getElementById("chart");
+  if (prices.length < 2) {
+    svg.innerHTML = '<text x="10" y="20">暂无价格数据</text>';
+    return;
+  }
+  const times = prices.map((p) => new Date(p.time).getTime());
+  const values = prices.map((p) => p.price);
+  const minT = Math.min(...times), maxT = Math.max(...times);
+  const minV = Math.min(...values), maxV = Math.max(...values);
+  const x = (t) => ((t - minT) / (maxT - minT || 1)) * 780 + 10;
+  const y = (v) => 230 - ((v - minV) / (maxV - minV || 1)) * 210;
+  const points = prices.map((p, i) => x(times[i]).toFixed(1) + "," + y(values[i]).toFixed(1)).join(" ");
+  svg.innerHTML =
+    '<polyline fill="none" stroke="#2563eb" stroke-width="2" points="' + points + '"/>' +
+    '<text x="10" y="14" font-size="12">' + maxV.toFixed(5) + "</text>" +
+    '<text x="10" y="236" font-size="12">' + minV.toFixed(5) + "</text>";
+}
+
+async function refresh() {
+  const results = await Promise.allSettled([
+    api("/status").then(renderStatus),
+    api("/prices?since=24h").then(renderChart),
+    api("/channels").then((channels) => fillTable("channels", channels, (c) => [
+      [c.channel], [c.target], [formatTime(c.lastSuccess)], [formatTime(c.lastFailure)],
+      [String(c.failures), c.failures > 0 ? "fail" : ""], [c.lastError || "-", "message"],
+    ])),
+    api("/swaps?limit=50").then((swaps) => fillTable("swaps", swaps, (s) => [
+      [formatTime(s.time)], [s.direction],
+      [s.amountIn.toFixed(5) + " " + s.tokenIn + " → " + s.amountOut.toFixed(5) + " " + s.tokenOut],
+      ["$" + s.volumeUSD.toFixed(2)], [s.price.toFixed(5)], [shortHash(s.txHash)],
+    ])),
+    api("/notifications?limit=50").then((records) => fillTable("notifications", records, (n) => [
+      [formatTime(n.time)], [shortHash(n.txHash)], [n.message, "message"],
+    ])),
+    api("/config").then((config) => {
+      document.getElementById("config").textContent = JSON.stringify(config, null, 2);
+    }),
+  ]);
+  const failed = results.find((r) => r.status === "rejected");
+  if (failed) {
+    document.getElementById("status-line").textContent = "加载失败：" + failed.reason.message;
+  }
+}
+
+refresh();
+setInterval(refresh, 15000);
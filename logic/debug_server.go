@@ -0,0 +1,55 @@
+package logic
+
+import (
+	"expvar"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// 调试接口配置
+type DebugConfig struct {
+	Listen string `json:"listen"` // 监听地址，为空时不启动，建议只监听本机，例如 127.0.0.1:6060
+}
+
+func getDebugConfig() DebugConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Debug
+}
+
+var startedAt = time.Now()
+
+func init() {
+	expvar.Publish("goroutines", expvar.Func(func() interface{} { return runtime.NumGoroutine() }))
+	expvar.Publish("uptime_seconds", expvar.Func(func() interface{} { return int64(time.Since(startedAt).Seconds()) }))
+	expvar.Publish("tasks", expvar.Func(func() interface{} { return listTasks() }))
+	expvar.Publish("leader", expvar.Func(func() interface{} { return leading() }))
+	expvar.Publish("delivery_queues", expvar.Func(func() interface{} { return listDeliveryQueues() }))
+}
+
+// 在单独的地址上提供 pprof 与 expvar，用于排查内存增长和 goroutine 泄漏，需要 admin 范围，HTTPS 配置与管理接口共用
+//
+//	GET /debug/pprof/  性能分析，例如 go tool pprof http://127.0.0.1:6060/debug/pprof/heap
+//	GET /debug/vars    运行时内存统计、goroutine 数量与任务状态
+func startDebugServer() {
+	cfg := getDebugConfig()
+	if cfg.Listen == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	tlsConfig, err := loadServerTLS()
+	if err != nil {
+		slog.Error("Failed to start debug server", "error", err)
+		return
+	}
+	serveHTTP("debug", cfg.Listen, requireScope(scopeAdmin, false, mux), tlsConfig)
+}
@@ -0,0 +1,453 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 推送队列配置。Swap 通知按渠道进入有界队列，由每个渠道的发送协程依次发送，渠道变慢时不会无限堆积。
+// 队列满时的处理方式：
+//
+//	block   阻塞获取，超过本轮任务的超时后放弃，进度不前进，下一轮重新处理
+//	digest  合并为一行摘要，队列消化到一半以下时作为一条汇总通知发送
+//	drop    丢弃推送级别最低的通知（包括新通知本身）
+type DeliveryConfig struct {
+	QueueSize          int    `json:"queueSize"`          // 每个渠道的队列长度，默认 100
+	Overflow           string `json:"overflow"`           // 队列满时的处理方式 block / digest / drop，默认 block
+	SendTimeoutSeconds int    `json:"sendTimeoutSeconds"` // 单条通知的发送超时，默认 30
+}
+
+// 队列满时的处理方式
+const (
+	overflowBlock  = "block"
+	overflowDigest = "digest"
+	overflowDrop   = "drop"
+)
+
+// 合并为汇总时最多列出的摘要行数
+const maxCollapsedLines = 20
+
+func getDeliveryConfig() DeliveryConfig {
+	configMutex.RLock()
+	cfg := configData.Delivery
+	configMutex.RUnlock()
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	switch cfg.Overflow {
+	case overflowBlock, overflowDigest, overflowDrop:
+	default:
+		cfg.Overflow = overflowBlock
+	}
+	if cfg.SendTimeoutSeconds <= 0 {
+		cfg.SendTimeoutSeconds = 30
+	}
+	return cfg
+}
+
+// 推送级别的优先顺序，丢弃时先丢弃级别低的
+var barkLevelRank = map[string]int{
+	barkLevelPassive:       0,
+	barkLevelActive:        1,
+	barkLevelTimeSensitive: 2,
+	barkLevelCritical:      3,
+}
+
+func levelRank(level string) int {
+	if rank, ok := barkLevelRank[level]; ok {
+		return rank
+	}
+	return barkLevelRank[barkLevelActive]
+}
+
+// 一条待发送的通知
+type deliveryJob struct {
+	ctx     context.Context // 入队时的上下文，只用于链路追踪，不继承取消
+	level   string          // Bark 推送级别
+	key     string          // 接收目标，合并时相同目标的摘要合为一条
+	summary string          // 合并时使用的一行摘要
+	send    func(ctx context.Context)
+	digest  func(ctx context.Context, level string, lines []string, collapsed int) // 发送合并后的汇总
+}
+
+// 同一目标被合并的通知
+type collapsedJobs struct {
+	job   deliveryJob // 第一条被合并的通知，用于发送汇总
+	level string      // 被合并通知中最高的推送级别
+	lines []string
+	count int
+}
+
+// DeliveryQueueStatus 渠道推送队列的状态
+type DeliveryQueueStatus struct {
+	Channel   string `json:"channel"`
+	Depth     int    `json:"depth"`     // 当前排队的通知数
+	Capacity  int    `json:"capacity"`  // 队列长度
+	MaxDepth  int    `json:"maxDepth"`  // 启动以来的最大排队数
+	Pending   int    `json:"pending"`   // 已合并、等待汇总发送的通知数
+	Enqueued  int64  `json:"enqueued"`  // 入队总数
+	Blocked   int64  `json:"blocked"`   // 因队列满阻塞获取的次数
+	Collapsed int64  `json:"collapsed"` // 合并为汇总的通知数
+	Dropped   int64  `json:"dropped"`   // 丢弃的通知数
+}
+
+type deliveryQueue struct {
+	channel string
+	mu      sync.Mutex
+	jobs    []deliveryJob
+	pending map[string]*collapsedJobs
+	order   []string // pending 的合并顺序
+	closed  bool
+	sending bool // 正在发送一条通知
+	full    bool // 正处于队列满的状态，用于只在开始和结束时记录日志
+	status  DeliveryQueueStatus
+
+	ready  chan struct{} // 有新通知或队列关闭
+	space  chan struct{} // 队列有空位
+	done   chan struct{} // 发送协程已退出
+	stop   context.Context
+	cancel context.CancelFunc // 停止时取消发送中的通知
+}
+
+var (
+	deliveryQueues      = make(map[string]*deliveryQueue)
+	deliveryQueuesMutex sync.Mutex
+)
+
+// 渠道的推送队列，首次使用时创建并启动发送协程，队列长度在创建时确定，修改后需要重启
+func getDeliveryQueue(channel string) *deliveryQueue {
+	deliveryQueuesMutex.Lock()
+	defer deliveryQueuesMutex.Unlock()
+	if q, ok := deliveryQueues[channel]; ok {
+		return q
+	}
+	stop, cancel := context.WithCancel(context.Background())
+	q := &deliveryQueue{
+		channel: channel,
+		pending: make(map[string]*collapsedJobs),
+		status:  DeliveryQueueStatus{Channel: channel, Capacity: getDeliveryConfig().QueueSize},
+		ready:   make(chan struct{}, 1),
+		space:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stop:    stop,
+		cancel:  cancel,
+	}
+	deliveryQueues[channel] = q
+	go q.run()
+	return q
+}
+
+// 把通知放入渠道的推送队列，队列满时按 delivery.overflow 处理。
+// 只有 block 方式在等待超时时返回错误，此时通知没有入队
+func deliver(ctx context.Context, channel string, job deliveryJob) error {
+	return getDeliveryQueue(channel).enqueue(ctx, job, getDeliveryConfig().Overflow)
+}
+
+// 把逐笔发布的输出（AMQP、Home Assistant 等）放入渠道队列，由发送协程按 sendTimeoutSeconds 发送，
+// 不阻塞通知。队列满时丢弃新的发布
+func deliverOutput(ctx context.Context, channel, summary string, send func(ctx context.Context)) {
+	job := deliveryJob{level: barkLevelPassive, key: channel, summary: summary, send: send}
+	getDeliveryQueue(channel).enqueue(ctx, job, overflowDrop)
+}
+
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (q *deliveryQueue) enqueue(ctx context.Context, job deliveryJob, overflow string) error {
+	job.ctx = ctx
+	blocked := false
+	for {
+		q.mu.Lock()
+		if q.closed {
+			// 停止后直接发送
+			q.mu.Unlock()
+			job.send(ctx)
+			return nil
+		}
+		if len(q.jobs) < q.status.Capacity {
+			q.push(job)
+			q.mu.Unlock()
+			return nil
+		}
+		q.overflowing()
+		switch overflow {
+		case overflowDigest:
+			q.collapse(job)
+			q.mu.Unlock()
+			return nil
+		case overflowDrop:
+			q.dropLowest(job)
+			q.mu.Unlock()
+			return nil
+		}
+		if !blocked {
+			blocked = true
+			q.status.Blocked++
+		}
+		q.mu.Unlock()
+		select {
+		case <-q.space:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (q *deliveryQueue) push(job deliveryJob) {
+	q.jobs = append(q.jobs, job)
+	q.status.Enqueued++
+	q.status.MaxDepth = max(q.status.MaxDepth, len(q.jobs))
+	notify(q.ready)
+}
+
+// 记录队列开始溢出，调用时持有锁
+func (q *deliveryQueue) overflowing() {
+	if !q.full {
+		q.full = true
+		slog.Warn("Delivery queue full, channel is slow", "channel", q.channel, "capacity", q.status.Capacity)
+	}
+}
+
+func (q *deliveryQueue) collapse(job deliveryJob) {
+	c, ok := q.pending[job.key]
+	if !ok {
+		c = &collapsedJobs{job: job, level: job.level}
+		q.pending[job.key] = c
+		q.order = append(q.order, job.key)
+	}
+	if levelRank(job.level) > levelRank(c.level) {
+		c.level = job.level
+	}
+	if len(c.lines) < maxCollapsedLines {
+		c.lines = append(c.lines, job.summary)
+	}
+	c.count++
+	q.status.Collapsed++
+}
+
+// 丢弃级别最低的通知，级别相同时丢弃最早入队的；新通知的级别不高于队列中最低的级别时丢弃新通知
+func (q *deliveryQueue) dropLowest(job deliveryJob) {
+	q.status.Dropped++
+	lowest := -1
+	for i, queued := range q.jobs {
+		if lowest < 0 || levelRank(queued.level) < levelRank(q.jobs[lowest].level) {
+			lowest = i
+		}
+	}
+	if levelRank(job.level) <= levelRank(q.jobs[lowest].level) {
+		slog.Warn("Delivery queue full, dropping notification", "channel", q.channel, "level", job.level, "summary", job.summary)
+		return
+	}
+	dropped := q.jobs[lowest]
+	slog.Warn("Delivery queue full, dropping notification", "channel", q.channel, "level", dropped.level, "summary", dropped.summary)
+	q.jobs = append(q.jobs[:lowest], q.jobs[lowest+1:]...)
+	q.status.Enqueued--
+	q.push(job)
+}
+
+// 取出下一条要发送的通知，队列消化到一半以下时优先发送合并的汇总；队列关闭且为空时返回 false
+func (q *deliveryQueue) next() (func(ctx context.Context), context.Context, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.order) > 0 && len(q.jobs) <= q.status.Capacity/2 {
+			key := q.order[0]
+			q.order = q.order[1:]
+			c := q.pending[key]
+			delete(q.pending, key)
+			q.sending = true
+			q.mu.Unlock()
+			return func(ctx context.Context) { c.job.digest(ctx, c.level, c.lines, c.count) }, c.job.ctx, true
+		}
+		if len(q.jobs) > 0 {
+			job := q.jobs[0]
+			q.jobs[0] = deliveryJob{}
+			q.jobs = q.jobs[1:]
+			if q.full && len(q.jobs) < q.status.Capacity/2 {
+				q.full = false
+				slog.Info("Delivery queue recovered", "channel", q.channel)
+			}
+			q.sending = true
+			q.mu.Unlock()
+			notify(q.space)
+			return job.send, job.ctx, true
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return nil, nil, false
+		}
+		q.sending = false
+		q.mu.Unlock()
+		<-q.ready
+	}
+}
+
+func (q *deliveryQueue) run() {
+	defer close(q.done)
+	for {
+		send, jobCtx, ok := q.next()
+		if !ok {
+			return
+		}
+		timeout := time.Duration(getDeliveryConfig().SendTimeoutSeconds) * time.Second
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(jobCtx), timeout)
+		stop := context.AfterFunc(q.stop, cancel)
+		send(ctx)
+		stop()
+		cancel()
+	}
+}
+
+// 队列状态，按渠道名排序
+func listDeliveryQueues() []DeliveryQueueStatus {
+	deliveryQueuesMutex.Lock()
+	queues := make([]*deliveryQueue, 0, len(deliveryQueues))
+	for _, q := range deliveryQueues {
+		queues = append(queues, q)
+	}
+	deliveryQueuesMutex.Unlock()
+	statuses := make([]DeliveryQueueStatus, 0, len(queues))
+	for _, q := range queues {
+		q.mu.Lock()
+		status := q.status
+		status.Depth = len(q.jobs)
+		for _, c := range q.pending {
+			status.Pending += c.count
+		}
+		q.mu.Unlock()
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Channel < statuses[j].Channel })
+	return statuses
+}
+
+// 等待所有队列中的通知（包括合并的汇总）发送完毕，不停止队列
+func flushDeliveryQueues(ctx context.Context) error {
+	for {
+		idle := true
+		deliveryQueuesMutex.Lock()
+		for _, q := range deliveryQueues {
+			q.mu.Lock()
+			if len(q.jobs) > 0 || len(q.order) > 0 || q.sending {
+				idle = false
+			}
+			q.mu.Unlock()
+		}
+		deliveryQueuesMutex.Unlock()
+		if idle {
+			return nil
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// 停止接收新通知并等待队列发送完毕，超时后取消发送中的通知，未发送的通知丢弃
+func drainDeliveryQueues(timeout time.Duration) {
+	deliveryQueuesMutex.Lock()
+	queues := make([]*deliveryQueue, 0, len(deliveryQueues))
+	for _, q := range deliveryQueues {
+		queues = append(queues, q)
+	}
+	deliveryQueuesMutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for _, q := range queues {
+		q.mu.Lock()
+		q.closed = true
+		q.mu.Unlock()
+		notify(q.ready)
+	}
+	for _, q := range queues {
+		select {
+		case <-q.done:
+			continue
+		case <-ctx.Done():
+		}
+		q.mu.Lock()
+		remaining := len(q.jobs)
+		q.jobs, q.pending, q.order = nil, map[string]*collapsedJobs{}, nil
+		q.mu.Unlock()
+		slog.Warn("Delivery queue drain timeout, discarding notifications", "channel", q.channel, "remaining", remaining)
+		q.cancel()
+		<-q.done
+	}
+}
+
+// 汇总通知的正文：提示语后逐行列出被合并通知的摘要
+func collapsedMessage(lang, channel string, lines []string, collapsed int) string {
+	header := translate(lang, "alert.collapsed", channel, collapsed)
+	if omitted := collapsed - len(lines); omitted > 0 {
+		lines = append(lines, translate(lang, "alert.collapsedMore", omitted))
+	}
+	return header + "\n" + strings.Join(lines, "\n")
+}
+
+// 已放入推送队列的 Swap 通知，键为渠道、接收目标和交易哈希。
+// 某个渠道入队超时后整笔交易下一轮重新处理，已入队的渠道不再重复推送
+var deliveredSwaps = &observedHashes{set: make(map[string]bool), limit: 10000}
+
+// 把通知放入渠道的推送队列，同一渠道和接收目标的同一笔交易只入队一次
+func deliverSwapOnce(ctx context.Context, channel, hash string, job deliveryJob) error {
+	key := channel + "|" + job.key + "|" + hash
+	if deliveredSwaps.has(key) {
+		return nil
+	}
+	if err := deliver(ctx, channel, job); err != nil {
+		return err
+	}
+	deliveredSwaps.add(key)
+	return nil
+}
+
+// 把一组接收者的 Swap 通知放入 Bark 和 Telegram 的推送队列，按 Bark 推送级别决定丢弃顺序，合并时的摘要为 Bark 消息的首行
+func deliverSwap(ctx context.Context, event *SwapEvent, group targetGroup, barkMessage string, barkOpts barkOptions, telegramNotice notification) error {
+	summary, _, _ := strings.Cut(strings.TrimSpace(barkMessage), "\n")
+	level := barkOpts.Level
+	hash := event.Swap.TransactionHash
+	if len(group.Bark) > 0 {
+		err := deliverSwapOnce(ctx, channelBark, hash, deliveryJob{
+			level:   level,
+			key:     group.Lang + "|" + strings.Join(group.Bark, ","),
+			summary: summary,
+			send: func(ctx context.Context) {
+				pushBark(ctx, group.Bark, barkMessage, barkOpts)
+			},
+			digest: func(ctx context.Context, level string, lines []string, collapsed int) {
+				opts := localizedBarkOptions(barkOptions{Level: level}, group.Lang, templateSwap)
+				pushBark(ctx, group.Bark, collapsedMessage(group.Lang, channelBark, lines, collapsed), opts)
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(group.Chats) == 0 || getTelegramConfig().BotToken == "" {
+		return nil
+	}
+	format := channelFormat(channelTelegram)
+	text := telegramNotice.telegramText(format)
+	return deliverSwapOnce(ctx, channelTelegram, hash, deliveryJob{
+		level:   level,
+		key:     group.Lang + "|" + fmt.Sprint(group.Chats),
+		summary: summary,
+		send: func(ctx context.Context) {
+			pushTelegram(ctx, event, text, group.Chats)
+		},
+		digest: func(ctx context.Context, level string, lines []string, collapsed int) {
+			pushTelegram(ctx, nil, escapeText(format, collapsedMessage(group.Lang, channelTelegram, lines, collapsed)), group.Chats)
+		},
+	})
+}
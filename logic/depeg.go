@@ -0,0 +1,108 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// 脱锚告警配置
+type DepegConfig struct {
+	Enabled         bool    `json:"enabled"`
+	DeviationPct    float64 `json:"deviationPct"`    // UNIBTC/WBTC 偏离 1 超过该百分比时告警，例如 1 表示 1%
+	MoveBps         float64 `json:"moveBps"`         // 窗口内价格变动超过该基点数时告警
+	WindowMinutes   int     `json:"windowMinutes"`   // 价格变动的统计窗口
+	CooldownMinutes int     `json:"cooldownMinutes"` // 同类告警的最小间隔
+}
+
+// 价格采样点
+type pricePoint struct {
+	Time  time.Time
+	Price float64
+}
+
+// 脱锚检测状态
+type depegTracker struct {
+	mu          sync.Mutex
+	points      []pricePoint         // 窗口内的价格
+	lastAlerted map[string]time.Time // 各类告警上次发送时间
+}
+
+var depeg = &depegTracker{lastAlerted: make(map[string]time.Time)}
+
+func getDepegConfig() DepegConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Depeg
+}
+
+// 观察每笔 Swap 的池子价格
+func observeDepeg(ctx context.Context, event *SwapEvent) {
+	cfg := getDepegConfig()
+	if !cfg.Enabled {
+		return
+	}
+	price := event.PoolPrice
+	if price == nil {
+		price = event.ExecutionPrice
+	}
+	if price == nil {
+		return
+	}
+	p, _ := price.Float64()
+	for _, message := range depeg.observe(cfg, pricePoint{Time: event.Time, Price: p}) {
+		slog.Warn("Depeg alert", "message", message)
+		sendAlert(ctx, message, barkLevelCritical)
+	}
+}
+
+// 记录价格并返回需要发送的告警
+func (t *depegTracker) observe(cfg DepegConfig, point pricePoint) []localizedText {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	window := time.Duration(cfg.WindowMinutes) * time.Minute
+	if window <= 0 {
+		window = 10 * time.Minute
+	}
+	cutoff := point.Time.Add(-window)
+	kept := t.points[:0]
+	for _, p := range t.points {
+		if !p.Time.Before(cutoff) {
+			kept = append(kept, p)
+		}
+	}
+	t.points = append(kept, point)
+
+	var alerts []localizedText
+	if cfg.DeviationPct > 0 {
+		deviation := (point.Price - 1) * 100
+		if math.Abs(deviation) > cfg.DeviationPct && t.allow(cfg, "deviation", point.Time) {
+			alerts = append(alerts, i18nText("alert.depeg", point.Price, deviation*100))
+		}
+	}
+	if cfg.MoveBps > 0 && len(t.points) > 1 {
+		first := t.points[0]
+		moveBps := (point.Price/first.Price - 1) * 10000
+		if math.Abs(moveBps) > cfg.MoveBps && t.allow(cfg, "move", point.Time) {
+			alerts = append(alerts, i18nText("alert.depegMove",
+				point.Time.Sub(first.Time).Round(time.Second).String(), moveBps, first.Price, point.Price))
+		}
+	}
+	return alerts
+}
+
+// 冷却时间内不重复告警
+func (t *depegTracker) allow(cfg DepegConfig, kind string, now time.Time) bool {
+	cooldown := time.Duration(cfg.CooldownMinutes) * time.Minute
+	if cooldown <= 0 {
+		cooldown = 30 * time.Minute
+	}
+	if last, ok := t.lastAlerted[kind]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+	t.lastAlerted[kind] = now
+	return true
+}
@@ -0,0 +1,139 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 每日汇总配置
+type DigestConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Time     string   `json:"time"`     // 发送时间，格式 HH:MM
+	Timezone string   `json:"timezone"` // 时区，例如 Asia/Shanghai
+	Targets  []string `json:"targets"`  // 汇总推送的 Bark 地址，为空时推送到所有地址
+
+	WeeklyEnabled bool `json:"weeklyEnabled"` // 是否发送周报
+	WeeklyDay     int  `json:"weeklyDay"`     // 周报发送日，0 表示周日
+}
+
+// 一段时间内的成交汇总
+type swapSummary struct {
+	Count        int
+	VolumeUSD    float64
+	BuyVolumeUSD float64 // WBTC -> UNIBTC
+	Largest      *swapRecord
+	ClosePrice   float64
+}
+
+func getDigestConfig() DigestConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Digest
+	if cfg.Time == "" {
+		cfg.Time = "09:00"
+	}
+	if cfg.Timezone == "" {
+		cfg.Timezone = "Asia/Shanghai"
+	}
+	return cfg
+}
+
+// 推送汇总消息，按订阅者的语言渲染，配置了 targets 时使用默认语言
+func sendDigest(ctx context.Context, message localizedText) {
+	now := time.Now()
+	groups := []targetGroup{{localeData: defaultLocale(), Bark: secretValues(getDigestConfig().Targets)}}
+	if len(groups[0].Bark) == 0 {
+		groups = subscriberGroups(ctx, nil, barkLevelActive, now)
+	}
+	for _, group := range groups {
+		if len(group.Bark) == 0 {
+			continue
+		}
+		data := messageTemplateData{localeData: group.localeData, Message: message.in(group.Lang), Level: barkLevelActive, Time: now, TimeText: group.FormatTime(now)}
+		notice := renderNotification(templateDigest, channelBark, data)
+		pushBark(ctx, group.Bark, notice.Body, localizedBarkOptions(notice.barkOptions(barkOptions{Level: barkLevelActive}), group.Lang, templateDigest))
+		logNotification(ctx, "", group.Bark, notice.Body)
+	}
+}
+
+// 将 HH:MM 转换为 cron 表达式，附带时区
+func dailyCronSpec(clock, timezone, dayOfWeek string) (string, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return "", fmt.Errorf("invalid hour in %q", clock)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return "", fmt.Errorf("invalid minute in %q", clock)
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return "", fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	return fmt.Sprintf("CRON_TZ=%s %d %d * * %s", timezone, minute, hour, dayOfWeek), nil
+}
+
+// 汇总成交记录
+func summarizeSwaps(records []swapRecord) swapSummary {
+	var summary swapSummary
+	for i := range records {
+		r := &records[i]
+		summary.Count++
+		summary.VolumeUSD += r.VolumeUSD
+		if r.Direction == directionBuy {
+			summary.BuyVolumeUSD += r.VolumeUSD
+		}
+		if summary.Largest == nil || r.VolumeUSD > summary.Largest.VolumeUSD {
+			summary.Largest = r
+		}
+		if r.Price > 0 {
+			summary.ClosePrice = r.Price
+		}
+	}
+	return summary
+}
+
+// 净流向描述
+func (s swapSummary) netFlow() localizedText {
+	sell := s.VolumeUSD - s.BuyVolumeUSD
+	switch {
+	case s.BuyVolumeUSD > sell:
+		return i18nText("digest.netBuy", formatDigestUSD(s.BuyVolumeUSD-sell))
+	case sell > s.BuyVolumeUSD:
+		return i18nText("digest.netSell", formatDigestUSD(sell-s.BuyVolumeUSD))
+	default:
+		return i18nText("digest.flat")
+	}
+}
+
+// DailyDigestTask 发送过去 24 小时的成交汇总
+func DailyDigestTask(ctx context.Context) error {
+	now := time.Now()
+	summary := summarizeSwaps(querySwapHistory(ctx, now.Add(-24*time.Hour), now))
+	message := formatDailyDigest(now, summary)
+	slog.Info("Sending daily digest", "message", message)
+	sendDigest(ctx, message)
+	return nil
+}
+
+func formatDailyDigest(now time.Time, summary swapSummary) localizedText {
+	loc, err := time.LoadLocation(getDigestConfig().Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	date := now.In(loc).Format("2006-01-02")
+	if summary.Count == 0 {
+		return i18nText("digest.dailyEmpty", date)
+	}
+	largest := summary.Largest
+	return i18nText("digest.daily",
+		date, summary.Count, formatDigestUSD(summary.VolumeUSD), formatDigestUSD(largest.VolumeUSD), largest.TokenIn, largest.TokenOut,
+		summary.netFlow(), formatPrice(summary.ClosePrice))
+}
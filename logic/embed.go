@@ -0,0 +1,70 @@
+package logic
+
+import (
+	"context"
+	"time"
+)
+
+// 供 pkg/source、pkg/notify、pkg/pipeline 使用的导出函数，嵌入本程序的外部程序应使用这些包
+
+// NewWatcher 按当前配置创建 Watcher，配置修改后需要重新创建
+func NewWatcher() *Watcher {
+	return newWatcher()
+}
+
+// GraphSources 当前配置中的 Graph 数据源，第一个为 graphAPIURLs 对应的主数据源
+func GraphSources() []Source {
+	graphSources := getGraphSources()
+	sources := make([]Source, len(graphSources))
+	for i, source := range graphSources {
+		sources[i] = source
+	}
+	return sources
+}
+
+// NewStaticSource 返回固定交易的数据源，swaps 按区块从新到旧，每轮只返回处理进度之后的部分
+func NewStaticSource(name string, swaps []Swap) Source {
+	return staticSource{name: name, swaps: swaps}
+}
+
+// DefaultNotifier 内置的通知流程：过滤、格式化后推送到配置的渠道、外部命令与已注册的 AlertHandler
+func DefaultNotifier() Notifier {
+	return clockNotifier(systemClock{})
+}
+
+// CurrentStore 当前配置的存储，首次调用时打开，打开失败时返回错误，下次调用重新打开
+func CurrentStore() (Store, error) {
+	return openActiveStore()
+}
+
+// ReadConfig 读取配置文件，不应用
+func ReadConfig(path string) (Config, error) {
+	return readConfigFile(path)
+}
+
+// ApplyConfig 替换当前配置，关闭已打开的存储，下次使用时按新配置打开。配置无效（例如条件表达式无法编译）时返回错误，不应用。
+// 使用 json 存储时处理进度保存在配置中，cfg 中的进度会替换当前进度
+func ApplyConfig(cfg Config) error {
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+	CloseStore()
+	applyConfig(cfg)
+	return nil
+}
+
+// FlushStore 写入尚未持久化的处理进度，退出前调用
+func FlushStore() {
+	flushStore()
+}
+
+// Prepare 处理交易前读取代币元数据，读取失败时使用配置中的值
+func Prepare(ctx context.Context) {
+	loadTokenMetadata(ctx)
+}
+
+// Drain 等待推送队列发送完毕与外部命令执行结束，最多等待 timeout
+func Drain(timeout time.Duration) {
+	drainDeliveryQueues(timeout)
+	waitExecHooks(timeout)
+}
@@ -0,0 +1,57 @@
+package logic
+
+// 通知中的 emoji，按方向和大额交易分级映射，只用于支持 emoji 的渠道。
+// 模板中通过 .Emoji（分级与方向）、.DirectionEmoji、.TierEmoji 使用，内置标题以 .Emoji 开头
+type EmojiConfig struct {
+	Buy      string          `json:"buy"`      // 买入，默认 🟢，"-" 表示不使用
+	Sell     string          `json:"sell"`     // 卖出，默认 🔴，"-" 表示不使用
+	Whale    string          `json:"whale"`    // 大额交易分级，默认 🐋，分级的 emoji 优先，"-" 表示不使用
+	Channels map[string]bool `json:"channels"` // 按渠道开关，未配置的渠道默认开启
+}
+
+func getEmojiConfig() EmojiConfig {
+	configMutex.RLock()
+	cfg := configData.Emoji
+	configMutex.RUnlock()
+	cfg.Buy = emojiOrDefault(cfg.Buy, "🟢")
+	cfg.Sell = emojiOrDefault(cfg.Sell, "🔴")
+	cfg.Whale = emojiOrDefault(cfg.Whale, "🐋")
+	return cfg
+}
+
+func emojiOrDefault(emoji, def string) string {
+	switch emoji {
+	case "":
+		return def
+	case "-":
+		return ""
+	default:
+		return emoji
+	}
+}
+
+// 渠道是否使用 emoji
+func channelEmoji(channel string) bool {
+	enabled, ok := getEmojiConfig().Channels[channel]
+	return enabled || !ok
+}
+
+// 按渠道填充模板数据中的 emoji，不支持 emoji 的渠道清空
+func (d swapTemplateData) withEmoji(channel string) swapTemplateData {
+	d.DirectionEmoji, d.TierEmoji, d.Emoji = "", "", ""
+	if !channelEmoji(channel) {
+		return d
+	}
+	cfg := getEmojiConfig()
+	switch d.Direction {
+	case directionBuy:
+		d.DirectionEmoji = cfg.Buy
+	case directionSell:
+		d.DirectionEmoji = cfg.Sell
+	}
+	if d.Tier != "" {
+		d.TierEmoji = emojiOrDefault(d.tierEmoji, cfg.Whale)
+	}
+	d.Emoji = d.TierEmoji + d.DirectionEmoji
+	return d
+}
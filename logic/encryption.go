@@ -0,0 +1,245 @@
+package logic
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 加密值的前缀，配置中以该前缀开头的值会在使用时解密
+const encryptedPrefix = "enc:v1:"
+
+// 静态加密配置。密钥为 base64 编码的 32 字节，依次从环境变量和密钥命令获取，
+// 密钥命令可以调用 KMS 解密，例如 ["sh", "-c", "aws kms decrypt ... --query Plaintext --output text"]
+type EncryptionConfig struct {
+	Enabled    bool     `json:"enabled"`    // 是否加密存储中的通知记录与设置
+	KeyEnv     string   `json:"keyEnv"`     // 保存密钥的环境变量，默认为 MESSAGE_PUSH_STATE_KEY
+	KeyCommand []string `json:"keyCommand"` // 输出密钥的命令
+}
+
+var (
+	encryptionKey      []byte
+	encryptionKeyMutex sync.Mutex
+)
+
+func getEncryptionConfig() EncryptionConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Encryption
+	if cfg.KeyEnv == "" {
+		cfg.KeyEnv = "MESSAGE_PUSH_STATE_KEY"
+	}
+	return cfg
+}
+
+// 获取加密密钥，成功后缓存
+func loadEncryptionKey() ([]byte, error) {
+	encryptionKeyMutex.Lock()
+	defer encryptionKeyMutex.Unlock()
+	if encryptionKey != nil {
+		return encryptionKey, nil
+	}
+
+	cfg := getEncryptionConfig()
+	encoded := strings.TrimSpace(os.Getenv(cfg.KeyEnv))
+	if encoded == "" && len(cfg.KeyCommand) > 0 {
+		cmd := exec.Command(cfg.KeyCommand[0], cfg.KeyCommand[1:]...)
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("key command: %w", err)
+		}
+		encoded = strings.TrimSpace(string(output))
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("encryption key not found in $%s or keyCommand", cfg.KeyEnv)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode encryption key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	encryptionKey = key
+	return key, nil
+}
+
+func newGCM() (cipher.AEAD, error) {
+	key, err := loadEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// 使用 AES-256-GCM 加密，返回带前缀的 base64 字符串
+func encryptValue(plaintext string) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// 解密 encryptValue 的结果，没有前缀的值原样返回
+func decryptValue(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// 配置中的敏感值，先解密再替换外部密钥引用，失败时返回空字符串，避免把密文或引用当作地址使用
+func secretValue(value string) string {
+	return secretValueContext(context.Background(), value)
+}
+
+// 与 secretValue 相同，获取外部密钥时使用调用方的上下文，用于处理交易等可以取消的路径
+func secretValueContext(ctx context.Context, value string) string {
+	plaintext, err := decryptValue(value)
+	if err != nil {
+		slog.Error("Failed to decrypt config value", "error", err)
+		return ""
+	}
+	resolved, err := resolveSecretRefs(ctx, plaintext)
+	if err != nil {
+		slog.Error("Failed to resolve secret reference", "error", err)
+		return ""
+	}
+	return resolved
+}
+
+func secretValues(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if plaintext := secretValue(value); plaintext != "" {
+			result = append(result, plaintext)
+		}
+	}
+	return result
+}
+
+// 加密通知记录与设置的存储。Swap 历史、处理进度和交易哈希都是公开的链上数据，不加密；
+// 通知和订阅者中的 Bark 地址包含设备密钥，通知内容可能包含地址标签，因此加密保存
+type encryptedStore struct {
+	Store
+}
+
+func (s *encryptedStore) LogNotification(ctx context.Context, record NotificationRecord) error {
+	sealed := record
+	sealed.Targets = make([]string, len(record.Targets))
+	for i, target := range record.Targets {
+		value, err := encryptValue(target)
+		if err != nil {
+			return err
+		}
+		sealed.Targets[i] = value
+	}
+	message, err := encryptValue(record.Message)
+	if err != nil {
+		return err
+	}
+	sealed.Message = message
+	return s.Store.LogNotification(ctx, sealed)
+}
+
+// 未加密时写入的旧记录原样返回
+func (s *encryptedStore) QueryNotifications(ctx context.Context, since, until time.Time) ([]NotificationRecord, error) {
+	records, err := s.Store.QueryNotifications(ctx, since, until)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		for j, target := range records[i].Targets {
+			if records[i].Targets[j], err = decryptValue(target); err != nil {
+				return nil, err
+			}
+		}
+		if records[i].Message, err = decryptValue(records[i].Message); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// 设置整体加密，密文以 JSON 字符串保存，兼容要求值为 JSON 的存储
+func (s *encryptedStore) SaveSetting(ctx context.Context, kind, key string, value []byte) error {
+	sealed, err := encryptValue(string(value))
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return err
+	}
+	return s.Store.SaveSetting(ctx, kind, key, data)
+}
+
+// 未加密时写入的旧设置原样返回，下次保存时加密
+func (s *encryptedStore) LoadSettings(ctx context.Context, kind string) (map[string][]byte, error) {
+	settings, err := s.Store.LoadSettings(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range settings {
+		var sealed string
+		if json.Unmarshal(value, &sealed) != nil || !strings.HasPrefix(sealed, encryptedPrefix) {
+			continue
+		}
+		plaintext, err := decryptValue(sealed)
+		if err != nil {
+			return nil, err
+		}
+		settings[key] = []byte(plaintext)
+	}
+	return settings, nil
+}
+
+// RunEncrypt 加密命令行参数中的值，输出可以直接写入配置的密文
+func RunEncrypt(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: encrypt <value>")
+	}
+	value, err := encryptValue(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
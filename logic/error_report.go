@@ -0,0 +1,207 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// 错误上报配置，可以同时上报到 Sentry 和自定义 webhook
+type ErrorReportConfig struct {
+	SentryDSN    string `json:"sentryDSN"`    // 修改后需要重启
+	WebhookURL   string `json:"webhookURL"`   // 以 JSON POST 上报
+	Environment  string `json:"environment"`  // 例如 production
+	DedupMinutes int    `json:"dedupMinutes"` // 相同错误的最小上报间隔，默认 60
+}
+
+func getErrorReportConfig() ErrorReportConfig {
+	configMutex.RLock()
+	cfg := configData.ErrorReport
+	configMutex.RUnlock()
+	cfg.SentryDSN = secretValue(cfg.SentryDSN)
+	cfg.WebhookURL = secretValue(cfg.WebhookURL)
+	if cfg.DedupMinutes <= 0 {
+		cfg.DedupMinutes = 60
+	}
+	return cfg
+}
+
+// 错误报告的类型
+const (
+	errorKindPanic       = "panic"
+	errorKindTaskFailure = "task_failure"
+)
+
+// 上报的错误，webhook 收到的 JSON 与该结构一致
+type errorReport struct {
+	Time    time.Time         `json:"time"`
+	Host    string            `json:"host"`
+	Kind    string            `json:"kind"`
+	Message string            `json:"message"`
+	Job     string            `json:"job,omitempty"`
+	Context map[string]string `json:"context,omitempty"` // 交易哈希、区块等上下文
+	Stack   string            `json:"stack,omitempty"`
+}
+
+var (
+	sentryEnabled bool
+	reportedAt    = make(map[string]time.Time) // 按错误指纹记录最近一次上报时间
+	reportMutex   sync.Mutex
+)
+
+// 按配置初始化 Sentry
+func initErrorReporting() {
+	cfg := getErrorReportConfig()
+	if cfg.SentryDSN == "" {
+		return
+	}
+	err := sentry.Init(sentry.ClientOptions{Dsn: cfg.SentryDSN, Environment: cfg.Environment, AttachStacktrace: true})
+	if err != nil {
+		slog.Error("Failed to initialize Sentry", "error", err)
+		return
+	}
+	sentryEnabled = true
+}
+
+// 停止前发送尚未发出的事件
+func flushErrorReporting(timeout time.Duration) {
+	if sentryEnabled {
+		sentry.Flush(timeout)
+	}
+}
+
+// 上报错误，相同的错误在 dedupMinutes 内只上报一次
+func reportError(report errorReport) {
+	cfg := getErrorReportConfig()
+	if !sentryEnabled && cfg.WebhookURL == "" {
+		return
+	}
+	now := time.Now()
+	fingerprint := errorFingerprint(report)
+	reportMutex.Lock()
+	if last, ok := reportedAt[fingerprint]; ok && now.Sub(last) < time.Duration(cfg.DedupMinutes)*time.Minute {
+		reportMutex.Unlock()
+		return
+	}
+	reportedAt[fingerprint] = now
+	reportMutex.Unlock()
+
+	report.Time = now
+	report.Host, _ = os.Hostname()
+	if sentryEnabled {
+		captureSentry(report)
+	}
+	if cfg.WebhookURL != "" {
+		go func() {
+			if err := postErrorWebhook(cfg.WebhookURL, report); err != nil {
+				slog.Error("Failed to post error report", "error", err)
+			}
+		}()
+	}
+}
+
+func errorFingerprint(report errorReport) string {
+	sum := sha1.Sum([]byte(report.Kind + "|" + report.Job + "|" + report.Message))
+	return hex.EncodeToString(sum[:])
+}
+
+func captureSentry(report errorReport) {
+	sentry.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("kind", report.Kind)
+		if report.Job != "" {
+			scope.SetTag("job", report.Job)
+		}
+		if len(report.Context) > 0 {
+			context := make(sentry.Context, len(report.Context))
+			for k, v := range report.Context {
+				context[k] = v
+			}
+			scope.SetContext("swap", context)
+		}
+		if report.Stack != "" {
+			scope.SetExtra("stack", report.Stack)
+		}
+		level := sentry.LevelError
+		if report.Kind == errorKindPanic {
+			level = sentry.LevelFatal
+		}
+		scope.SetLevel(level)
+		sentry.CaptureMessage(report.Message)
+	})
+}
+
+func postErrorWebhook(webhookURL string, report errorReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// 任务中未被单独处理的 panic
+func reportJobPanic(name string, recovered interface{}, stack []byte) {
+	reportError(errorReport{
+		Kind:    errorKindPanic,
+		Message: fmt.Sprint(recovered),
+		Job:     name,
+		Stack:   string(stack),
+	})
+}
+
+// 处理单笔 Swap 时的 panic 只影响该笔交易，附带交易信息上报
+func notifySafely(ctx context.Context, swap Swap, mevTag string, now time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			slog.Error("Panic while sending notification", "transactionHash", swap.TransactionHash, "panic", r, "stack", string(stack))
+			reportError(errorReport{
+				Kind:    errorKindPanic,
+				Message: fmt.Sprint(r),
+				Job:     "graph_task",
+				Context: map[string]string{
+					"tx_hash":   swap.TransactionHash,
+					"block":     swap.BlockNumber,
+					"sender":    swap.Sender,
+					"recipient": swap.Recipient,
+					"pool":      getPoolConfig().Address,
+				},
+				Stack: string(stack),
+			})
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sendNotification(ctx, swap, mevTag, now)
+}
+
+// 内置的通知流程，当前时间取自 clock
+func clockNotifier(clock Clock) Notifier {
+	return NotifierFunc(func(ctx context.Context, swap Swap, mevTag string) error {
+		return notifySafely(ctx, swap, mevTag, clock.Now())
+	})
+}
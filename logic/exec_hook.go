@@ -0,0 +1,140 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 外部命令配置：每笔发送通知的 Swap 都以 JSON 写入命令的标准输入，命令在后台执行，不影响推送，
+// 可用于交易脚本、自定义日志等。命令不经过 shell，需要 shell 语法时使用 ["sh", "-c", "..."]
+type ExecConfig struct {
+	Command       []string `json:"command"`       // 命令及参数，为空时不启用
+	Timeout       int      `json:"timeout"`       // 单次执行超时（秒），默认 10，超时后结束进程
+	MaxConcurrent int      `json:"maxConcurrent"` // 同时执行的命令数上限，默认 4，达到上限时跳过并记录错误
+}
+
+func getExecConfig() ExecConfig {
+	configMutex.RLock()
+	cfg := configData.Exec
+	configMutex.RUnlock()
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 4
+	}
+	return cfg
+}
+
+// 写入命令标准输入的内容，在 Swap 事件之外附带分级与消息正文
+type execPayload struct {
+	swapFeedEvent
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// 记录到日志中的命令输出长度上限
+const execOutputLimit = 4 << 10
+
+var (
+	execRunning int // 正在执行的命令数
+	execMutex   sync.Mutex
+	execWG      sync.WaitGroup
+)
+
+// 在后台执行外部命令，达到并发上限时跳过
+func runExecHook(ctx context.Context, event *SwapEvent, level, message string) {
+	cfg := getExecConfig()
+	if len(cfg.Command) == 0 {
+		return
+	}
+	label := cfg.Command[0]
+	input, err := json.Marshal(execPayload{swapFeedEvent: newSwapFeedEvent(event), Level: level, Message: message})
+	if err != nil {
+		slog.Error("Failed to encode swap for exec", "error", err)
+		return
+	}
+
+	execMutex.Lock()
+	if execRunning >= cfg.MaxConcurrent {
+		execMutex.Unlock()
+		err := fmt.Errorf("concurrency limit %d reached", cfg.MaxConcurrent)
+		recordChannelResult("exec", label, time.Now(), err)
+		slog.Error("Exec hook skipped", "transactionHash", event.Swap.TransactionHash, "error", err)
+		return
+	}
+	execRunning++
+	execWG.Add(1)
+	execMutex.Unlock()
+
+	// 命令在本轮结束后继续执行，不随本轮取消，只受自身超时限制
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			execMutex.Lock()
+			execRunning--
+			execMutex.Unlock()
+			execWG.Done()
+		}()
+		start := time.Now()
+		output, err := execCommand(ctx, cfg, event, level, input)
+		recordChannelResult("exec", label, time.Now(), err)
+		if err != nil {
+			slog.Error("Exec hook failed", "command", label, "transactionHash", event.Swap.TransactionHash,
+				"error", err, "output", output)
+			return
+		}
+		slog.Debug("Exec hook finished", "command", label, "transactionHash", event.Swap.TransactionHash,
+			"duration", time.Since(start), "output", output)
+	}()
+}
+
+// 执行一次命令，返回合并后的标准输出与标准错误（超出长度上限的部分截断）
+func execCommand(ctx context.Context, cfg ExecConfig, event *SwapEvent, level string, input []byte) (string, error) {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, cfg.Command[0], cfg.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(),
+		"MESSAGE_PUSH_TX_HASH="+event.Swap.TransactionHash,
+		"MESSAGE_PUSH_BLOCK="+event.Swap.BlockNumber,
+		"MESSAGE_PUSH_LEVEL="+level,
+	)
+	var output bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	// 子进程继承了输出管道时，结束命令后最多再等待一秒
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+	text := strings.TrimSpace(output.String())
+	if len(text) > execOutputLimit {
+		text = text[:execOutputLimit] + "..."
+	}
+	return text, err
+}
+
+// 等待后台执行的命令结束，超时后不再等待
+func waitExecHooks(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		execWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		slog.Warn("Exec hooks still running after drain timeout")
+	}
+}
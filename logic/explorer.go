@@ -0,0 +1,60 @@
+package logic
+
+import "strings"
+
+// 各链默认的区块浏览器地址
+var defaultExplorers = map[string]string{
+	"ethereum": "https://etherscan.io",
+	"arbitrum": "https://arbiscan.io",
+	"optimism": "https://optimistic.etherscan.io",
+	"base":     "https://basescan.org",
+	"bsc":      "https://bscscan.com",
+	"polygon":  "https://polygonscan.com",
+}
+
+// 区块浏览器配置
+type ExplorerConfig struct {
+	Chain   string `json:"chain"`   // 链名称，默认 ethereum
+	BaseURL string `json:"baseURL"` // 自定义浏览器地址，优先于链的默认地址
+}
+
+// 交易相关的浏览器链接
+type explorerLinks struct {
+	Tx     string
+	Sender string
+}
+
+func getExplorerConfig() ExplorerConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Explorer
+}
+
+// 当前链的浏览器地址，未知链返回空字符串
+func explorerBaseURL() string {
+	cfg := getExplorerConfig()
+	if cfg.BaseURL != "" {
+		return strings.TrimRight(cfg.BaseURL, "/")
+	}
+	chain := strings.ToLower(cfg.Chain)
+	if chain == "" {
+		chain = "ethereum"
+	}
+	return defaultExplorers[chain]
+}
+
+// 生成交易和发送方的浏览器链接
+func swapExplorerLinks(swap *Swap) explorerLinks {
+	base := explorerBaseURL()
+	if base == "" {
+		return explorerLinks{}
+	}
+	links := explorerLinks{}
+	if swap.TransactionHash != "" {
+		links.Tx = base + "/tx/" + swap.TransactionHash
+	}
+	if swap.Sender != "" {
+		links.Sender = base + "/address/" + swap.Sender
+	}
+	return links
+}
@@ -0,0 +1,117 @@
+package logic
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunExport 导出存储中的 Swap 历史或通知记录，输出 CSV 或 JSON Lines
+func RunExport(args []string) error {
+	defer CloseStore()
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "csv", "输出格式：csv / jsonl")
+	since := fs.String("since", "30d", "导出最近多长时间的记录，支持 d 表示天，例如 30d、12h")
+	kind := fs.String("type", "swaps", "导出内容：swaps / notifications")
+	out := fs.String("out", "", "输出文件，为空时输出到标准输出")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	window, err := parseDays(*since)
+	if err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "jsonl" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	ctx, stop := commandContext()
+	defer stop()
+	now := time.Now()
+	switch *kind {
+	case "swaps":
+		records, err := getStore().QuerySwaps(ctx, now.Add(-window), now.Add(time.Second))
+		if err != nil {
+			return err
+		}
+		if *format == "jsonl" {
+			return writeJSONLines(w, records)
+		}
+		return writeSwapsCSV(w, records)
+	case "notifications":
+		records, err := getStore().QueryNotifications(ctx, now.Add(-window), now.Add(time.Second))
+		if err != nil {
+			return err
+		}
+		if *format == "jsonl" {
+			return writeJSONLines(w, records)
+		}
+		return writeNotificationsCSV(w, records)
+	default:
+		return fmt.Errorf("unknown type %q", *kind)
+	}
+}
+
+// 解析时长，在 time.ParseDuration 的基础上支持以 d 结尾的天数
+func parseDays(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func writeJSONLines[T any](w io.Writer, records []T) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSwapsCSV(w io.Writer, records []swapRecord) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"time", "tx_hash", "block", "sender", "recipient", "token_in", "token_out",
+		"direction", "amount_in", "amount_out", "volume_usd", "price"})
+	for _, r := range records {
+		writer.Write([]string{
+			r.Time.Format(time.RFC3339), r.TxHash, strconv.FormatInt(r.Block, 10), r.Sender, r.Recipient,
+			r.TokenIn, r.TokenOut, r.Direction,
+			strconv.FormatFloat(r.AmountIn, 'f', -1, 64), strconv.FormatFloat(r.AmountOut, 'f', -1, 64),
+			strconv.FormatFloat(r.VolumeUSD, 'f', 2, 64), strconv.FormatFloat(r.Price, 'f', -1, 64),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeNotificationsCSV(w io.Writer, records []NotificationRecord) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"time", "tx_hash", "targets", "message"})
+	for _, r := range records {
+		writer.Write([]string{r.Time.Format(time.RFC3339), r.TxHash, strings.Join(r.Targets, " "), r.Message})
+	}
+	writer.Flush()
+	return writer.Error()
+}
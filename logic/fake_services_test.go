@@ -0,0 +1,227 @@
+package logic
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 本地模拟的外部服务，用于集成测试在不访问网络的情况下执行完整的 获取-处理-推送 流程
+
+// 一次模拟的故障，按注入顺序依次返回，用完后恢复正常响应
+type fakeFault struct {
+	Status int      // HTTP 状态码，为 0 时返回 200
+	Errors []string // GraphQL errors 中的消息
+	Body   string   // 原样返回的响应体，例如损坏的 JSON
+}
+
+// 模拟的 Graph API，按查询中的 first、orderDirection 和 blockNumber_gt / gte 分页返回 swaps，
+// 查询包含 _meta 时返回最新区块。不同路径可以作为不同的地址，分别注入故障
+type fakeGraph struct {
+	server   *httptest.Server
+	mu       sync.Mutex
+	swaps    []Swap
+	faults   map[string][]fakeFault
+	requests map[string]int // 路径 -> 请求次数
+}
+
+var (
+	fakeFirstPattern = regexp.MustCompile(`first:\s*(\d+)`)
+	fakeBlockPattern = regexp.MustCompile(`blockNumber_(gte?):\s*(\d+)`)
+)
+
+func newFakeGraph() *fakeGraph {
+	g := &fakeGraph{faults: make(map[string][]fakeFault), requests: make(map[string]int)}
+	g.server = httptest.NewServer(http.HandlerFunc(g.serve))
+	return g
+}
+
+func (g *fakeGraph) url(path string) string {
+	return g.server.URL + path
+}
+
+func (g *fakeGraph) close() {
+	g.server.Close()
+}
+
+func (g *fakeGraph) addSwaps(swaps ...Swap) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.swaps = append(g.swaps, swaps...)
+}
+
+// 为指定路径注入依次返回的故障
+func (g *fakeGraph) inject(path string, faults ...fakeFault) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.faults[path] = append(g.faults[path], faults...)
+}
+
+func (g *fakeGraph) requestCount(path string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.requests[path]
+}
+
+func (g *fakeGraph) serve(w http.ResponseWriter, r *http.Request) {
+	var request graphRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	g.mu.Lock()
+	g.requests[r.URL.Path]++
+	var fault *fakeFault
+	if faults := g.faults[r.URL.Path]; len(faults) > 0 {
+		fault, g.faults[r.URL.Path] = &faults[0], faults[1:]
+	}
+	page := g.page(request.Query)
+	g.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if fault != nil {
+		writeFakeFault(w, *fault)
+		return
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
+// 按查询条件返回一页，调用时持有锁
+func (g *fakeGraph) page(query string) GraphResponse {
+	first := len(g.swaps)
+	if m := fakeFirstPattern.FindStringSubmatch(query); m != nil {
+		first, _ = strconv.Atoi(m[1])
+	}
+	var matched []Swap
+	var head int64
+	for _, swap := range g.swaps {
+		block, _ := strconv.ParseInt(swap.BlockNumber, 10, 64)
+		head = max(head, block)
+		if m := fakeBlockPattern.FindStringSubmatch(query); m != nil {
+			from, _ := strconv.ParseInt(m[2], 10, 64)
+			if block < from || (m[1] == "gt" && block == from) {
+				continue
+			}
+		}
+		matched = append(matched, swap)
+	}
+	desc := strings.Contains(query, "orderDirection: desc")
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, _ := strconv.ParseInt(matched[i].BlockNumber, 10, 64)
+		b, _ := strconv.ParseInt(matched[j].BlockNumber, 10, 64)
+		if desc {
+			return a > b
+		}
+		return a < b
+	})
+	var page GraphResponse
+	page.Data.Swaps = matched[:min(first, len(matched))]
+	if strings.Contains(query, "_meta") {
+		page.Data.Meta = new(GraphMeta)
+		page.Data.Meta.Block.Number = head
+	}
+	return page
+}
+
+func writeFakeFault(w http.ResponseWriter, fault fakeFault) {
+	if fault.Status != 0 {
+		w.WriteHeader(fault.Status)
+	}
+	if fault.Body != "" {
+		io.WriteString(w, fault.Body)
+		return
+	}
+	var response GraphResponse
+	for _, message := range fault.Errors {
+		response.Errors = append(response.Errors, GraphError{Message: message})
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// 模拟的推送接收端：Bark 地址为 <url>/<key>/，Telegram Bot API 地址为 <url>，key 不能以 bot 开头
+type fakeReceiver struct {
+	server   *httptest.Server
+	mu       sync.Mutex
+	bark     []fakeMessage
+	telegram []fakeMessage
+	faults   []fakeFault // 依次应用到之后的 Bark 推送请求
+}
+
+// 收到的一条推送
+type fakeMessage struct {
+	Target string // Bark 的 key 或 Telegram 的会话 ID
+	Text   string
+}
+
+func newFakeReceiver() *fakeReceiver {
+	f := &fakeReceiver{}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+func (f *fakeReceiver) barkURL(key string) string {
+	return f.server.URL + "/" + key + "/"
+}
+
+func (f *fakeReceiver) close() {
+	f.server.Close()
+}
+
+func (f *fakeReceiver) failBark(faults ...fakeFault) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.faults = append(f.faults, faults...)
+}
+
+// 收到的 Bark 与 Telegram 推送
+func (f *fakeReceiver) messages() (bark, telegram []fakeMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeMessage(nil), f.bark...), append([]fakeMessage(nil), f.telegram...)
+}
+
+func (f *fakeReceiver) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bark, f.telegram, f.faults = nil, nil, nil
+}
+
+func (f *fakeReceiver) serve(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/bot") {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if len(f.faults) > 0 {
+			fault := f.faults[0]
+			f.faults = f.faults[1:]
+			writeFakeFault(w, fault)
+			return
+		}
+		// /<key>/[<title>/]<body>，正文为最后一段
+		segments := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+		text, _ := url.PathUnescape(segments[len(segments)-1])
+		f.bark = append(f.bark, fakeMessage{Target: segments[0], Text: text})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if strings.HasSuffix(r.URL.Path, "/sendMessage") {
+		var params struct {
+			ChatID int64  `json:"chat_id"`
+			Text   string `json:"text"`
+		}
+		json.NewDecoder(r.Body).Decode(&params)
+		f.mu.Lock()
+		f.telegram = append(f.telegram, fakeMessage{Target: strconv.FormatInt(params.ChatID, 10), Text: params.Text})
+		f.mu.Unlock()
+		io.WriteString(w, `{"ok":true,"result":{"message_id":1}}`)
+		return
+	}
+	io.WriteString(w, `{"ok":true,"result":[]}`)
+}
@@ -0,0 +1,160 @@
+package logic
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// RSS 订阅配置，挂在管理接口的 /feed.xml 上
+type FeedConfig struct {
+	Enabled bool   `json:"enabled"`
+	Title   string `json:"title"`  // 订阅标题
+	Link    string `json:"link"`   // 订阅对外访问的地址，部分阅读器需要
+	Items   int    `json:"items"`  // 最多包含的条目数，默认 50
+	Days    int    `json:"days"`   // 只包含最近多少天的通知，默认 7
+	Alerts  bool   `json:"alerts"` // 是否包含系统告警，默认只包含 Swap 通知
+}
+
+func getFeedConfig() FeedConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Feed
+	if cfg.Title == "" {
+		cfg.Title = "message-push alerts"
+	}
+	if cfg.Items <= 0 {
+		cfg.Items = 50
+	}
+	if cfg.Days <= 0 {
+		cfg.Days = 7
+	}
+	return cfg
+}
+
+// json 存储不记录通知，订阅使用内存中最近的通知
+var (
+	recentNotifications      []NotificationRecord
+	recentNotificationsMutex sync.Mutex
+)
+
+const recentNotificationsLimit = 200
+
+func rememberNotification(record NotificationRecord) {
+	recentNotificationsMutex.Lock()
+	defer recentNotificationsMutex.Unlock()
+	recentNotifications = append(recentNotifications, record)
+	if n := len(recentNotifications); n > recentNotificationsLimit {
+		recentNotifications = append([]NotificationRecord(nil), recentNotifications[n-recentNotificationsLimit:]...)
+	}
+}
+
+func feedNotifications(ctx context.Context, since, until time.Time) ([]NotificationRecord, error) {
+	if getStorageConfig().Driver != storageDriverJSON {
+		return getStore().QueryNotifications(ctx, since, until)
+	}
+	recentNotificationsMutex.Lock()
+	defer recentNotificationsMutex.Unlock()
+	var records []NotificationRecord
+	for _, record := range recentNotifications {
+		if !record.Time.Before(since) && record.Time.Before(until) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// 生成最近通知的 RSS 2.0 订阅
+func buildFeed(ctx context.Context, cfg FeedConfig, now time.Time) (rssFeed, error) {
+	records, err := feedNotifications(ctx, now.AddDate(0, 0, -cfg.Days), now.Add(time.Second))
+	if err != nil {
+		return rssFeed{}, err
+	}
+	if !cfg.Alerts {
+		swaps := records[:0]
+		for _, record := range records {
+			if record.TxHash != "" {
+				swaps = append(swaps, record)
+			}
+		}
+		records = swaps
+	}
+	records = newestFirst(records, cfg.Items, func(r NotificationRecord) time.Time { return r.Time })
+
+	feed := rssFeed{Version: "2.0", Channel: rssChannel{
+		Title:         cfg.Title,
+		Link:          cfg.Link,
+		Description:   "Swap alerts pushed by message-push",
+		LastBuildDate: now.Format(time.RFC1123Z),
+	}}
+	base := explorerBaseURL()
+	for _, record := range records {
+		item := rssItem{
+			Title:       feedItemTitle(record.Message),
+			Description: record.Message,
+			GUID:        rssGUID{Value: record.TxHash},
+			PubDate:     record.Time.Format(time.RFC1123Z),
+		}
+		if record.TxHash == "" {
+			item.GUID.Value = "alert-" + record.Time.UTC().Format(time.RFC3339Nano)
+		} else if base != "" {
+			item.Link = base + "/tx/" + record.TxHash
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return feed, nil
+}
+
+// 条目标题取消息的前 80 个字符
+func feedItemTitle(message string) string {
+	if utf8.RuneCountInString(message) <= 80 {
+		return message
+	}
+	return string([]rune(message)[:80]) + "…"
+}
+
+// 提供 /feed.xml，需要只读范围。阅读器通常无法设置请求头，令牌也可以用 ?token= 传入
+func registerFeedHandler(mux *http.ServeMux) {
+	mux.Handle("GET /feed.xml", requireScope(scopeRead, true, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		feed, err := buildFeed(r.Context(), getFeedConfig(), time.Now())
+		if err != nil {
+			writeAdminError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		w.Write([]byte(xml.Header))
+		encoder := xml.NewEncoder(w)
+		encoder.Indent("", "  ")
+		encoder.Encode(feed)
+	})))
+}
@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// 买卖力量统计配置
+type FlowConfig struct {
+	Enabled bool     `json:"enabled"`
+	Windows []string `json:"windows"` // 统计窗口，例如 ["1h", "24h"]
+}
+
+func getFlowConfig() FlowConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Flow
+	if len(cfg.Windows) == 0 {
+		cfg.Windows = []string{"1h", "24h"}
+	}
+	return cfg
+}
+
+// 生成附加在告警中的买卖力量统计，例如 " Flow: 1h 62pct sell, 24h 55pct buy"
+func flowSummary(ctx context.Context, now time.Time) localizedText {
+	cfg := getFlowConfig()
+	if !cfg.Enabled {
+		return localizedText{}
+	}
+
+	var parts []localizedText
+	for _, w := range cfg.Windows {
+		window, err := time.ParseDuration(w)
+		if err != nil {
+			slog.Error("Invalid flow window", "window", w, "error", err)
+			continue
+		}
+		summary := summarizeSwaps(querySwapHistory(ctx, now.Add(-window), now.Add(time.Second)))
+		if summary.VolumeUSD == 0 {
+			continue
+		}
+		buyPct := summary.BuyVolumeUSD / summary.VolumeUSD * 100
+		side, pct := i18nText("flow.buy"), buyPct
+		if buyPct < 50 {
+			side, pct = i18nText("flow.sell"), 100-buyPct
+		}
+		parts = append(parts, i18nText("flow.window", w, side, pct))
+	}
+	if len(parts) == 0 {
+		return localizedText{}
+	}
+	return i18nText("flow.summary", localizedList{Separator: "list.separator", Items: parts})
+}
@@ -0,0 +1,241 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 金样输出中告警与汇总消息使用的固定时间
+var goldenTime = time.Date(2025, 2, 18, 8, 30, 0, 0, time.UTC)
+
+// 一种渠道配置：渠道、消息格式与详细程度
+type goldenVariant struct {
+	channel   string
+	format    string
+	verbosity string
+}
+
+// Bark 只有纯文本，Telegram 覆盖全部格式，两者都覆盖全部详细程度
+func goldenVariants() []goldenVariant {
+	var variants []goldenVariant
+	verbosities := []string{verbosityStandard, verbosityCompact, verbosityVerbose}
+	for _, verbosity := range verbosities {
+		variants = append(variants, goldenVariant{channelBark, formatPlain, verbosity})
+	}
+	for _, format := range []string{formatPlain, formatMarkdown, formatHTML} {
+		for _, verbosity := range verbosities {
+			variants = append(variants, goldenVariant{channelTelegram, format, verbosity})
+		}
+	}
+	return variants
+}
+
+var updateGolden = flag.Bool("update", false, "按当前输出重写 testdata/golden 中的金样文件")
+
+// TestGolden 用样例交易渲染每种 渠道/格式/详细程度/语言 组合的内置模板，与金样文件比较并输出差异，
+// 用于在提交前以 diff 的形式审查格式变化。
+//
+// 样例交易为 testdata/golden/swaps/*.json，金样文件为 testdata/golden/<样例名>.<语言>.golden，
+// 告警与汇总消息为 testdata/golden/messages.<语言>.golden。渲染时使用默认配置。
+// 格式有意修改时按当前输出重写金样文件：
+//
+//	go test ./logic -run TestGolden -update
+func TestGolden(t *testing.T) {
+	dir := filepath.Join("testdata", "golden")
+	fixtures, err := filepath.Glob(filepath.Join(dir, "swaps", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatalf("no fixtures found in %s", filepath.Join(dir, "swaps"))
+	}
+	quietLogs(t)
+	previous := getConfigSnapshot()
+	t.Cleanup(func() { applyConfig(previous) })
+
+	check := func(name string, render func(lang string) ([]byte, error)) {
+		for _, lang := range goldenLanguages() {
+			file := name + "." + lang + ".golden"
+			t.Run(file, func(t *testing.T) {
+				compareGolden(t, filepath.Join(dir, file), lang, render)
+			})
+		}
+	}
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".json")
+		check(name, func(lang string) ([]byte, error) { return renderGoldenSwaps(fixture, lang) })
+	}
+	check("messages", renderGoldenMessages)
+}
+
+// 内置语言包中的全部语言
+func goldenLanguages() []string {
+	applyConfig(Config{})
+	var langs []string
+	for lang := range loadLocales() {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// 渲染并与金样文件比较，不一致时输出差异；-update 时直接写入
+func compareGolden(t *testing.T, path, lang string, render func(lang string) ([]byte, error)) {
+	got, err := render(lang)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *updateGolden {
+		if err := writeFileAtomic(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatal("golden file missing, run with -update")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\n%s", path, lineDiff(string(want), string(got)))
+	}
+}
+
+// 按各渠道配置渲染样例文件中的交易
+func renderGoldenSwaps(fixture, lang string) ([]byte, error) {
+	swaps, err := renderSwaps(context.Background(), fixture, "", "")
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	for _, variant := range goldenVariants() {
+		applyGoldenConfig(variant, lang)
+		locale := defaultLocale()
+		var samples []interface{}
+		for i := range swaps {
+			event, err := normalizeSwap(&swaps[i])
+			if err != nil {
+				return nil, fmt.Errorf("swap %s: %w", swaps[i].TransactionHash, err)
+			}
+			data := newSwapTemplateData(context.Background(), event)
+			data.localeData, data.TimeText = locale, locale.FormatTime(event.Time)
+			data.Level = whaleBarkOptions(event).Level
+			samples = append(samples, data)
+		}
+		if err := errors.Join(checkMessageTemplates(templateSwap, samples)...); err != nil {
+			return nil, err
+		}
+		for i, data := range samples {
+			fmt.Fprintf(&out, "== %s #%d %s (%s, %s) ==\n", templateSwap, i+1, variant.channel, variant.format, variant.verbosity)
+			writeNotification(&out, variant.channel, renderNotification(templateSwap, variant.channel, data))
+			out.WriteString("\n")
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// 渲染告警与汇总消息，正文使用语言包中的文本
+func renderGoldenMessages(lang string) ([]byte, error) {
+	var out bytes.Buffer
+	for _, variant := range goldenVariants() {
+		if variant.verbosity != verbosityStandard {
+			// 告警与汇总没有按详细程度区分的内置模板
+			continue
+		}
+		applyGoldenConfig(variant, lang)
+		locale := defaultLocale()
+		for _, kind := range []string{templateAlert, templateDigest} {
+			data := messageTemplateData{
+				localeData: locale,
+				Message:    collapsedMessage(lang, variant.channel, []string{i18nText("alert.selfTest").in(lang)}, 3),
+				Level:      barkLevelActive,
+				Time:       goldenTime,
+				TimeText:   locale.FormatTime(goldenTime),
+			}
+			fmt.Fprintf(&out, "== %s %s (%s) ==\n", kind, variant.channel, variant.format)
+			writeNotification(&out, variant.channel, renderNotification(kind, variant.channel, data))
+			out.WriteString("\n")
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// 使用默认配置，只设置语言、Telegram 格式和渠道的详细程度
+func applyGoldenConfig(variant goldenVariant, lang string) {
+	cfg := Config{}
+	cfg.I18n.Language = lang
+	cfg.Telegram.Format = variant.format
+	cfg.Templates.Verbosity = map[string]string{variant.channel: variant.verbosity}
+	applyConfig(cfg)
+}
+
+// 逐行比较，输出带两行上下文的差异，- 为金样文件中的行，+ 为渲染结果中的行
+func lineDiff(want, got string) string {
+	a, b := strings.Split(want, "\n"), strings.Split(got, "\n")
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	type line struct {
+		op   byte
+		text string
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i]})
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j]})
+			j++
+		}
+	}
+
+	// 只输出变化的行及其前后两行
+	const context = 2
+	keep := make([]bool, len(lines))
+	for k, l := range lines {
+		if l.op != ' ' {
+			for n := max(k-context, 0); n <= min(k+context, len(lines)-1); n++ {
+				keep[n] = true
+			}
+		}
+	}
+	var out strings.Builder
+	for k, l := range lines {
+		if !keep[k] {
+			continue
+		}
+		if k > 0 && !keep[k-1] && out.Len() > 0 {
+			out.WriteString("...\n")
+		}
+		fmt.Fprintf(&out, "%c %s\n", l.op, l.text)
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
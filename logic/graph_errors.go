@@ -0,0 +1,102 @@
+package logic
+
+import (
+	"expvar"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GraphQL 错误分类
+const (
+	graphErrIndexing  = "indexing"   // 子图索引失败或尚未同步
+	graphErrSyntax    = "syntax"     // 查询语法或字段错误
+	graphErrRateLimit = "rate_limit" // 触发网关限流
+	graphErrUnknown   = "unknown"    // 其他错误
+)
+
+// 按分类统计的 GraphQL 错误次数
+var graphErrorCounter = expvar.NewMap("graph_errors")
+
+// GraphError GraphQL 响应中 errors 数组的单个元素
+type GraphError struct {
+	Message   string `json:"message"`
+	Locations []struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"locations,omitempty"`
+	Path []interface{} `json:"path,omitempty"`
+}
+
+// GraphQueryError 一次 GraphQL 查询返回的错误
+type GraphQueryError struct {
+	Kind     string
+	Endpoint string
+	Messages []string
+}
+
+func (e *GraphQueryError) Error() string {
+	return fmt.Sprintf("graph query failed (%s) at %s: %s", e.Kind, e.Endpoint, strings.Join(e.Messages, "; "))
+}
+
+// Retryable 是否应该切换到备用端点重试，语法错误换端点也无法解决
+func (e *GraphQueryError) Retryable() bool {
+	return e.Kind != graphErrSyntax
+}
+
+// 部署不存在，例如 "deployment `Qm...` not found"
+var deploymentNotFoundPattern = regexp.MustCompile(`deployment\b.*\bnot found`)
+
+// 根据错误信息判断错误类型，语法错误优先，查询中的字段名包含 subgraph、deployment 时不会被误判为索引错误
+func classifyGraphError(message string) string {
+	msg := strings.ToLower(message)
+	switch {
+	case containsAny(msg, "rate limit", "too many requests", "quota", "throttl"):
+		return graphErrRateLimit
+	case containsAny(msg, "syntax error", "unexpected", "unknown field", "no such field", "cannot query field", "unknown argument", "invalid value", "parse"):
+		return graphErrSyntax
+	case containsAny(msg, "indexing error", "indexing_error", "has not started syncing", "subgraph not found", "store error", "block not found"),
+		deploymentNotFoundPattern.MatchString(msg):
+		return graphErrIndexing
+	default:
+		return graphErrUnknown
+	}
+}
+
+// 将 errors 数组转换为 GraphQueryError，取最严重的分类作为整体分类
+func newGraphQueryError(endpoint string, errs []GraphError) *GraphQueryError {
+	queryErr := &GraphQueryError{Kind: graphErrUnknown, Endpoint: endpoint}
+	for _, e := range errs {
+		kind := classifyGraphError(e.Message)
+		if graphErrorPriority(kind) > graphErrorPriority(queryErr.Kind) {
+			queryErr.Kind = kind
+		}
+		queryErr.Messages = append(queryErr.Messages, e.Message)
+	}
+	graphErrorCounter.Add(queryErr.Kind, 1)
+	return queryErr
+}
+
+// 分类优先级，数值越大越优先
+func graphErrorPriority(kind string) int {
+	switch kind {
+	case graphErrSyntax:
+		return 3
+	case graphErrIndexing:
+		return 2
+	case graphErrRateLimit:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// 判断字符串是否包含任意一个子串
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package logic
+
+import "testing"
+
+func TestClassifyGraphError(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"Rate limit exceeded", graphErrRateLimit},
+		{"429 Too Many Requests", graphErrRateLimit},
+		{"Syntax Error: Unexpected Name \"swaps\"", graphErrSyntax},
+		{"Type `Query` has no field `subgraphs`", graphErrUnknown},
+		{"Cannot query field \"deployment\" on type \"Swap\"", graphErrSyntax},
+		{"Unknown argument \"subgraph\" on field \"swaps\"", graphErrSyntax},
+		{"subgraph not found: QmXyz", graphErrIndexing},
+		{"deployment `QmXyz` not found", graphErrIndexing},
+		{"Subgraph `QmXyz` has not started syncing yet", graphErrIndexing},
+		{"indexing_error", graphErrIndexing},
+		{"Store error: database unavailable", graphErrIndexing},
+		{"deployment is healthy", graphErrUnknown},
+		{"internal server error", graphErrUnknown},
+	}
+	for _, tt := range tests {
+		if got := classifyGraphError(tt.message); got != tt.want {
+			t.Errorf("classifyGraphError(%q) = %s, want %s", tt.message, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package logic
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 每轮轮询把需要的实体合并成一个带别名的 GraphQL 文档，一次请求取回，减少受限网关上的请求数。
+// 目前只有 swaps 和 _meta（子图的索引区块与索引错误），新实体按同样的方式追加查询片段和 GraphResponse 字段
+
+// swaps 查询片段，按区块升序分页，%s 为 gt 或 gte
+const swapsQueryTemplate = `swaps: swaps(first: %d, orderBy: blockNumber, orderDirection: asc, where: {blockNumber_%s: %d}) {
+    id
+    sender
+    recipient
+    amount0
+    amount1
+    sqrtPriceX96
+    liquidity
+    tick
+    blockNumber
+    blockTimestamp
+    transactionHash
+    btcPrice
+  }`
+
+// _meta 查询片段
+const graphMetaQuery = `meta: _meta {
+    block {
+      number
+      timestamp
+    }
+    hasIndexingErrors
+  }`
+
+// 子图的索引状态
+type GraphMeta struct {
+	Block struct {
+		Number    int64 `json:"number"`
+		Timestamp int64 `json:"timestamp"`
+	} `json:"block"`
+	HasIndexingErrors bool `json:"hasIndexingErrors"`
+}
+
+// 把多个查询片段合并为一个文档
+func buildGraphQuery(parts ...string) string {
+	return "{\n  " + strings.Join(parts, "\n  ") + "\n}"
+}
+
+// 一页 swaps 查询，inclusive 时包含 startBlock 本身，withMeta 时同一请求中带上 _meta
+func swapsQuery(pageSize, startBlock int, inclusive, withMeta bool) string {
+	op := "gt"
+	if inclusive {
+		op = "gte"
+	}
+	parts := []string{fmt.Sprintf(swapsQueryTemplate, pageSize, op, startBlock)}
+	if withMeta {
+		parts = append(parts, graphMetaQuery)
+	}
+	return buildGraphQuery(parts...)
+}
+
+// 各数据源最近一次查询到的子图状态
+type subgraphStatus struct {
+	Block          int64     `json:"block"`
+	BlockTime      time.Time `json:"blockTime"`
+	IndexingErrors bool      `json:"indexingErrors"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+var (
+	subgraphStatuses      = make(map[string]subgraphStatus)
+	subgraphStatusesMutex sync.Mutex
+)
+
+// 记录数据源的子图状态，出现或恢复索引错误时记录日志
+func recordGraphMeta(source string, meta *GraphMeta, now time.Time) {
+	if meta == nil {
+		return
+	}
+	subgraphStatusesMutex.Lock()
+	defer subgraphStatusesMutex.Unlock()
+	previous := subgraphStatuses[source]
+	if meta.HasIndexingErrors != previous.IndexingErrors {
+		if meta.HasIndexingErrors {
+			slog.Warn("Subgraph has indexing errors", "source", source, "block", meta.Block.Number)
+		} else if !previous.UpdatedAt.IsZero() {
+			slog.Info("Subgraph indexing errors cleared", "source", source, "block", meta.Block.Number)
+		}
+	}
+	subgraphStatuses[source] = subgraphStatus{
+		Block:          meta.Block.Number,
+		BlockTime:      time.Unix(meta.Block.Timestamp, 0),
+		IndexingErrors: meta.HasIndexingErrors,
+		UpdatedAt:      now,
+	}
+}
+
+func listSubgraphStatuses() map[string]subgraphStatus {
+	subgraphStatusesMutex.Lock()
+	defer subgraphStatusesMutex.Unlock()
+	statuses := make(map[string]subgraphStatus, len(subgraphStatuses))
+	for source, status := range subgraphStatuses {
+		statuses[source] = status
+	}
+	return statuses
+}
@@ -0,0 +1,169 @@
+package logic
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Graph 响应录制配置。开启后把包含交易或错误的原始响应按天写入 JSON Lines 文件，
+// 没有新交易的正常响应不记录，replay 命令可以重新处理这些响应
+type RecordingConfig struct {
+	Dir           string `json:"dir"`           // 录制目录，为空时不录制
+	RetentionDays int    `json:"retentionDays"` // 保留天数，默认 3
+}
+
+func getRecordingConfig() RecordingConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Recording
+	if cfg.RetentionDays <= 0 {
+		cfg.RetentionDays = 3
+	}
+	return cfg
+}
+
+// 一次录制的 Graph 响应
+type graphRecording struct {
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`
+	Endpoint string    `json:"endpoint"` // 只记录主机名，地址中可能包含密钥
+	Query    string    `json:"query"`
+	Status   int       `json:"status"`
+	Body     string    `json:"body"` // 原始响应体，可能不是合法的 JSON
+}
+
+// 录制文件名，按 UTC 日期分文件
+const recordingFilePattern = "graph-*.jsonl"
+
+func recordingFile(dir string, t time.Time) string {
+	return filepath.Join(dir, "graph-"+t.UTC().Format("20060102")+".jsonl")
+}
+
+var recordingMutex sync.Mutex
+
+// 录制一次响应，只记录非 200、GraphQL 错误、无法解析或包含交易的响应
+func recordGraphResponse(endpoint, query string, status int, body []byte, page *GraphResponse, decodeErr error) {
+	dir := getRecordingConfig().Dir
+	if dir == "" {
+		return
+	}
+	if status == 200 && decodeErr == nil && len(page.Errors) == 0 && len(page.Data.Swaps) == 0 {
+		return
+	}
+	now := time.Now()
+	line, err := json.Marshal(graphRecording{
+		Time:     now,
+		Source:   graphSourceName(endpoint),
+		Endpoint: urlHost(endpoint),
+		Query:    query,
+		Status:   status,
+		Body:     string(body),
+	})
+	if err != nil {
+		slog.Error("Failed to encode graph recording", "error", err)
+		return
+	}
+
+	recordingMutex.Lock()
+	defer recordingMutex.Unlock()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		slog.Error("Failed to create recording directory", "dir", dir, "error", err)
+		return
+	}
+	f, err := os.OpenFile(recordingFile(dir, now), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		slog.Error("Failed to open recording file", "error", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		slog.Error("Failed to write graph recording", "error", err)
+	}
+}
+
+// 地址所属的数据源名称
+func graphSourceName(endpoint string) string {
+	for _, source := range getGraphSources() {
+		if contains(source.urls, endpoint) {
+			return source.name
+		}
+	}
+	return primaryGraphSource
+}
+
+// 删除超过保留天数的录制文件，由存储清理任务调用
+func pruneGraphRecordings(now time.Time) error {
+	cfg := getRecordingConfig()
+	if cfg.Dir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(cfg.Dir, recordingFilePattern))
+	if err != nil {
+		return err
+	}
+	oldest := filepath.Base(recordingFile(cfg.Dir, now.AddDate(0, 0, -cfg.RetentionDays)))
+	recordingMutex.Lock()
+	defer recordingMutex.Unlock()
+	for _, file := range files {
+		if filepath.Base(file) < oldest {
+			if err := os.Remove(file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 读取目录中 [since, until) 区间内的录制，按时间排序
+func readGraphRecordings(dir string, since, until time.Time) ([]graphRecording, error) {
+	files, err := filepath.Glob(filepath.Join(dir, recordingFilePattern))
+	if err != nil {
+		return nil, err
+	}
+	first, last := filepath.Base(recordingFile(dir, since)), filepath.Base(recordingFile(dir, until))
+	var recordings []graphRecording
+	for _, file := range files {
+		if name := filepath.Base(file); name < first || name > last {
+			continue
+		}
+		if err := scanGraphRecordings(file, func(r graphRecording) {
+			if !r.Time.Before(since) && r.Time.Before(until) {
+				recordings = append(recordings, r)
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(recordings, func(i, j int) bool { return recordings[i].Time.Before(recordings[j].Time) })
+	return recordings, nil
+}
+
+func scanGraphRecordings(file string, fn func(graphRecording)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64<<10), 64<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var r graphRecording
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			return fmt.Errorf("%s:%d: %w", file, n, err)
+		}
+		fn(r)
+	}
+	return scanner.Err()
+}
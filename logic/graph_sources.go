@@ -0,0 +1,91 @@
+package logic
+
+import (
+	"context"
+	"sync"
+)
+
+// 额外的 Graph 数据源，例如其他链上同一交易对的池子。代币元数据与主池子相同，
+// 每个数据源有独立的处理进度，与主数据源（graphAPIURLs）一起并发获取后依次处理
+type GraphSourceConfig struct {
+	Name       string   `json:"name"`       // 数据源名称，用于处理进度和日志，例如 arbitrum
+	URLs       []string `json:"urls"`       // Graph API 地址列表，按顺序故障转移
+	StartBlock string   `json:"startBlock"` // 首次运行时的起始区块号
+}
+
+// 主数据源的名称
+const primaryGraphSource = "primary"
+
+// 一个 Swap 数据源
+type graphSource struct {
+	name       string
+	cursor     string // 处理进度名称
+	urls       []string
+	startBlock string
+}
+
+func getGraphSources() []graphSource {
+	sources := []graphSource{{name: primaryGraphSource, cursor: swapCursorName(), urls: getGraphAPIURLs()}}
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	for _, cfg := range configData.GraphSources {
+		if cfg.Name == "" || len(cfg.URLs) == 0 {
+			continue
+		}
+		sources = append(sources, graphSource{
+			name:       cfg.Name,
+			cursor:     defaultCursorName + ":" + cfg.Name,
+			urls:       cfg.URLs,
+			startBlock: cfg.StartBlock,
+		})
+	}
+	return sources
+}
+
+func getGraphConcurrency() int {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	if configData.GraphConcurrency <= 0 {
+		return 4
+	}
+	return configData.GraphConcurrency
+}
+
+func (s graphSource) Name() string { return s.name }
+
+func (s graphSource) CursorName() string { return s.cursor }
+
+// 读取数据源的处理进度，额外数据源没有进度时使用配置的起始区块，不沿用主数据源的旧版进度
+func (s graphSource) LoadCursor(ctx context.Context, store Store) (Cursor, error) {
+	if s.name == primaryGraphSource {
+		return loadCursor(ctx, store, s.cursor)
+	}
+	cursor, found, err := store.LoadCursor(ctx, s.cursor)
+	if err != nil || found {
+		return cursor, err
+	}
+	return Cursor{LastBlockNumber: s.startBlock}, nil
+}
+
+// 各数据源当前使用的 Graph API 地址下标
+var (
+	activeGraphEndpoints      = make(map[string]int)
+	activeGraphEndpointsMutex sync.Mutex
+)
+
+func (s graphSource) activeEndpoint() int {
+	activeGraphEndpointsMutex.Lock()
+	defer activeGraphEndpointsMutex.Unlock()
+	return activeGraphEndpoints[s.name]
+}
+
+func (s graphSource) setActiveEndpoint(idx int) {
+	activeGraphEndpointsMutex.Lock()
+	defer activeGraphEndpointsMutex.Unlock()
+	activeGraphEndpoints[s.name] = idx
+}
+
+// 分页获取 from 之后的交易
+func (s graphSource) FetchSwaps(ctx context.Context, from string) ([]Swap, error) {
+	return fetchSwaps(ctx, s, from)
+}
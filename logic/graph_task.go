@@ -2,96 +2,234 @@ package logic
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log/slog"
-	"math/big"
 	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
 	graphAPIURL = "https://api.studio.thegraph.com/query/100116/contract_3e2f0/version/latest"
 	configFile  = "app_config.json" // 合并后的配置文件
+
+	configBackupFile = configFile + ".bak" // 最近一次成功保存的配置
 )
 
 // 配置文件结构
 type Config struct {
-	BarkAPIURLs     []string `json:"barkAPIURLs"`     // Bark API 地址列表
-	LastBlockNumber string   `json:"lastBlockNumber"` // 上次处理的区块号
-	CurrentTxHashes []string `json:"currentTxHashes"` // 当前已处理的交易哈希列表
-	LimitPrice      int      `json:"limitPrice"`      // 限制 BTC 价格
+	GraphAPIURLs     []string            `json:"graphAPIURLs"`     // Graph API 地址列表，按顺序故障转移
+	GraphSources     []GraphSourceConfig `json:"graphSources"`     // 额外的 Graph 数据源，与主数据源并发获取
+	GraphConcurrency int                 `json:"graphConcurrency"` // 同时查询的数据源数量上限，默认 4
+	BarkAPIURLs      []string            `json:"barkAPIURLs"`      // Bark API 地址列表，存储中没有订阅者时作为默认订阅者
+	LastBlockNumber  string              `json:"lastBlockNumber"`  // 上次处理的区块号（默认进度）
+	Cursors          map[string]string   `json:"cursors"`          // 按数据源区分的处理进度，名称 -> 区块号
+	CurrentTxHashes  []string            `json:"currentTxHashes"`  // 旧版的已处理交易哈希列表，启动后迁移到 seenTxHashes
+	SeenTxHashes     map[string]int64    `json:"seenTxHashes"`     // 已处理的交易哈希 -> 首次处理时间（Unix 秒）
+	LimitPrice       int                 `json:"limitPrice"`       // 限制 BTC 价格
+
+	Settings map[string]map[string]json.RawMessage `json:"settings,omitempty"` // 使用 json 存储时运行中修改的设置，类型 -> 键 -> 值
+
+	HTTP   HTTPConfig   `json:"http"`   // 出站 HTTP 请求配置
+	Rules  RulesConfig  `json:"rules"`  // 通知过滤规则
+	Script ScriptConfig `json:"script"` // 脚本钩子
+	Exec   ExecConfig   `json:"exec"`   // 外部命令
+	Depeg  DepegConfig  `json:"depeg"`  // 脱锚告警
+
+	VolumeSpike VolumeSpikeConfig `json:"volumeSpike"` // 成交量异动告警
+	PriceAlert  PriceAlertConfig  `json:"priceAlert"`  // 均线与价格区间告警
+
+	History HistoryConfig `json:"history"` // 本地历史记录
+	Digest  DigestConfig  `json:"digest"`  // 每日汇总
+
+	WhaleTiers []WhaleTier `json:"whaleTiers"` // 大额交易分级
+
+	RPCURL         string            `json:"rpcURL"`         // 以太坊 JSON-RPC 地址
+	AddressLabels  map[string]string `json:"addressLabels"`  // 地址标签，地址 -> 名称
+	KnownAddresses []KnownAddress    `json:"knownAddresses"` // 补充的路由、聚合器与机器人地址
+	ENS            ENSConfig         `json:"ens"`            // ENS 名称解析
+	Explorer       ExplorerConfig    `json:"explorer"`       // 区块浏览器链接
+	Receipt        ReceiptConfig     `json:"receipt"`        // 交易回执补充信息
+	MEV            MEVConfig         `json:"mev"`            // 夹子交易检测
+	Arbitrage      ArbitrageConfig   `json:"arbitrage"`      // 链上与 CEX 价差告警
+	Pool           PoolConfig        `json:"pool"`           // 监控的池子
+	TVL            TVLConfig         `json:"tvl"`            // 池子 TVL 监控
+	LP             LPConfig          `json:"lp"`             // LP 仓位跟踪
+	Flow           FlowConfig        `json:"flow"`           // 买卖力量统计
+	Spam           SpamConfig        `json:"spam"`           // 小额刷单合并
+
+	PriceHistory PriceHistoryConfig `json:"priceHistory"` // 价格历史
+	Chart        ChartConfig        `json:"chart"`        // 价格与成交量图表
+
+	Storage     StorageConfig     `json:"storage"`     // 处理进度与历史记录的存储
+	Cache       CacheConfig       `json:"cache"`       // 代币、地址与参考价的查询缓存
+	Encryption  EncryptionConfig  `json:"encryption"`  // 静态加密
+	Secrets     SecretsConfig     `json:"secrets"`     // 外部密钥管理
+	Admin       AdminConfig       `json:"admin"`       // 管理接口
+	Tasks       TaskConfig        `json:"tasks"`       // 定时任务
+	Polling     PollingConfig     `json:"polling"`     // Graph 自适应轮询
+	Delivery    DeliveryConfig    `json:"delivery"`    // 推送队列
+	HA          HAConfig          `json:"ha"`          // 多实例主备
+	SelfTest    SelfTestConfig    `json:"selfTest"`    // 启动自检
+	Watchdog    WatchdogConfig    `json:"watchdog"`    // 运行状态监控
+	Tracing     TracingConfig     `json:"tracing"`     // 链路追踪
+	Health      HealthConfig      `json:"health"`      // 健康检查
+	Log         LogConfig         `json:"log"`         // 日志
+	ErrorReport ErrorReportConfig `json:"errorReport"` // 错误上报
+	Audit       AuditConfig       `json:"audit"`       // 通知决策审计
+	Recording   RecordingConfig   `json:"recording"`   // Graph 响应录制
+	Chaos       ChaosConfig       `json:"chaos"`       // 故障注入，仅用于测试
+	Debug       DebugConfig       `json:"debug"`       // pprof 与 expvar 调试接口
+	GRPC        GRPCConfig        `json:"grpc"`        // gRPC 接口
+	Feed        FeedConfig        `json:"feed"`        // RSS 订阅
+	Telegram    TelegramConfig    `json:"telegram"`    // Telegram 通知与命令
+	Inbound     InboundConfig     `json:"inbound"`     // 外部告警接收
+	Kafka       KafkaConfig       `json:"kafka"`       // Kafka 输出
+	NATS        NATSConfig        `json:"nats"`        // NATS JetStream 输出
+	RedisOutput RedisOutputConfig `json:"redisOutput"` // Redis 频道与流输出
+	AMQP        AMQPConfig        `json:"amqp"`        // AMQP 输出
+
+	HomeAssistant HomeAssistantConfig `json:"homeAssistant"` // Home Assistant 传感器
+	Alertmanager  AlertmanagerConfig  `json:"alertmanager"`  // 转发到 Alertmanager
+	Templates     TemplatesConfig     `json:"templates"`     // 消息模板
+	I18n          I18nConfig          `json:"i18n"`          // 通知语言
+	Numbers       NumberFormatConfig  `json:"numbers"`       // 通知中的数字格式
+	Emoji         EmojiConfig         `json:"emoji"`         // 通知中的方向与分级 emoji
 }
 
 var (
 	configData  Config       // 全局配置数据
 	configMutex sync.RWMutex // 配置读写锁
+
+	// 最近一次保存或重新加载时的配置文件内容。json 存储每轮保存进度都会重写配置文件，
+	// 内容相同时不重新加载，避免清空密钥缓存、重置客户端与模板
+	lastConfigContent      []byte
+	lastConfigContentMutex sync.Mutex
 )
 
-func init() {
-	// 初始化时加载配置
-	loadConfig()
-	// 启动文件监控
-	go watchConfig()
+var initOnce sync.Once
+
+// Init 加载当前目录的配置文件并监控文件变化，文件不存在时写入默认配置。
+// 独立运行时在 main 中调用，导入本包不会读写任何文件；多次调用只执行一次
+func Init() {
+	initOnce.Do(func() {
+		loadConfig()
+		go watchConfig()
+	})
 }
 
-// 加载配置文件
+// 加载配置文件，配置文件缺失或损坏时使用最近一次成功保存的备份
 func loadConfig() {
-	file, err := os.Open(configFile)
+	newConfig, err := readConfigFile(configFile)
 	if err != nil {
-		slog.Error("Error opening config file, using default config", "error", err)
-		// 如果配置文件不存在，使用默认配置
-		configData = Config{
-			BarkAPIURLs: []string{
-				"https://api.day.app/iuizSoSLLvtMTZhhmuWetY/%E4%BA%A4%E6%98%93%E6%8F%90%E9%86%92/",
-			},
-			LastBlockNumber: "21612681",
-			CurrentTxHashes: []string{"0xccce6256453e517062bb4cfb74494a0bdb2fefa793f75d3d31cf041d76bf99fd"},
-			LimitPrice:      1000,
+		slog.Error("Error reading config file, trying backup", "error", err)
+		newConfig, err = readConfigFile(configBackupFile)
+		if err != nil {
+			if _, statErr := os.Stat(configFile); statErr == nil {
+				// 配置文件存在但无法解析，保留当前配置
+				slog.Error("Error reading config backup, keeping current config", "error", err)
+				return
+			}
+			slog.Error("Error opening config file, using default config", "error", err)
+			// 如果配置文件不存在，使用默认配置
+			configMutex.Lock()
+			configData = Config{
+				BarkAPIURLs: []string{
+					"https://api.day.app/iuizSoSLLvtMTZhhmuWetY/%E4%BA%A4%E6%98%93%E6%8F%90%E9%86%92/",
+				},
+				LastBlockNumber: "21612681",
+				CurrentTxHashes: []string{"0xccce6256453e517062bb4cfb74494a0bdb2fefa793f75d3d31cf041d76bf99fd"},
+				LimitPrice:      1000,
+			}
+			configMutex.Unlock()
+			saveConfig()
+			return
 		}
-		saveConfig()
-		return
+		slog.Warn("Loaded config from backup", "path", configBackupFile)
 	}
-	defer file.Close()
 
-	decoder := json.NewDecoder(file)
-	var newConfig Config
-	err = decoder.Decode(&newConfig)
-	if err != nil {
-		slog.Error("Error decoding config data", "error", err)
-		return
-	}
+	applyConfig(newConfig)
+}
 
-	// 更新全局配置
+// 更新全局配置，并重置依赖配置的客户端、模板与语言包
+func applyConfig(newConfig Config) {
 	configMutex.Lock()
 	configData = newConfig
 	configMutex.Unlock()
+	resetHTTPClient()
+	resetSecretCache()
+	applyLogLevel()
+	applyLogSampling()
+	applyConditions(newConfig.Rules.Conditions)
+	resetScript()
+	resetMessageTemplates()
+	resetLocales()
 }
 
-// 保存配置文件
-func saveConfig() {
-	file, err := os.Create(configFile)
+// 当前配置的副本，用于临时修改后恢复
+func getConfigSnapshot() Config {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData
+}
+
+// 读取并解析配置文件，内容无效时返回错误
+func readConfigFile(path string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(path)
 	if err != nil {
-		slog.Error("Error creating config file", "error", err)
-		return
+		return config, err
 	}
-	defer file.Close()
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, err
+	}
+	return config, validateConfig(config)
+}
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ") // 格式化输出
-	err = encoder.Encode(&configData)
+// 检查应用前就能发现的配置错误，目前为无法编译的条件表达式
+func validateConfig(cfg Config) error {
+	if _, err := compileConditions(cfg.Rules.Conditions); err != nil {
+		return fmt.Errorf("invalid rules.conditions: %w", err)
+	}
+	return nil
+}
+
+// 保存配置文件，写入成功后同时更新备份
+func saveConfig() {
+	configMutex.RLock()
+	data, err := json.MarshalIndent(&configData, "", "  ") // 格式化输出
+	configMutex.RUnlock()
 	if err != nil {
 		slog.Error("Error encoding config data", "error", err)
+		return
+	}
+	data = append(data, '\n')
+
+	lastConfigContentMutex.Lock()
+	lastConfigContent = data
+	lastConfigContentMutex.Unlock()
+	if err := writeFileAtomic(configFile, data, 0644); err != nil {
+		slog.Error("Error writing config file", "error", err)
+		return
+	}
+	if err := writeFileAtomic(configBackupFile, data, 0644); err != nil {
+		slog.Error("Error writing config backup", "error", err)
 	}
 }
 
-// 监控配置文件变化
+// 监控配置文件变化。保存时通过重命名替换文件，因此监控所在目录而不是文件本身
 func watchConfig() {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -100,9 +238,9 @@ func watchConfig() {
 	}
 	defer watcher.Close()
 
-	err = watcher.Add(configFile)
+	err = watcher.Add(filepath.Dir(configFile))
 	if err != nil {
-		slog.Error("Failed to add config file to watcher", "error", err)
+		slog.Error("Failed to add config directory to watcher", "error", err)
 		return
 	}
 
@@ -112,9 +250,13 @@ func watchConfig() {
 			if !ok {
 				return
 			}
-			if event.Op&fsnotify.Write == fsnotify.Write {
+			if filepath.Base(event.Name) != filepath.Base(configFile) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) != 0 && configFileChanged() {
 				slog.Info("Config file modified, reloading...")
 				loadConfig() // 配置文件修改时重新加载
+				rescheduleDigestTasks()
 			}
 		case err, ok := <-watcher.Errors:
 			if !ok {
@@ -125,11 +267,37 @@ func watchConfig() {
 	}
 }
 
+// 配置文件内容与最近一次保存或重新加载时不同，读取失败时视为已变化
+func configFileChanged() bool {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return true
+	}
+	lastConfigContentMutex.Lock()
+	defer lastConfigContentMutex.Unlock()
+	if bytes.Equal(data, lastConfigContent) {
+		return false
+	}
+	lastConfigContent = data
+	return true
+}
+
+// 获取 Graph API 地址列表，未配置时使用默认地址
+func getGraphAPIURLs() []string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	if len(configData.GraphAPIURLs) == 0 {
+		return []string{graphAPIURL}
+	}
+	return configData.GraphAPIURLs
+}
+
 // 获取 Bark API 地址列表
 func getBarkAPIURLs() []string {
 	configMutex.RLock()
-	defer configMutex.RUnlock()
-	return configData.BarkAPIURLs
+	urls := configData.BarkAPIURLs
+	configMutex.RUnlock()
+	return secretValues(urls)
 }
 
 func getLimitPrice() int {
@@ -145,13 +313,6 @@ func getLastBlockNumber() string {
 	return configData.LastBlockNumber
 }
 
-// 获取当前已处理的交易哈希列表
-func getCurrentTxHashes() []string {
-	configMutex.RLock()
-	defer configMutex.RUnlock()
-	return configData.CurrentTxHashes
-}
-
 // 更新上次处理的区块号
 func setLastBlockNumber(blockNumber string) {
 	configMutex.Lock()
@@ -159,32 +320,6 @@ func setLastBlockNumber(blockNumber string) {
 	configData.LastBlockNumber = blockNumber
 }
 
-// 更新当前已处理的交易哈希列表
-func setCurrentTxHashes(txHashes []string) {
-	configMutex.Lock()
-	defer configMutex.Unlock()
-	configData.CurrentTxHashes = txHashes
-}
-
-// GraphQL 查询模板
-const queryTemplate = `
-{
-  swaps(first: %d, orderBy: blockNumber, orderDirection: desc, where: {blockNumber_gt: %d}) {
-    id
-    sender
-    recipient
-    amount0
-    amount1
-    sqrtPriceX96
-    liquidity
-    tick
-    blockNumber
-    blockTimestamp
-    transactionHash
-    btcPrice
-  }
-}`
-
 // Swap 数据结构
 type Swap struct {
 	ID              string `json:"id"`
@@ -204,183 +339,319 @@ type Swap struct {
 // GraphResponse 数据结构
 type GraphResponse struct {
 	Data struct {
-		Swaps []Swap `json:"swaps"`
+		Swaps []Swap     `json:"swaps"`
+		Meta  *GraphMeta `json:"meta"`
 	} `json:"data"`
+	Errors []GraphError `json:"errors"`
 }
 
 // 获取最新的 Swap 数据
-func fetchSwaps() ([]Swap, error) {
+func fetchSwaps(ctx context.Context, source graphSource, lastBlockNumber string) (allSwaps []Swap, err error) {
+	ctx, span := startSpan(ctx, "fetch_swaps", attribute.String("graph.source", source.name))
+	defer func() {
+		span.SetAttributes(attribute.Int("swaps.count", len(allSwaps)))
+		endSpan(span, err)
+	}()
 	pageSize := 50
-	startBlock, _ := strconv.Atoi(getLastBlockNumber())
-	var allSwaps []Swap
-
-	for {
-		query := fmt.Sprintf(queryTemplate, pageSize, startBlock)
-		requestBody, err := json.Marshal(map[string]string{"query": query})
-		if err != nil {
-			slog.Error("Failed to create request body", "error", err)
+	startBlock, _ := strconv.Atoi(lastBlockNumber)
+	page := graphResponsePool.Get().(*GraphResponse)
+	defer graphResponsePool.Put(page)
+
+	// 按区块升序分页，下一页从上一页的最后一个区块开始（包含该区块，同一区块的交易可能跨页），按 ID 去重。
+	// 第一页同时查询子图状态
+	fetched := make(map[string]bool)
+	for first := true; ; first = false {
+		query := swapsQuery(pageSize, startBlock, !first, first)
+		if err := queryWithFailover(ctx, source, query, page); err != nil {
 			return nil, err
 		}
-
-		req, err := http.NewRequest("POST", graphAPIURL, bytes.NewBuffer(requestBody))
-		if err != nil {
-			slog.Error("Failed to create HTTP request", "error", err)
-			return nil, err
+		if first {
+			recordGraphMeta(source.name, page.Data.Meta, time.Now())
 		}
-		req.Header.Set("Content-Type", "application/json")
-
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			slog.Error("Failed to execute request", "error", err)
-			return nil, err
+		swaps := page.Data.Swaps
+
+		added := 0
+		for _, swap := range swaps {
+			if !fetched[swap.ID] {
+				fetched[swap.ID] = true
+				allSwaps = append(allSwaps, swap)
+				added++
+			}
 		}
-		defer resp.Body.Close()
-
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			slog.Error("Failed to read response body", "error", err)
-			return nil, err
+		if len(swaps) < pageSize {
+			break
 		}
-
-		var graphResponse GraphResponse
-		err = json.Unmarshal(body, &graphResponse)
-		if err != nil {
-			slog.Error("Failed to parse response body", "error", err)
-			return nil, err
+		if added == 0 {
+			slog.Warn("Too many swaps in one block, remaining swaps skipped", "source", source.name, "block", startBlock)
+			break
 		}
+		startBlock, _ = strconv.Atoi(swaps[len(swaps)-1].BlockNumber)
+	}
+	// 后续处理按区块从新到旧
+	slices.Reverse(allSwaps)
+	return allSwaps, nil
+}
 
-		if len(graphResponse.Data.Swaps) == 0 {
-			break
+// 依次尝试数据源的各个 Graph API 地址，出现可重试错误时切换到下一个地址，结果写入 page
+func queryWithFailover(ctx context.Context, source graphSource, query string, page *GraphResponse) error {
+	endpoints := source.urls
+	active := source.activeEndpoint()
+	var lastErr error
+	for i := 0; i < len(endpoints); i++ {
+		idx := (active + i) % len(endpoints)
+		err := querySwaps(ctx, endpoints[idx], query, page)
+		if err == nil {
+			if idx != active%len(endpoints) {
+				slog.Warn("Switched graph endpoint", "source", source.name, "endpoint", endpoints[idx])
+			}
+			source.setActiveEndpoint(idx)
+			return nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return err
 		}
+		if queryErr, ok := err.(*GraphQueryError); ok && !queryErr.Retryable() {
+			return err
+		}
+		slog.Warn("Graph endpoint failed, trying next", "source", source.name, "endpoint", endpoints[idx], "error", err)
+	}
+	return lastErr
+}
 
-		allSwaps = append(allSwaps, graphResponse.Data.Swaps...)
-		newStartBlock, _ := strconv.Atoi(graphResponse.Data.Swaps[len(graphResponse.Data.Swaps)-1].BlockNumber)
-		startBlock = newStartBlock
+// GraphQL 请求体
+type graphRequest struct {
+	Query string `json:"query"`
+}
 
-		if len(graphResponse.Data.Swaps) < pageSize {
-			break
+// 轮询之间复用的响应与请求缓冲，减少每秒一轮查询的内存分配
+var (
+	graphResponsePool = sync.Pool{New: func() any { return new(GraphResponse) }}
+	graphBufferPool   = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+)
+
+// 清空响应以便复用，保留 Swap 切片的容量
+func (r *GraphResponse) reset() {
+	swaps := r.Data.Swaps[:cap(r.Data.Swaps)]
+	clear(swaps)
+	r.Data.Swaps = swaps[:0]
+	r.Data.Meta = nil
+	r.Errors = nil
+}
+
+// 向指定地址发送一次 GraphQL 查询，流式解码响应到 page，page 中的 Swap 切片在下次查询时会被覆盖
+func querySwaps(ctx context.Context, endpoint, query string, page *GraphResponse) (err error) {
+	ctx, span := startSpan(ctx, "graph.query", attribute.String("server.address", urlHost(endpoint)))
+	defer func() { endSpan(span, err) }()
+
+	requestBody := graphBufferPool.Get().(*bytes.Buffer)
+	requestBody.Reset()
+	defer graphBufferPool.Put(requestBody)
+	if err := json.NewEncoder(requestBody).Encode(graphRequest{Query: query}); err != nil {
+		slog.Error("Failed to create request body", "error", err)
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(withChaosTarget(ctx, chaosGraph), "POST", endpoint, bytes.NewReader(requestBody.Bytes()))
+	if err != nil {
+		slog.Error("Failed to create HTTP request", "error", err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		slog.Error("Failed to execute request", "error", err)
+		return err
+	}
+	defer func() {
+		// 读完剩余内容，连接才能复用
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		queryErr := newGraphQueryError(endpoint, []GraphError{{Message: "too many requests: " + resp.Status}})
+		slog.Error("Graph API rate limited", "endpoint", endpoint, "kind", queryErr.Kind)
+		recordGraphResponse(endpoint, query, resp.StatusCode, nil, nil, queryErr)
+		return queryErr
+	}
+
+	// 调试日志和响应录制需要完整的响应内容，只在开启时保留一份
+	body := io.Reader(resp.Body)
+	debug := slog.Default().Enabled(ctx, slog.LevelDebug)
+	var rawBody *bytes.Buffer
+	if debug || getRecordingConfig().Dir != "" {
+		rawBody = graphBufferPool.Get().(*bytes.Buffer)
+		rawBody.Reset()
+		defer graphBufferPool.Put(rawBody)
+		body = io.TeeReader(resp.Body, rawBody)
+	}
+
+	page.reset()
+	err = json.NewDecoder(body).Decode(page)
+	if rawBody != nil {
+		if debug {
+			slog.Debug("Graph API response", "endpoint", urlHost(endpoint), "status", resp.Status, "body", rawBody.String())
 		}
+		recordGraphResponse(endpoint, query, resp.StatusCode, rawBody.Bytes(), page, err)
 	}
-	return allSwaps, nil
+	if err != nil {
+		slog.Error("Failed to parse response body", "error", err, "status", resp.Status)
+		return err
+	}
+
+	if len(page.Errors) > 0 {
+		queryErr := newGraphQueryError(endpoint, page.Errors)
+		slog.Error("Graph API returned errors", "endpoint", endpoint, "kind", queryErr.Kind, "messages", queryErr.Messages)
+		return queryErr
+	}
+	return nil
 }
 
-// 发送通知
-func sendNotification(swap Swap) error {
+// 发送通知，now 为当前时间，用于小额刷单合并、暂停判断和订阅者的免打扰时段
+func sendNotification(ctx context.Context, swap Swap, mevTag string, now time.Time) (err error) {
+	ctx, span := startSpan(ctx, "notify", attribute.String("tx.hash", swap.TransactionHash),
+		attribute.String("block.number", swap.BlockNumber))
+	defer func() { endSpan(span, err) }()
+
 	timestamp, _ := strconv.ParseInt(swap.BlockTimestamp, 10, 64)
-	loc, _ := time.LoadLocation("Asia/Shanghai")
-	readableTime := time.Unix(timestamp, 0).In(loc).Format("2006-01-02 15:04:05")
+	readableTime := time.Unix(timestamp, 0).In(defaultLocation()).Format("2006-01-02 15:04:05")
 	slog.Info("New swap detected", "blockNumber", swap.BlockNumber, "transactionHash", swap.TransactionHash, "blockTimes", readableTime, "btcPrice", swap.BtcPrice)
 
-	message, vol := FormatSwap(&swap)
-	if message == "" {
+	event, ok := filterSwap(ctx, &swap, now)
+	if !ok {
 		return nil
 	}
-	volBtc := new(big.Float).Quo(vol, big.NewFloat(1e8))
-	volBtcStr := volBtc.Text('f', 2)
-	limitPriceFloat := big.NewFloat(float64(getLimitPrice()))
-	if volBtc.Cmp(limitPriceFloat) > 0 {
-		slog.Info("Volume > limitPrice, sending notification", "volume", volBtcStr)
-	} else {
-		slog.Info("Volume < limitPrice, skipping notification", "volume", volBtcStr)
+	if until, paused := notificationsPaused(now); paused {
+		slog.Info("Notifications paused, skipping", "transactionHash", swap.TransactionHash, "until", until)
+		auditSuppressedSwap(&swap, event, auditReasonPaused, "until "+until.Format(time.RFC3339))
 		return nil
 	}
 
-	for _, baseURL := range getBarkAPIURLs() {
-		baseURL = baseURL + message + "?call=1&level=critical"
-		slog.Info("Notification sent test", "url", baseURL)
-		resp, err := http.Get(baseURL)
-		if err != nil {
-			slog.Error("Failed to send notification to device", "url", baseURL, "error", err)
-			continue
+	_, enrichSpan := startSpan(ctx, "enrich")
+	receipt, tvl, flow, actor := receiptSummary(ctx, swap.TransactionHash), latestTVLSummary(), flowSummary(ctx, event.Time), actorTag(&swap)
+	enrichSpan.End()
+
+	opts := whaleBarkOptions(event)
+	// 按接收者的语言、时区和时间格式生成模板数据
+	localize := func(locale localeData) swapTemplateData {
+		data := newSwapTemplateData(ctx, event)
+		data.localeData, data.TimeText = locale, locale.FormatTime(event.Time)
+		lang := locale.Lang
+		data.Receipt, data.TVL, data.Flow, data.Actor = receipt.in(lang), tvl.in(lang), flow.in(lang), actor.in(lang)
+		data.MEV = mevTag
+		data.Level = opts.Level
+		return data
+	}
+	data := localize(defaultLocale())
+	notice := renderNotification(templateSwap, channelBark, data)
+	message := notice.Body
+	groups := subscriberGroups(ctx, event, opts.Level, now)
+	var targets []string
+	for _, group := range groups {
+		targets = append(targets, group.Bark...)
+	}
+	_, hookSpan := startSpan(ctx, "script_hook")
+	hooked := runScriptHook(ctx, event, message, targets)
+	hookSpan.SetAttributes(attribute.Bool("hook.drop", hooked.Drop))
+	hookSpan.End()
+	if hooked.Drop {
+		slog.Info("Notification dropped by script", "transactionHash", swap.TransactionHash)
+		auditSuppressedSwap(&swap, event, auditReasonScript, "")
+		return nil
+	}
+	// 脚本修改了消息时所有渠道和语言都使用脚本的结果
+	scripted := hooked.Message != message
+	message = hooked.Message
+	notice.Body, notice.URL = message, data.Links.Tx
+	groups = restrictBarkTargets(groups, hooked.Targets)
+
+	opts.Copy = data.Links.Sender
+	image := chartImageURL(ctx, now)
+	telegramFormat := channelFormat(channelTelegram)
+	for _, group := range groups {
+		localized := localize(group.localeData)
+		barkNotice := renderNotification(templateSwap, channelBark, localized)
+		telegramNotice := renderNotification(templateSwap, channelTelegram, localized)
+		if scripted {
+			barkNotice.Body = message
+			telegramNotice.Body = escapeText(telegramFormat, strings.TrimSpace(message+"\n"+data.Links.Tx))
 		}
-		defer resp.Body.Close()
-		if resp.StatusCode != http.StatusOK {
-			slog.Error("Notification failed", "url", baseURL, "status", resp.Status)
-		} else {
-			slog.Info("Notification sent successfully", "url", baseURL)
+		barkNotice.URL, barkNotice.Image = data.Links.Tx, image
+		barkOpts := localizedBarkOptions(barkNotice.barkOptions(opts), group.Lang, templateSwap)
+		if err := deliverSwap(ctx, event, group, barkNotice.Body, barkOpts, telegramNotice); err != nil {
+			return err
 		}
 	}
+	runExecHook(ctx, event, opts.Level, message)
+	notifyAlertHandlers(ctx, Alert{SwapEvent: event, Level: opts.Level, Message: message, MEV: mevTag})
+	forwardAlertmanager(ctx, swapAlertmanagerAlert(event, notice, opts.Level))
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	logNotification(ctx, swap.TransactionHash, hooked.Targets, message)
+	auditNotified(&swap, event)
 	return nil
 }
 
-// FormatSwap 格式化 Swap 数据
-func FormatSwap(swap *Swap) (string, *big.Float) {
-	amount0Float, _ := new(big.Float).SetString(swap.Amount0)
-	amount1Float, _ := new(big.Float).SetString(swap.Amount1)
-
-	var amountIn, amountOut *big.Float
-	var tokenIn, tokenOut string
+// 按规则、成交额下限和小额刷单过滤，返回是否需要通知
+func filterSwap(ctx context.Context, swap *Swap, now time.Time) (*SwapEvent, bool) {
+	_, span := startSpan(ctx, "filter")
+	defer span.End()
+	result := func(r string) { span.SetAttributes(attribute.String("filter.result", r)) }
 
-	if amount0Float.Sign() < 0 {
-		amountIn = amount1Float
-		amountOut = new(big.Float).Neg(amount0Float)
-		tokenIn = "WBTC"
-		tokenOut = "UNIBTC"
+	event, err := normalizeSwap(swap)
+	if err != nil {
+		slog.Error("Failed to normalize swap", "transactionHash", swap.TransactionHash, "error", err)
+		auditSuppressedSwap(swap, nil, auditReasonInvalid, err.Error())
+		result("invalid")
+		return nil, false
+	}
+	if ok, reason := evaluateRules(event); !ok {
+		slog.Info("Swap filtered by rules", "transactionHash", swap.TransactionHash, "reason", reason)
+		auditSuppressedSwap(swap, event, auditReasonRules, reason)
+		result("rules")
+		return nil, false
+	}
+	vol := event.Volume
+	volStr := vol.StringFixed(2)
+	span.SetAttributes(attribute.String("swap.volume_usd", volStr))
+	if vol.Cmp(decimal.NewFromInt(int64(getLimitPrice()))) > 0 {
+		slog.Info("Volume > limitPrice, sending notification", "volume", volStr)
 	} else {
-		amountIn = amount0Float
-		amountOut = new(big.Float).Neg(amount1Float)
-		tokenIn = "UNIBTC"
-		tokenOut = "WBTC"
-	}
-
-	wbtcPrice := big.NewFloat(100000.0)
-	if swap.BtcPrice != "" {
-		if parsedPrice, _, err := new(big.Float).Parse(swap.BtcPrice, 10); err == nil {
-			wbtcPrice = parsedPrice
-		} else {
-			slog.Error("Failed to parse btcPrice", "error", err)
-		}
+		slog.Info("Volume < limitPrice, skipping notification", "volume", volStr)
+		auditSuppressedSwap(swap, event, auditReasonBelowLimit, fmt.Sprintf("limitPrice %d", getLimitPrice()))
+		result("below_limit")
+		return nil, false
 	}
-
-	vol := new(big.Float).Mul(amountIn, wbtcPrice)
-	amountInStr := new(big.Float).Quo(amountIn, big.NewFloat(1e8)).Text('f', 5)
-	amountOutStr := new(big.Float).Quo(amountOut, big.NewFloat(1e8)).Text('f', 5)
-	volStr := new(big.Float).Quo(vol, big.NewFloat(1e8)).Text('f', 2)
-
-	timestamp, err := strconv.ParseInt(swap.BlockTimestamp, 10, 64)
-	if err != nil {
-		return "", vol
+	if spam.suppress(getSpamConfig(), event, now) {
+		slog.Info("Tiny swap merged into aggregate alert", "transactionHash", swap.TransactionHash, "sender", swap.Sender)
+		auditSuppressedSwap(swap, event, auditReasonSpam, "")
+		result("spam")
+		return nil, false
 	}
-
-	loc, _ := time.LoadLocation("Asia/Shanghai")
-	readableTime := time.Unix(timestamp, 0).In(loc).Format("2006-01-02 15:04:05")
-
-	return fmt.Sprintf("%s  %s %s -> %s %s Vol: $%s", readableTime,
-		amountInStr, tokenIn, amountOutStr, tokenOut, volStr), vol
+	result("pass")
+	return event, true
 }
 
-// GraphTask 主任务
-func GraphTask() error {
-	swaps, err := fetchSwaps()
+// FormatSwap 格式化 Swap 数据，同时返回以美元计的成交额。数量、价格或时间无法解析时返回错误
+func FormatSwap(swap *Swap) (string, decimal.Decimal, error) {
+	event, err := normalizeSwap(swap)
 	if err != nil {
-		slog.Error("Error fetching swaps", "error", err)
-		time.Sleep(3 * time.Second)
-		return err
-	}
-	if len(swaps) == 0 {
-		slog.Info("No new swaps found")
-		return nil
+		return "", decimal.Decimal{}, err
 	}
+	return formatSwapEvent(context.Background(), event), event.Volume, nil
+}
 
-	var newTxHashes []string
-	for _, swap := range swaps {
-		if !contains(getCurrentTxHashes(), swap.TransactionHash) {
-			err = sendNotification(swap)
-			if err != nil {
-				slog.Error("Error sending notification", "error", err)
-			} else {
-				newTxHashes = append(newTxHashes, swap.TransactionHash)
-			}
-		}
-	}
+// 按 swap 模板格式化归一化后的 Swap，不包含回执、TVL 等补充信息
+func formatSwapEvent(ctx context.Context, event *SwapEvent) string {
+	return renderMessage(templateSwap, channelBark, newSwapTemplateData(ctx, event))
+}
 
-	if len(swaps) > 0 {
-		setLastBlockNumber(swaps[0].BlockNumber)
-		setCurrentTxHashes(newTxHashes)
-		saveConfig()
-	}
-	return nil
+// GraphTask 主任务：按当前配置创建 Watcher 执行一轮
+func GraphTask(ctx context.Context) error {
+	return newWatcher().Run(ctx)
 }
 
 // 判断切片是否包含某个元素
@@ -0,0 +1,207 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"messag-push/api/pushpb"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// gRPC 接口配置，认证与 HTTPS 配置与管理接口共用
+type GRPCConfig struct {
+	Listen string `json:"listen"` // 监听地址，为空时不启动，例如 127.0.0.1:9090
+}
+
+func getGRPCConfig() GRPCConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.GRPC
+}
+
+var grpcServer *grpc.Server
+
+// 启动 gRPC 接口，提供 Swap 订阅与历史查询，定义见 api/pushpb/push.proto
+func startGRPCServer() {
+	cfg := getGRPCConfig()
+	if cfg.Listen == "" {
+		return
+	}
+	tlsConfig, err := loadServerTLS()
+	if err != nil {
+		slog.Error("Failed to start gRPC server", "error", err)
+		return
+	}
+	listener, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		slog.Error("Failed to start gRPC server", "addr", cfg.Listen, "error", err)
+		return
+	}
+	var options []grpc.ServerOption
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer = grpc.NewServer(append(options,
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := checkGRPCToken(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := checkGRPCToken(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)...)
+	pushpb.RegisterSwapFeedServer(grpcServer, &swapFeedServer{})
+	go func() {
+		slog.Info("gRPC server listening", "addr", cfg.Listen)
+		if err := grpcServer.Serve(listener); err != nil {
+			slog.Error("gRPC server stopped", "error", err)
+		}
+	}()
+}
+
+// 等待正在处理的请求结束，超时后强制关闭
+func stopGRPCServer(ctx context.Context) {
+	if grpcServer == nil {
+		return
+	}
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+}
+
+// 配置了认证时校验 authorization 元数据，所有方法都只需要只读范围
+func checkGRPCToken(ctx context.Context) error {
+	cfg := getAdminConfig()
+	if !cfg.authEnabled() {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if scope, ok := credentialScope(cfg, value); ok {
+			if !scopeAllows(scope, scopeRead) {
+				return status.Error(codes.PermissionDenied, "read scope required")
+			}
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "unauthorized")
+}
+
+type swapFeedServer struct {
+	pushpb.UnimplementedSwapFeedServer
+}
+
+func (s *swapFeedServer) Subscribe(req *pushpb.SubscribeRequest, stream grpc.ServerStreamingServer[pushpb.SwapEvent]) error {
+	events, unsubscribe := swapFeed.subscribe()
+	defer unsubscribe()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if (req.Direction != "" && !strings.EqualFold(req.Direction, event.Direction)) || event.VolumeUSD < req.MinVolumeUsd {
+				continue
+			}
+			if err := stream.Send(swapEventProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *swapFeedServer) QuerySwaps(ctx context.Context, req *pushpb.QueryRequest) (*pushpb.QuerySwapsResponse, error) {
+	since, until, limit := queryRange(req)
+	records, err := getStore().QuerySwaps(ctx, since, until)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &pushpb.QuerySwapsResponse{}
+	for _, record := range newestFirst(records, limit, func(r swapRecord) time.Time { return r.Time }) {
+		resp.Swaps = append(resp.Swaps, swapEventProto(swapFeedEvent{swapRecord: record}))
+	}
+	return resp, nil
+}
+
+func (s *swapFeedServer) QueryNotifications(ctx context.Context, req *pushpb.QueryRequest) (*pushpb.QueryNotificationsResponse, error) {
+	since, until, limit := queryRange(req)
+	records, err := getStore().QueryNotifications(ctx, since, until)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &pushpb.QueryNotificationsResponse{}
+	for _, record := range newestFirst(records, limit, func(r NotificationRecord) time.Time { return r.Time }) {
+		targets := make([]string, len(record.Targets))
+		for i, target := range record.Targets {
+			targets[i] = maskTarget(target)
+		}
+		resp.Notifications = append(resp.Notifications, &pushpb.Notification{
+			Time:    timestamppb.New(record.Time),
+			TxHash:  record.TxHash,
+			Targets: targets,
+			Message: record.Message,
+		})
+	}
+	return resp, nil
+}
+
+// 查询区间默认为最近 24 小时，条数默认 50，最多 1000
+func queryRange(req *pushpb.QueryRequest) (time.Time, time.Time, int) {
+	until := time.Now().Add(time.Second)
+	if req.Until != nil {
+		until = req.Until.AsTime()
+	}
+	since := until.Add(-24 * time.Hour)
+	if req.Since != nil {
+		since = req.Since.AsTime()
+	}
+	limit := 50
+	if req.Limit > 0 {
+		limit = min(int(req.Limit), 1000)
+	}
+	return since, until, limit
+}
+
+func swapEventProto(event swapFeedEvent) *pushpb.SwapEvent {
+	return &pushpb.SwapEvent{
+		TxHash:    event.TxHash,
+		Block:     event.Block,
+		Time:      timestamppb.New(event.Time),
+		Sender:    event.Sender,
+		Recipient: event.Recipient,
+		TokenIn:   event.TokenIn,
+		TokenOut:  event.TokenOut,
+		Direction: event.Direction,
+		AmountIn:  event.AmountIn,
+		AmountOut: event.AmountOut,
+		VolumeUsd: event.VolumeUSD,
+		Price:     event.Price,
+		Tick:      event.Tick,
+		ExecPrice: event.ExecPrice,
+		ImpactBps: event.ImpactBps,
+		Chain:     event.Chain,
+		Pool:      event.Pool,
+	}
+}
@@ -0,0 +1,96 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// 健康检查配置
+type HealthConfig struct {
+	Listen            string `json:"listen"`            // 单独的监听地址，例如 0.0.0.0:8081，为空时挂在管理接口上
+	MaxPollAgeSeconds int    `json:"maxPollAgeSeconds"` // 最近一次成功查询超过该时间时视为未就绪
+}
+
+func getHealthConfig() HealthConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Health
+	if cfg.MaxPollAgeSeconds <= 0 {
+		cfg.MaxPollAgeSeconds = 120
+	}
+	return cfg
+}
+
+// 在单独的地址上提供健康检查，便于只对外暴露健康检查而不暴露管理接口
+func startHealthServer() {
+	cfg := getHealthConfig()
+	if cfg.Listen == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	registerHealthHandlers(mux)
+	serveHTTP("health", cfg.Listen, mux, nil)
+}
+
+// 注册健康检查接口
+//
+//	GET /healthz  进程存活
+//	GET /readyz   存储可访问且最近一次成功查询未超过 maxPollAgeSeconds，备实例只检查存储
+func registerHealthHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, true, map[string]string{"process": "ok"})
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		ready, checks := readiness(r.Context(), time.Now())
+		writeHealth(w, ready, checks)
+	})
+}
+
+func readiness(ctx context.Context, now time.Time) (bool, map[string]string) {
+	ready := true
+	checks := make(map[string]string)
+
+	if _, _, err := getStore().LoadCursor(ctx, swapCursorName()); err != nil {
+		ready = false
+		checks["store"] = "error: " + err.Error()
+	} else {
+		checks["store"] = "ok"
+	}
+
+	if !leading() {
+		checks["poll"] = "standby"
+		return ready, checks
+	}
+	lastQuery, queried := watchdog.lastSuccess()
+	maxAge := time.Duration(getHealthConfig().MaxPollAgeSeconds) * time.Second
+	switch {
+	case !queried:
+		ready = false
+		checks["poll"] = "no successful query yet"
+	case now.Sub(lastQuery) > maxAge:
+		ready = false
+		checks["poll"] = fmt.Sprintf("last success %s ago", now.Sub(lastQuery).Round(time.Second))
+	default:
+		checks["poll"] = "ok"
+	}
+	// 子图索引错误时数据可能停止更新，只作提示，不影响就绪状态
+	for source, status := range listSubgraphStatuses() {
+		if status.IndexingErrors {
+			checks["subgraph."+source] = fmt.Sprintf("indexing errors at block %d", status.Block)
+		}
+	}
+	return ready, checks
+}
+
+func writeHealth(w http.ResponseWriter, ok bool, checks map[string]string) {
+	status, code := "ok", http.StatusOK
+	if !ok {
+		status, code = "fail", http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "checks": checks})
+}
@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// 本地历史记录配置
+type HistoryConfig struct {
+	Path          string `json:"path"`          // 历史记录文件（JSON Lines），为空时只保存在内存中，使用 SQLite 存储时忽略
+	RetentionDays int    `json:"retentionDays"` // 保留天数
+}
+
+// 历史记录中的单笔 Swap
+type swapRecord struct {
+	TxHash    string    `json:"txHash"`
+	Block     int64     `json:"block"`
+	Time      time.Time `json:"time"`
+	Sender    string    `json:"sender"`
+	Recipient string    `json:"recipient"`
+	TokenIn   string    `json:"tokenIn"`
+	TokenOut  string    `json:"tokenOut"`
+	Direction string    `json:"direction"`
+	AmountIn  float64   `json:"amountIn"`
+	AmountOut float64   `json:"amountOut"`
+	VolumeUSD float64   `json:"volumeUSD"`
+	Price     float64   `json:"price"` // 池子价格（token1 / token0）
+}
+
+func (r swapRecord) timestamp() time.Time { return r.Time }
+
+// Swap 历史记录
+var history = newTimeSeries[swapRecord](func() (string, time.Duration) {
+	cfg := getHistoryConfig()
+	return cfg.Path, time.Duration(cfg.RetentionDays) * 24 * time.Hour
+})
+
+func getHistoryConfig() HistoryConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.History
+	if cfg.RetentionDays <= 0 {
+		cfg.RetentionDays = 8
+	}
+	return cfg
+}
+
+// 观察每笔 Swap 并写入历史记录
+func recordSwap(ctx context.Context, event *SwapEvent) {
+	if err := getStore().AppendSwap(ctx, newSwapRecord(event)); err != nil {
+		slog.Error("Failed to record swap", "transactionHash", event.Swap.TransactionHash, "error", err)
+	}
+}
+
+func newSwapRecord(event *SwapEvent) swapRecord {
+	env := newConditionEnv(event)
+	return swapRecord{
+		TxHash:    env.TxHash,
+		Block:     int64(env.Block),
+		Time:      event.Time,
+		Sender:    env.Sender,
+		Recipient: env.Recipient,
+		TokenIn:   env.TokenIn,
+		TokenOut:  env.TokenOut,
+		Direction: env.Direction,
+		AmountIn:  env.AmountIn,
+		AmountOut: env.AmountOut,
+		VolumeUSD: env.VolUSD,
+		Price:     env.PoolPrice,
+	}
+}
@@ -0,0 +1,121 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Home Assistant 输出配置，通过 REST API 更新传感器，可在自动化中使用
+//
+//	sensor.<entityPrefix>_price      池子价格（token1 / token0）
+//	sensor.<entityPrefix>_last_swap  最近一笔 Swap 的成交额（美元），属性中包含完整的 Swap
+//
+// 开启 fireEvents 时每笔 Swap 还会触发 message_push_swap 事件。更新通过 homeassistant 推送队列异步进行，
+// 每笔最长 10 秒，不影响通知，队列满时丢弃
+type HomeAssistantConfig struct {
+	URL          string `json:"url"`          // 例如 http://homeassistant.local:8123，为空时不启用
+	Token        string `json:"token"`        // 长期访问令牌，支持密钥引用
+	EntityPrefix string `json:"entityPrefix"` // 默认 message_push
+	FireEvents   bool   `json:"fireEvents"`
+}
+
+func getHomeAssistantConfig() HomeAssistantConfig {
+	configMutex.RLock()
+	cfg := configData.HomeAssistant
+	configMutex.RUnlock()
+	cfg.Token = secretValue(cfg.Token)
+	if cfg.EntityPrefix == "" {
+		cfg.EntityPrefix = "message_push"
+	}
+	return cfg
+}
+
+// Home Assistant 触发的事件类型
+const homeAssistantEventType = "message_push_swap"
+
+func publishHomeAssistant(ctx context.Context, event *SwapEvent) {
+	cfg := getHomeAssistantConfig()
+	if cfg.URL == "" {
+		return
+	}
+	feed := newSwapFeedEvent(event)
+	deliverOutput(ctx, channelHomeAssistant, event.Swap.TransactionHash, func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		err := updateHomeAssistant(ctx, cfg, feed)
+		recordChannelResult(channelHomeAssistant, urlHost(cfg.URL), time.Now(), err)
+		if err != nil {
+			slog.Error("Failed to update home assistant", "transactionHash", event.Swap.TransactionHash, "error", err)
+		}
+	})
+}
+
+func updateHomeAssistant(ctx context.Context, cfg HomeAssistantConfig, feed swapFeedEvent) error {
+	var attributes map[string]interface{}
+	data, err := json.Marshal(feed)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return err
+	}
+
+	token0, token1 := getPoolTokenMeta()
+	lang := defaultLanguage()
+	if feed.Price > 0 {
+		err := homeAssistantRequest(ctx, cfg, "/api/states/sensor."+cfg.EntityPrefix+"_price", map[string]interface{}{
+			"state": strconv.FormatFloat(feed.Price, 'f', 6, 64),
+			"attributes": map[string]interface{}{
+				"friendly_name":       translate(lang, "ha.price", token0.Symbol, token1.Symbol),
+				"unit_of_measurement": token1.Symbol,
+				"state_class":         "measurement",
+				"updated_by":          feed.TxHash,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	lastSwap := map[string]interface{}{"friendly_name": translate(lang, "ha.lastSwap"), "unit_of_measurement": "USD", "device_class": "monetary"}
+	for key, value := range attributes {
+		lastSwap[key] = value
+	}
+	err = homeAssistantRequest(ctx, cfg, "/api/states/sensor."+cfg.EntityPrefix+"_last_swap", map[string]interface{}{
+		"state":      strconv.FormatFloat(feed.VolumeUSD, 'f', 2, 64),
+		"attributes": lastSwap,
+	})
+	if err != nil || !cfg.FireEvents {
+		return err
+	}
+	return homeAssistantRequest(ctx, cfg, "/api/events/"+homeAssistantEventType, attributes)
+}
+
+func homeAssistantRequest(ctx context.Context, cfg HomeAssistantConfig, path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(cfg.URL, "/")+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("home assistant returned %s for %s", resp.Status, path)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package logic
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// 访问范围
+const (
+	scopeAdmin   = "admin"   // 全部接口
+	scopeRead    = "read"    // 只读：查询接口、管理页面、订阅流、RSS 和 gRPC
+	scopeWebhook = "webhook" // 只能推送外部告警
+)
+
+// 带范围的访问令牌，以 Authorization: Bearer <token> 传入
+type AccessToken struct {
+	Name  string `json:"name"`
+	Token string `json:"token"` // 支持密钥引用
+	Scope string `json:"scope"` // admin / read / webhook，默认 read
+}
+
+// Basic 认证用户，浏览器访问管理页面时会弹出登录框
+type BasicAuthUser struct {
+	Username string `json:"username"`
+	Password string `json:"password"` // 支持密钥引用
+	Scope    string `json:"scope"`    // admin / read / webhook，默认 read
+}
+
+// HTTPS 配置，证书文件与 ACME 二选一，修改后需要重启
+type TLSConfig struct {
+	CertFile     string   `json:"certFile"`
+	KeyFile      string   `json:"keyFile"`
+	ACMEDomains  []string `json:"acmeDomains"`  // 通过 Let's Encrypt 自动申请证书的域名，使用 TLS-ALPN 验证，需要对外监听 443 端口
+	ACMEEmail    string   `json:"acmeEmail"`    // 证书到期提醒邮箱
+	ACMECacheDir string   `json:"acmeCacheDir"` // 证书缓存目录，默认 acme-cache
+}
+
+func (c TLSConfig) enabled() bool {
+	return c.CertFile != "" || len(c.ACMEDomains) > 0
+}
+
+// 是否配置了任何认证方式，都未配置时不校验
+func (c AdminConfig) authEnabled() bool {
+	return c.Token != "" || len(c.Tokens) > 0 || len(c.BasicAuth) > 0
+}
+
+func normalizeScope(scope string) string {
+	if scope == "" {
+		return scopeRead
+	}
+	return scope
+}
+
+// admin 可以访问所有接口，其他范围只能访问同名范围的接口
+func scopeAllows(granted, required string) bool {
+	return granted == scopeAdmin || granted == required
+}
+
+// 校验 Authorization 头的值，返回对应的范围
+func credentialScope(cfg AdminConfig, authorization string) (string, bool) {
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok {
+		return tokenScope(cfg, token)
+	}
+	if encoded, ok := strings.CutPrefix(authorization, "Basic "); ok {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", false
+		}
+		username, password, _ := strings.Cut(string(decoded), ":")
+		return basicAuthScope(cfg, username, password)
+	}
+	return "", false
+}
+
+func tokenScope(cfg AdminConfig, token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	if cfg.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Token)) == 1 {
+		return scopeAdmin, true
+	}
+	for _, t := range cfg.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secretValue(t.Token))) == 1 {
+			return normalizeScope(t.Scope), true
+		}
+	}
+	return "", false
+}
+
+func basicAuthScope(cfg AdminConfig, username, password string) (string, bool) {
+	for _, user := range cfg.BasicAuth {
+		userMatch := subtle.ConstantTimeCompare([]byte(username), []byte(user.Username))
+		passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(secretValue(user.Password)))
+		if userMatch&passwordMatch == 1 && user.Password != "" {
+			return normalizeScope(user.Scope), true
+		}
+	}
+	return "", false
+}
+
+// 校验请求的访问范围，allowQuery 时也接受 ?token=，用于无法设置请求头的 RSS 阅读器和 EventSource
+func requireScope(required string, allowQuery bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := getAdminConfig()
+		if !cfg.authEnabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		scope, ok := credentialScope(cfg, r.Header.Get("Authorization"))
+		if !ok && allowQuery {
+			scope, ok = tokenScope(cfg, r.URL.Query().Get("token"))
+		}
+		if !ok {
+			if len(cfg.BasicAuth) > 0 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="message-push", charset="UTF-8"`)
+			}
+			writeAdminError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		if !scopeAllows(scope, required) {
+			writeAdminError(w, http.StatusForbidden, fmt.Errorf("%s scope required", required))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// 管理接口按请求方法区分范围：GET 只需要只读范围，其他方法需要 admin
+func requireAuth(next http.Handler) http.Handler {
+	read := requireScope(scopeRead, false, next)
+	admin := requireScope(scopeAdmin, false, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			read.ServeHTTP(w, r)
+			return
+		}
+		admin.ServeHTTP(w, r)
+	})
+}
+
+// 各服务共用同一份证书，ACME 只创建一个 Manager，避免重复申请
+var (
+	serverTLSOnce   sync.Once
+	serverTLSConfig *tls.Config
+	serverTLSError  error
+)
+
+// 按配置加载 HTTPS 证书，未配置时返回 nil
+func loadServerTLS() (*tls.Config, error) {
+	serverTLSOnce.Do(func() {
+		cfg := getAdminConfig().TLS
+		switch {
+		case len(cfg.ACMEDomains) > 0:
+			if cfg.ACMECacheDir == "" {
+				cfg.ACMECacheDir = "acme-cache"
+			}
+			manager := &autocert.Manager{
+				Prompt:     autocert.AcceptTOS,
+				HostPolicy: autocert.HostWhitelist(cfg.ACMEDomains...),
+				Cache:      autocert.DirCache(cfg.ACMECacheDir),
+				Email:      cfg.ACMEEmail,
+			}
+			serverTLSConfig = manager.TLSConfig()
+		case cfg.CertFile != "":
+			cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+			if err != nil {
+				serverTLSError = fmt.Errorf("load tls certificate: %w", err)
+				return
+			}
+			serverTLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		}
+	})
+	return serverTLSConfig, serverTLSError
+}
@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// HTTP 客户端配置，时间单位均为秒
+type HTTPConfig struct {
+	ConnectTimeout     int    `json:"connectTimeout"`     // 建立连接超时
+	ReadTimeout        int    `json:"readTimeout"`        // 单次请求总超时
+	Proxy              string `json:"proxy"`              // 代理地址，为空时读取环境变量
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"` // 跳过证书校验
+	CAFile             string `json:"caFile"`             // 自定义 CA 证书文件
+	MaxIdleConns       int    `json:"maxIdleConns"`       // 每个主机最大空闲连接数
+	IdleConnTimeout    int    `json:"idleConnTimeout"`    // 空闲连接保持时间
+}
+
+var (
+	httpClient      *http.Client // 所有出站请求共用的客户端
+	httpClientMutex sync.Mutex
+)
+
+// 获取共享的 HTTP 客户端，首次调用时按配置创建
+func getHTTPClient() *http.Client {
+	httpClientMutex.Lock()
+	defer httpClientMutex.Unlock()
+	if httpClient == nil {
+		httpClient = newHTTPClient(getHTTPConfig())
+		if chaos := getChaosConfig(); chaos.Enabled {
+			slog.Warn("Fault injection enabled, outbound requests will fail randomly",
+				"graphTimeout", chaos.GraphTimeout, "graphMalformed", chaos.GraphMalformed,
+				"graphRateLimit", chaos.GraphRateLimit, "channelFailure", chaos.ChannelFailure)
+			httpClient.Transport = newChaosTransport(httpClient.Transport, chaos)
+		}
+	}
+	return httpClient
+}
+
+// 配置变更后丢弃旧客户端，下次使用时重新创建
+func resetHTTPClient() {
+	httpClientMutex.Lock()
+	defer httpClientMutex.Unlock()
+	if httpClient != nil {
+		httpClient.CloseIdleConnections()
+	}
+	httpClient = nil
+}
+
+func getHTTPConfig() HTTPConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.HTTP
+}
+
+// 根据配置创建 HTTP 客户端
+func newHTTPClient(cfg HTTPConfig) *http.Client {
+	connectTimeout := secondsOrDefault(cfg.ConnectTimeout, 5)
+	readTimeout := secondsOrDefault(cfg.ReadTimeout, 15)
+	idleConnTimeout := secondsOrDefault(cfg.IdleConnTimeout, 90)
+	maxIdleConns := cfg.MaxIdleConns
+	if maxIdleConns <= 0 {
+		maxIdleConns = 10
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   connectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: connectTimeout,
+		MaxIdleConns:        maxIdleConns * 4,
+		MaxIdleConnsPerHost: maxIdleConns,
+		IdleConnTimeout:     idleConnTimeout,
+		ForceAttemptHTTP2:   true,
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify},
+	}
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			slog.Error("Invalid proxy address, using environment proxy", "proxy", cfg.Proxy, "error", err)
+		} else {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			slog.Error("Failed to read CA file", "file", cfg.CAFile, "error", err)
+		} else {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			pool.AppendCertsFromPEM(pem)
+			transport.TLSClientConfig.RootCAs = pool
+		}
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   readTimeout,
+	}
+}
+
+// 将秒数转换为 time.Duration，未配置时使用默认值
+func secondsOrDefault(seconds, def int) time.Duration {
+	if seconds <= 0 {
+		seconds = def
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,430 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// 通知文本的语言和时间配置。订阅者可以单独设置语言、时区和时间格式，未设置时使用这里的默认值
+type I18nConfig struct {
+	Language   string `json:"language"`   // 默认语言，默认 zh-CN
+	Dir        string `json:"dir"`        // 语言包目录，文件名为语言加 .json，例如 en-US.json，覆盖或补充内置文本
+	Timezone   string `json:"timezone"`   // 默认时区，默认 Asia/Shanghai
+	TimeFormat string `json:"timeFormat"` // 默认时间格式：24h、12h、relative 或 Go 时间布局，默认 24h
+}
+
+func getI18nConfig() I18nConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.I18n
+}
+
+// 内置语言
+const (
+	languageZhCN = "zh-CN"
+	languageEnUS = "en-US"
+)
+
+// 常见的语言写法
+var languageAliases = map[string]string{
+	"zh":      languageZhCN,
+	"zh-cn":   languageZhCN,
+	"zh-hans": languageZhCN,
+	"cn":      languageZhCN,
+	"en":      languageEnUS,
+	"en-us":   languageEnUS,
+}
+
+// 内置语言包，文本为 fmt 格式，译文可以用 %[n]s、%.2[n]f 调整参数顺序
+var builtinLocales = map[string]map[string]string{
+	languageZhCN: {
+		"list.separator":    "，",
+		"report.separator":  " | ",
+		"bark.title.swap":   "交易提醒",
+		"bark.title.alert":  "系统告警",
+		"bark.title.digest": "成交汇总",
+
+		"swap.volume":    "成交额",
+		"swap.price":     "均价",
+		"swap.impact":    "冲击",
+		"swap.by":        "地址",
+		"swap.mev":       "MEV",
+		"swap.viewTx":    "查看交易",
+		"swap.sender":    "发送方",
+		"swap.recipient": "接收方",
+		"swap.tick":      "Tick",
+		"swap.liquidity": "流动性",
+		"swap.block":     "区块",
+		"swap.tx":        "交易",
+
+		"receipt.gas":      " Gas %d%s%s",
+		"receipt.gasPrice": " @ %sgwei",
+		"receipt.via":      " 路由 %s",
+		"flow.summary":     " 资金流向 %s",
+		"flow.window":      "%s %s %.0fpct",
+		"flow.buy":         "买入",
+		"flow.sell":        "卖出",
+		"actor.bot":        " 操作者 机器人 %s",
+		"actor.user":       " 操作者 经 %s 的用户",
+		"tvl.summary":      " TVL %.2f BTC%s",
+		"tvl.usd":          " ($%.0f)",
+
+		"alert.taskRecovered":     "任务 %s 已恢复正常",
+		"alert.taskFailing":       "任务 %s 已连续失败 %d 次 详情请查看日志",
+		"alert.invalidConditions": "过滤条件无法编译 已暂停所有 Swap 通知 详情请查看日志",
+		"alert.arbitrage":         "链上与CEX价差 %.1fbps 池子成交价 %.5f CEX参考价 %.5f",
+		"alert.depeg":             "UNIBTC脱锚告警 当前比价 %.5f 偏离 %.1fbps",
+		"alert.depegMove":         "UNIBTC比价异动 %s内变动 %.1fbps (%.5f -> %.5f)",
+		"alert.tvlChange":         "池子TVL %s内%s %.1fpct %.4f BTC -> %.4f BTC%s",
+		"alert.tvlUp":             "增长",
+		"alert.tvlDown":           "下降",
+		"alert.maCross":           "%s均线%s %s%d %.5f %s%d %.5f 收盘价 %.5f",
+		"alert.goldenCross":       "金叉",
+		"alert.deathCross":        "死叉",
+		"alert.band":              "%s价格%s区间 %s [%.5f, %.5f] 当前 %.5f",
+		"alert.bandEnter":         "进入",
+		"alert.bandLeave":         "离开",
+		"alert.spam":              "地址 %s %d秒内 %d 笔小额交易 另有 %d 笔已合并 合计 $%.2f",
+		"alert.volumeSpike":       "成交量异动 最近%d分钟成交 $%.0f 基线均值 $%.0f 阈值 $%.0f",
+		"alert.lpRange":           "LP仓位 %s 价格%s区间 当前tick %d 区间 [%d, %d]",
+		"alert.lpEnter":           "回到",
+		"alert.lpLeave":           "离开",
+		"alert.lpReport":          "LP仓位 %s %s tick %d [%d, %d]%s",
+		"alert.lpInRange":         "区间内",
+		"alert.lpOutOfRange":      "区间外",
+		"alert.lpValue":           " 价值 %.5f + %.5f 未领取手续费 %.6f + %.6f",
+		"alert.watchdogStale":     "服务可能异常 已有 %d 分钟%s 最近一次 %s",
+		"alert.watchdogOK":        "服务已恢复 %s的情况已解除",
+		"alert.noQuery":           "没有成功的 Graph 查询",
+		"alert.noSwap":            "没有获取到新的 Swap",
+		"alert.selfTest":          "自检测试消息",
+		"alert.collapsed":         "%s 推送繁忙 合并了 %d 条通知",
+		"alert.collapsedMore":     "另有 %d 条",
+
+		"time.justNow":    "刚刚",
+		"time.minutesAgo": "%d分钟前",
+		"time.hoursAgo":   "%d小时前",
+		"time.daysAgo":    "%d天前",
+
+		"digest.daily":      "%s 日报 成交 %d 笔 总额 %s 最大 %s (%s -> %s) %s 收盘价 %s",
+		"digest.dailyEmpty": "%s 日报 过去24小时无成交",
+		"digest.netBuy":     "净买入 %s",
+		"digest.netSell":    "净卖出 %s",
+		"digest.flat":       "持平",
+		"weekly.summary":    "周报 %s ~ %s 成交 %d 笔 总额 %s",
+		"weekly.empty":      "周报 %s ~ %s 无成交",
+		"weekly.daily":      "每日 %s",
+		"weekly.report":     "%s",
+		"weekly.ratio":      "买卖比 %.2f",
+		"weekly.allBuy":     "买卖比 全部买入",
+		"weekly.priceRange": "价格区间 %s ~ %s",
+		"weekly.senders":    "发送方 %s",
+		"weekly.recipients": "接收方 %s",
+
+		"telegram.help": "/status 服务状态\n" +
+			"/last [n] 最近 n 笔 Swap，默认 5\n" +
+			"/price 当前价格与 24 小时变化\n" +
+			"/threshold [usd|off] 查看或设置本会话的成交额阈值\n" +
+			"/mute [2h|off] 本会话静音，默认 1 小时\n" +
+			"/unmute 取消静音",
+		"telegram.unknownCommand":   "未知命令",
+		"telegram.usage":            "用法: %s",
+		"telegram.saveFailed":       "保存失败: %s",
+		"telegram.muted":            "已静音至 %s",
+		"telegram.unmuted":          "已取消静音",
+		"telegram.leader":           "主实例",
+		"telegram.standby":          "备实例",
+		"telegram.uptime":           "%s 已运行 %s",
+		"telegram.cursor":           "处理进度: %s",
+		"telegram.lastQuery":        "最近查询: %s 前",
+		"telegram.paused":           "通知已暂停至 %s",
+		"telegram.tasksFailing":     "失败的任务: %s",
+		"telegram.chatThreshold":    "本会话阈值: $%.0f",
+		"telegram.chatMuted":        "本会话静音至 %s",
+		"telegram.noSwaps":          "最近 7 天没有 Swap",
+		"telegram.noPrices":         "最近 24 小时没有价格数据",
+		"telegram.price":            "价格 %s（%s）",
+		"telegram.priceChange":      "24 小时变化 %+.3f%%",
+		"telegram.thresholdDefault": "本会话未设置阈值，使用全局阈值 $%d",
+		"telegram.threshold":        "本会话阈值 $%.0f",
+		"telegram.thresholdCleared": "已取消本会话阈值",
+		"telegram.thresholdSet":     "本会话阈值已设置为 $%.0f",
+
+		"ha.price":    "%s/%s 价格",
+		"ha.lastSwap": "最近一笔 Swap",
+	},
+	languageEnUS: {
+		"list.separator":    ", ",
+		"report.separator":  " | ",
+		"bark.title.swap":   "Swap alert",
+		"bark.title.alert":  "System alert",
+		"bark.title.digest": "Swap digest",
+
+		"swap.volume":    "Vol",
+		"swap.price":     "Price",
+		"swap.impact":    "Impact",
+		"swap.by":        "By",
+		"swap.mev":       "MEV",
+		"swap.viewTx":    "View transaction",
+		"swap.sender":    "Sender",
+		"swap.recipient": "Recipient",
+		"swap.tick":      "Tick",
+		"swap.liquidity": "Liquidity",
+		"swap.block":     "Block",
+		"swap.tx":        "Tx",
+
+		"receipt.gas":      " Gas: %d%s%s",
+		"receipt.gasPrice": " @ %sgwei",
+		"receipt.via":      " Via: %s",
+		"flow.summary":     " Flow: %s",
+		"flow.window":      "%[1]s %.0[3]fpct %[2]s",
+		"flow.buy":         "buy",
+		"flow.sell":        "sell",
+		"actor.bot":        " Actor: bot %s",
+		"actor.user":       " Actor: user via %s",
+		"tvl.summary":      " TVL: %.2f BTC%s",
+		"tvl.usd":          " ($%.0f)",
+
+		"alert.taskRecovered":     "Task %s recovered",
+		"alert.taskFailing":       "Task %s failed %d times in a row, see logs for details",
+		"alert.invalidConditions": "Filter conditions failed to compile, all swap notifications are blocked, see logs for details",
+		"alert.arbitrage":         "On-chain vs CEX spread %.1fbps pool price %.5f CEX price %.5f",
+		"alert.depeg":             "UNIBTC depeg alert ratio %.5f deviation %.1fbps",
+		"alert.depegMove":         "UNIBTC ratio moved %.1[2]fbps in %[1]s (%.5[3]f -> %.5[4]f)",
+		"alert.tvlChange":         "Pool TVL %[2]s %.1[3]fpct in %[1]s %.4[4]f BTC -> %.4[5]f BTC%[6]s",
+		"alert.tvlUp":             "up",
+		"alert.tvlDown":           "down",
+		"alert.maCross":           "%s MA %s %s%d %.5f %s%d %.5f close %.5f",
+		"alert.goldenCross":       "golden cross",
+		"alert.deathCross":        "death cross",
+		"alert.band":              "%s price %s band %s [%.5f, %.5f] current %.5f",
+		"alert.bandEnter":         "entered",
+		"alert.bandLeave":         "left",
+		"alert.spam":              "Address %[1]s made %[3]d tiny swaps in %[2]ds, %[4]d more merged, total $%.2[5]f",
+		"alert.volumeSpike":       "Volume spike $%.0[2]f in the last %[1]d minutes, baseline mean $%.0[3]f threshold $%.0[4]f",
+		"alert.lpRange":           "LP position %s price %s range, current tick %d range [%d, %d]",
+		"alert.lpEnter":           "back in",
+		"alert.lpLeave":           "out of",
+		"alert.lpReport":          "LP position %s %s tick %d [%d, %d]%s",
+		"alert.lpInRange":         "in range",
+		"alert.lpOutOfRange":      "out of range",
+		"alert.lpValue":           " value %.5f + %.5f unclaimed fees %.6f + %.6f",
+		"alert.watchdogStale":     "Service may be down: %[2]s for %[1]d minutes, last at %[3]s",
+		"alert.watchdogOK":        "Service recovered: %s resolved",
+		"alert.noQuery":           "no successful Graph query",
+		"alert.noSwap":            "no new swaps",
+		"alert.selfTest":          "Self-test message",
+		"alert.collapsed":         "%s is slow, %d notifications collapsed",
+		"alert.collapsedMore":     "and %d more",
+
+		"time.justNow":    "just now",
+		"time.minutesAgo": "%dm ago",
+		"time.hoursAgo":   "%dh ago",
+		"time.daysAgo":    "%dd ago",
+
+		"digest.daily":      "%s daily: %d swaps total %s largest %s (%s -> %s) %s close %s",
+		"digest.dailyEmpty": "%s daily: no swaps in the last 24 hours",
+		"digest.netBuy":     "net buy %s",
+		"digest.netSell":    "net sell %s",
+		"digest.flat":       "flat",
+		"weekly.summary":    "Weekly %s ~ %s: %d swaps total %s",
+		"weekly.empty":      "Weekly %s ~ %s: no swaps",
+		"weekly.daily":      "Daily %s",
+		"weekly.report":     "%s",
+		"weekly.ratio":      "Buy/sell %.2f",
+		"weekly.allBuy":     "Buy/sell all buys",
+		"weekly.priceRange": "Price range %s ~ %s",
+		"weekly.senders":    "Senders %s",
+		"weekly.recipients": "Recipients %s",
+
+		"telegram.help": "/status service status\n" +
+			"/last [n] latest n swaps, default 5\n" +
+			"/price current price and 24h change\n" +
+			"/threshold [usd|off] show or set this chat's volume threshold\n" +
+			"/mute [2h|off] mute this chat, default 1 hour\n" +
+			"/unmute unmute this chat",
+		"telegram.unknownCommand":   "Unknown command",
+		"telegram.usage":            "Usage: %s",
+		"telegram.saveFailed":       "Failed to save: %s",
+		"telegram.muted":            "Muted until %s",
+		"telegram.unmuted":          "Unmuted",
+		"telegram.leader":           "Primary",
+		"telegram.standby":          "Standby",
+		"telegram.uptime":           "%s, up %s",
+		"telegram.cursor":           "Cursor: %s",
+		"telegram.lastQuery":        "Last query: %s ago",
+		"telegram.paused":           "Notifications paused until %s",
+		"telegram.tasksFailing":     "Failing tasks: %s",
+		"telegram.chatThreshold":    "Chat threshold: $%.0f",
+		"telegram.chatMuted":        "Chat muted until %s",
+		"telegram.noSwaps":          "No swaps in the last 7 days",
+		"telegram.noPrices":         "No price data in the last 24 hours",
+		"telegram.price":            "Price %s (%s)",
+		"telegram.priceChange":      "24h change %+.3f%%",
+		"telegram.thresholdDefault": "No threshold set for this chat, using the global threshold $%d",
+		"telegram.threshold":        "Chat threshold $%.0f",
+		"telegram.thresholdCleared": "Chat threshold cleared",
+		"telegram.thresholdSet":     "Chat threshold set to $%.0f",
+
+		"ha.price":    "%s/%s price",
+		"ha.lastSwap": "Last swap",
+	},
+}
+
+var (
+	loadedLocales      map[string]map[string]string
+	loadedLocalesMutex sync.Mutex
+)
+
+// 配置变更后重新加载语言包
+func resetLocales() {
+	loadedLocalesMutex.Lock()
+	defer loadedLocalesMutex.Unlock()
+	loadedLocales = nil
+}
+
+// 合并内置语言包与语言包目录中的文件
+func loadLocales() map[string]map[string]string {
+	loadedLocalesMutex.Lock()
+	defer loadedLocalesMutex.Unlock()
+	if loadedLocales != nil {
+		return loadedLocales
+	}
+	locales := make(map[string]map[string]string, len(builtinLocales))
+	for lang, bundle := range builtinLocales {
+		merged := make(map[string]string, len(bundle))
+		for key, text := range bundle {
+			merged[key] = text
+		}
+		locales[lang] = merged
+	}
+	if dir := getI18nConfig().Dir; dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			slog.Error("Failed to list locale bundles", "dir", dir, "error", err)
+		}
+		for _, file := range files {
+			var bundle map[string]string
+			data, err := os.ReadFile(file)
+			if err == nil {
+				err = json.Unmarshal(data, &bundle)
+			}
+			if err != nil {
+				slog.Error("Failed to load locale bundle", "path", file, "error", err)
+				continue
+			}
+			lang := strings.TrimSuffix(filepath.Base(file), ".json")
+			if alias, ok := languageAliases[strings.ToLower(lang)]; ok {
+				lang = alias
+			}
+			if locales[lang] == nil {
+				locales[lang] = make(map[string]string, len(bundle))
+			}
+			for key, text := range bundle {
+				locales[lang][key] = text
+			}
+		}
+	}
+	loadedLocales = locales
+	return locales
+}
+
+// 规范化语言名称，例如 zh、zh_cn 均为 zh-CN，不支持的语言返回空字符串
+func normalizeLanguage(lang string) string {
+	key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+	if key == "" {
+		return ""
+	}
+	if alias, ok := languageAliases[key]; ok {
+		return alias
+	}
+	for name := range loadLocales() {
+		if strings.ToLower(name) == key {
+			return name
+		}
+	}
+	return ""
+}
+
+// 默认语言，配置无效时为 zh-CN
+func defaultLanguage() string {
+	if lang := normalizeLanguage(getI18nConfig().Language); lang != "" {
+		return lang
+	}
+	return languageZhCN
+}
+
+// 按语言翻译，缺少的文本依次使用默认语言和 zh-CN 的文本
+func translate(lang, key string, args ...interface{}) string {
+	locales := loadLocales()
+	format, ok := locales[lang][key]
+	if !ok {
+		format, ok = locales[defaultLanguage()][key]
+	}
+	if !ok {
+		format, ok = locales[languageZhCN][key]
+	}
+	if !ok {
+		slog.Warn("Missing locale text", "language", lang, "key", key)
+		format = key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// 待翻译的文本，推送时按接收者的语言渲染。参数中的 localizedText 和 localizedList 同样按该语言渲染
+type localizedText struct {
+	Key  string
+	Args []interface{}
+}
+
+// 按分隔符拼接的多段文本，分隔符同样是语言包中的文本
+type localizedList struct {
+	Separator string
+	Items     []localizedText
+}
+
+func i18nText(key string, args ...interface{}) localizedText {
+	return localizedText{Key: key, Args: args}
+}
+
+func (t localizedText) empty() bool { return t.Key == "" }
+
+// 按指定语言渲染，空文本返回空字符串
+func (t localizedText) in(lang string) string {
+	if t.empty() {
+		return ""
+	}
+	args := make([]interface{}, len(t.Args))
+	for i, arg := range t.Args {
+		switch v := arg.(type) {
+		case localizedText:
+			args[i] = v.in(lang)
+		case localizedList:
+			args[i] = v.in(lang)
+		default:
+			args[i] = arg
+		}
+	}
+	return translate(lang, t.Key, args...)
+}
+
+// 默认语言的文本，用于日志和只有一个接收方的渠道
+func (t localizedText) String() string { return t.in(defaultLanguage()) }
+
+func (l localizedList) in(lang string) string {
+	parts := make([]string, 0, len(l.Items))
+	for _, item := range l.Items {
+		if !item.empty() {
+			parts = append(parts, item.in(lang))
+		}
+	}
+	return strings.Join(parts, translate(lang, l.Separator))
+}
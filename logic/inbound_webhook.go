@@ -0,0 +1,211 @@
+package logic
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 外部告警接收配置，挂在管理接口的 POST /webhook/{source} 上，
+// 接收任意 JSON 告警（Grafana、Alertmanager 或自己的脚本）并按路由推送
+type InboundConfig struct {
+	Enabled bool           `json:"enabled"`
+	Token   string         `json:"token"`  // 专用访问令牌，以 Bearer 头或 ?token= 传入；也接受管理接口中 webhook 或 admin 范围的凭据
+	Routes  []InboundRoute `json:"routes"` // 按顺序匹配第一条，都不匹配时推送给订阅者和 Telegram 会话
+}
+
+// 外部告警路由
+type InboundRoute struct {
+	Source   string   `json:"source"`   // 匹配路径中的来源，为空匹配所有来源
+	Severity string   `json:"severity"` // 匹配告警级别，例如 critical、warning，为空匹配所有级别
+	Drop     bool     `json:"drop"`     // 丢弃匹配的告警
+	Targets  []string `json:"targets"`  // Bark 地址，支持密钥引用，为空时推送给订阅者
+	Level    string   `json:"level"`    // Bark 推送级别，为空时按告警级别转换
+	Telegram *bool    `json:"telegram"` // 是否推送到 Telegram 会话，默认推送
+}
+
+func getInboundConfig() InboundConfig {
+	configMutex.RLock()
+	cfg := configData.Inbound
+	configMutex.RUnlock()
+	cfg.Token = secretValue(cfg.Token)
+	return cfg
+}
+
+// 外部告警请求体的上限
+const inboundMaxBody = 1 << 20
+
+// 从请求体中提取的告警
+type inboundAlert struct {
+	Source   string
+	Title    string
+	Message  string
+	Severity string
+}
+
+// 依次尝试的字段，覆盖常见的告警格式，Grafana 与 Alertmanager 的公共标签和注解也会查找
+var (
+	inboundTitleKeys    = []string{"title", "summary", "alertname", "subject"}
+	inboundMessageKeys  = []string{"message", "text", "body", "description", "content"}
+	inboundSeverityKeys = []string{"severity", "level", "priority"}
+)
+
+// 解析外部告警，Alertmanager 格式单独处理，无法识别字段时把整个 JSON 作为内容
+func parseInboundAlert(source string, body []byte) (inboundAlert, error) {
+	if alert, ok := parseAlertmanagerWebhook(source, body); ok {
+		return alert, nil
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return inboundAlert{}, fmt.Errorf("invalid json: %w", err)
+	}
+	scopes := []map[string]interface{}{payload}
+	for _, key := range []string{"commonAnnotations", "commonLabels", "annotations", "labels"} {
+		if nested, ok := payload[key].(map[string]interface{}); ok {
+			scopes = append(scopes, nested)
+		}
+	}
+	alert := inboundAlert{
+		Source:   source,
+		Title:    firstString(scopes, inboundTitleKeys),
+		Message:  firstString(scopes, inboundMessageKeys),
+		Severity: strings.ToLower(firstString(scopes, inboundSeverityKeys)),
+	}
+	if status, _ := payload["status"].(string); status == "resolved" {
+		alert.Severity = "resolved"
+	}
+	if alert.Message == "" {
+		alert.Message = alert.Title
+	}
+	if alert.Message == "" {
+		compact, _ := json.Marshal(payload)
+		alert.Message = string(compact)
+	}
+	return alert, nil
+}
+
+func firstString(scopes []map[string]interface{}, keys []string) string {
+	for _, scope := range scopes {
+		for _, key := range keys {
+			switch v := scope[key].(type) {
+			case string:
+				if v = strings.TrimSpace(v); v != "" {
+					return v
+				}
+			case float64:
+				return fmt.Sprint(v)
+			}
+		}
+	}
+	return ""
+}
+
+// 告警级别对应的 Bark 推送级别
+func inboundBarkLevel(severity string) string {
+	switch severity {
+	case "critical", "fatal", "emergency", "page", "p1":
+		return barkLevelCritical
+	case "error", "warning", "warn", "high", "p2":
+		return barkLevelTimeSensitive
+	case "resolved", "ok", "info", "debug", "low":
+		return barkLevelPassive
+	default:
+		return barkLevelActive
+	}
+}
+
+func matchInboundRoute(routes []InboundRoute, alert inboundAlert) *InboundRoute {
+	for i, route := range routes {
+		if route.Source != "" && !strings.EqualFold(route.Source, alert.Source) {
+			continue
+		}
+		if route.Severity != "" && !strings.EqualFold(route.Severity, alert.Severity) {
+			continue
+		}
+		return &routes[i]
+	}
+	return nil
+}
+
+// 按路由推送外部告警，返回处理结果
+func dispatchInboundAlert(r *http.Request, cfg InboundConfig, alert inboundAlert) string {
+	route := matchInboundRoute(cfg.Routes, alert)
+	if route != nil && route.Drop {
+		return "dropped"
+	}
+	if _, paused := notificationsPaused(time.Now()); paused {
+		return "paused"
+	}
+	opts := barkOptions{Title: alert.Title, Level: inboundBarkLevel(alert.Severity)}
+	if route != nil && route.Level != "" {
+		opts.Level = route.Level
+	}
+	targets, chats := subscriberTargets(r.Context(), nil, opts.Level, time.Now())
+	telegram := true
+	if route != nil {
+		if len(route.Targets) > 0 {
+			targets = secretValues(route.Targets)
+		}
+		if route.Telegram != nil {
+			telegram = *route.Telegram
+		}
+	}
+	message := alert.Message
+	if alert.Source != "" {
+		message = "[" + alert.Source + "] " + message
+	}
+	pushBark(r.Context(), targets, message, opts)
+	if telegram {
+		format := channelFormat(channelTelegram)
+		notice := notification{Title: escapeText(format, alert.Title), Body: escapeText(format, message)}
+		pushTelegram(r.Context(), nil, notice.telegramText(format), chats)
+	}
+	logNotification(r.Context(), "", targets, message)
+	return "sent"
+}
+
+func registerInboundWebhook(mux *http.ServeMux) {
+	scoped := requireScope(scopeWebhook, true, http.HandlerFunc(receiveInboundAlert))
+	mux.HandleFunc("POST /webhook/{source}", func(w http.ResponseWriter, r *http.Request) {
+		token := getInboundConfig().Token
+		if token != "" {
+			provided := r.URL.Query().Get("token")
+			if provided == "" {
+				provided = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			}
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+				receiveInboundAlert(w, r)
+				return
+			}
+			if !getAdminConfig().authEnabled() {
+				writeAdminError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+				return
+			}
+		}
+		scoped.ServeHTTP(w, r)
+	})
+}
+
+func receiveInboundAlert(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, inboundMaxBody))
+	if err != nil {
+		writeAdminError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	alert, err := parseInboundAlert(r.PathValue("source"), body)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	status := dispatchInboundAlert(r, getInboundConfig(), alert)
+	slog.Info("Inbound alert received", "source", alert.Source, "severity", alert.Severity, "status", status)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"status": status})
+}
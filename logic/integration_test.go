@@ -0,0 +1,317 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 集成测试的一个场景
+type integrationScenario struct {
+	name string
+	run  func(ctx context.Context, env *integrationEnv) error
+}
+
+// 集成测试环境：模拟的 Graph API 有 primary 和 secondary 两个地址，推送到模拟的 Bark 与 Telegram
+type integrationEnv struct {
+	graph    *fakeGraph
+	receiver *fakeReceiver
+	block    int64    // 最新生成的区块号
+	hashes   []string // 已生成的交易哈希
+}
+
+// 集成测试的起始进度
+const integrationStartBlock = 100
+
+// TestIntegration 在临时目录中使用模拟的 Graph API 和推送服务，执行完整的 GraphTask 轮次并检查推送结果与处理进度。
+// 覆盖分页、重复轮询、限流与故障转移、子图错误、重复交易、随机故障注入等场景，不访问网络。
+// 场景依次执行，后一个场景依赖前一个场景的进度和已处理交易，加 -v 时输出运行日志
+func TestIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integration scenarios take several seconds")
+	}
+	chdirTemp(t)
+
+	env := &integrationEnv{graph: newFakeGraph(), receiver: newFakeReceiver(), block: integrationStartBlock}
+	t.Cleanup(env.graph.close)
+	t.Cleanup(env.receiver.close)
+	CloseStore()
+	applyConfig(Config{
+		GraphAPIURLs:    []string{env.graph.url("/primary"), env.graph.url("/secondary")},
+		BarkAPIURLs:     []string{env.receiver.barkURL("integration")},
+		Telegram:        TelegramConfig{BotToken: "integration", ChatIDs: []int64{1}, APIURL: env.receiver.server.URL},
+		LastBlockNumber: strconv.Itoa(integrationStartBlock),
+		LimitPrice:      1000,
+	})
+	t.Cleanup(func() {
+		CloseStore()
+		applyConfig(Config{})
+	})
+	if !testing.Verbose() {
+		previous := slog.Default()
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError + 4})))
+		t.Cleanup(func() { slog.SetDefault(previous) })
+	}
+
+	for _, scenario := range integrationScenarios() {
+		t.Run(scenario.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			env.receiver.reset()
+			if err := scenario.run(ctx, env); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// 切换到临时目录，测试结束后切换回原目录，临时目录由 testing 删除
+func chdirTemp(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func integrationScenarios() []integrationScenario {
+	return []integrationScenario{
+		{"paged", func(ctx context.Context, env *integrationEnv) error {
+			// 每个区块两笔交易，共 120 笔，需要三页，且同一区块的交易跨页
+			env.addSwaps(60, 2)
+			if err := env.cycle(ctx); err != nil {
+				return err
+			}
+			if err := env.expect(120, 120); err != nil {
+				return err
+			}
+			if requests := env.graph.requestCount("/primary"); requests != 3 {
+				return fmt.Errorf("expected 3 graph requests, got %d", requests)
+			}
+			return env.expectCursor(ctx)
+		}},
+		{"rerun", func(ctx context.Context, env *integrationEnv) error {
+			// 没有新交易时不推送，进度不变
+			if err := env.cycle(ctx); err != nil {
+				return err
+			}
+			if err := env.expect(0, 0); err != nil {
+				return err
+			}
+			return env.expectCursor(ctx)
+		}},
+		{"rate-limit-failover", func(ctx context.Context, env *integrationEnv) error {
+			env.graph.inject("/primary", fakeFault{Status: http.StatusTooManyRequests})
+			env.addSwaps(5, 1)
+			before := env.graph.requestCount("/secondary")
+			if err := env.cycle(ctx); err != nil {
+				return err
+			}
+			if env.graph.requestCount("/secondary") == before {
+				return errors.New("secondary endpoint was not used")
+			}
+			if err := env.expect(5, 5); err != nil {
+				return err
+			}
+			return env.expectCursor(ctx)
+		}},
+		{"malformed-response", func(ctx context.Context, env *integrationEnv) error {
+			// 故障转移后继续使用 secondary，它返回无法解析的响应时切换回 primary
+			env.graph.inject("/secondary", fakeFault{Body: "{not json"})
+			env.addSwaps(2, 1)
+			if err := env.cycle(ctx); err != nil {
+				return err
+			}
+			if err := env.expect(2, 2); err != nil {
+				return err
+			}
+			return env.expectCursor(ctx)
+		}},
+		{"indexing-errors", func(ctx context.Context, env *integrationEnv) error {
+			// 所有地址都返回子图错误时本轮失败，进度不变，下一轮补发
+			fault := fakeFault{Errors: []string{"indexing_error"}}
+			env.graph.inject("/primary", fault)
+			env.graph.inject("/secondary", fault)
+			from := env.block
+			env.addSwaps(3, 1)
+			if err := env.cycle(ctx); err == nil {
+				return errors.New("expected graph task to fail")
+			}
+			if err := env.expect(0, 0); err != nil {
+				return err
+			}
+			if block := env.cursor(ctx); block != strconv.FormatInt(from, 10) {
+				return fmt.Errorf("cursor moved to %s on failure, expected %d", block, from)
+			}
+			if err := env.cycle(ctx); err != nil {
+				return err
+			}
+			if err := env.expect(3, 3); err != nil {
+				return err
+			}
+			return env.expectCursor(ctx)
+		}},
+		{"duplicate-hash", func(ctx context.Context, env *integrationEnv) error {
+			// 已处理过的交易哈希出现在新区块中时不再推送
+			env.addSwaps(1, 1)
+			env.block++
+			swap := env.swap(0, env.block)
+			swap.ID += "-replayed"
+			swap.TransactionHash = env.hashes[0]
+			env.graph.addSwaps(swap)
+			if err := env.cycle(ctx); err != nil {
+				return err
+			}
+			if err := env.expect(1, 1); err != nil {
+				return err
+			}
+			return env.expectCursor(ctx)
+		}},
+		{"injected-watcher", func(ctx context.Context, env *integrationEnv) error {
+			// 不经过 Graph API 和推送渠道，直接注入数据源、通知和时钟
+			var notified []string
+			watcher := &Watcher{
+				Sources: []Source{staticSource{name: "injected", swaps: []Swap{env.swap(1000, 2), env.swap(1001, 1)}}},
+				Store:   getStore(),
+				Notifier: NotifierFunc(func(ctx context.Context, swap Swap, mevTag string) error {
+					notified = append(notified, swap.TransactionHash)
+					return nil
+				}),
+				Clock: fixedClock(time.Unix(1700000000, 0)),
+			}
+			for round := 0; round < 2; round++ {
+				if err := watcher.Run(ctx); err != nil {
+					return err
+				}
+			}
+			if len(notified) != 2 {
+				return fmt.Errorf("expected 2 notifications, got %d", len(notified))
+			}
+			cursor, err := state.Get(ctx, defaultCursorName+":injected")
+			if err != nil {
+				return err
+			}
+			if cursor.LastBlockNumber != "2" {
+				return fmt.Errorf("injected cursor at %s, expected 2", cursor.LastBlockNumber)
+			}
+			return env.expect(0, 0)
+		}},
+		{"bark-failure", func(ctx context.Context, env *integrationEnv) error {
+			// 单个渠道推送失败不影响其他渠道和处理进度
+			env.receiver.failBark(fakeFault{Status: http.StatusInternalServerError})
+			env.addSwaps(2, 1)
+			if err := env.cycle(ctx); err != nil {
+				return err
+			}
+			if err := env.expect(1, 2); err != nil {
+				return err
+			}
+			return env.expectCursor(ctx)
+		}},
+		{"chaos", func(ctx context.Context, env *integrationEnv) error {
+			// 随机注入 Graph 超时、无法解析的响应、限流与推送失败，若干轮后进度追上最新区块，
+			// 每笔交易除注入失败的推送外都恰好推送一次
+			previous := getConfigSnapshot()
+			defer applyConfig(previous)
+			cfg := previous
+			cfg.HTTP.ReadTimeout = 1
+			cfg.Chaos = ChaosConfig{Enabled: true, Seed: 420, GraphTimeout: 0.05, GraphMalformed: 0.15,
+				GraphRateLimit: 0.15, ChannelFailure: 0.2}
+			applyConfig(cfg)
+			before := chaosFaultCounts()
+			env.addSwaps(20, 1)
+			target := strconv.FormatInt(env.block, 10)
+			for round := 0; round < 10 && env.cursor(ctx) != target; round++ {
+				env.cycle(ctx)
+			}
+			if err := env.expectCursor(ctx); err != nil {
+				return err
+			}
+			after := chaosFaultCounts()
+			if after[chaosGraph] == before[chaosGraph] {
+				return errors.New("no graph faults injected")
+			}
+			barkFailed, telegramFailed := after[channelBark]-before[channelBark], after[channelTelegram]-before[channelTelegram]
+			return env.expect(20-int(barkFailed), 20-int(telegramFailed))
+		}},
+	}
+}
+
+// 在之后的 blocks 个区块中每个区块生成 perBlock 笔交易，第 n 笔交易换入 n+1 个 token0
+func (env *integrationEnv) addSwaps(blocks, perBlock int) {
+	var swaps []Swap
+	for i := 0; i < blocks; i++ {
+		env.block++
+		for j := 0; j < perBlock; j++ {
+			swap := env.swap(len(env.hashes), env.block)
+			env.hashes = append(env.hashes, swap.TransactionHash)
+			swaps = append(swaps, swap)
+		}
+	}
+	env.graph.addSwaps(swaps...)
+}
+
+func (env *integrationEnv) swap(n int, block int64) Swap {
+	token0, token1 := getPoolTokenMeta()
+	swap := sampleSwap(time.Now())
+	swap.TransactionHash = fmt.Sprintf("0x%064x", n+1)
+	swap.ID = swap.TransactionHash + "#0"
+	swap.Amount0 = rawAmount(strconv.Itoa(n+1), token0.Decimals)
+	swap.Amount1 = rawAmount("-"+strconv.Itoa(n+1), token1.Decimals)
+	swap.BlockNumber = strconv.FormatInt(block, 10)
+	return swap
+}
+
+// 执行一轮 GraphTask，并等待推送队列发送完毕
+func (env *integrationEnv) cycle(ctx context.Context) error {
+	err := GraphTask(ctx)
+	if flushErr := flushDeliveryQueues(ctx); flushErr != nil {
+		return errors.Join(err, flushErr)
+	}
+	return err
+}
+
+// 检查本场景收到的推送条数，同一渠道的推送内容不应重复
+func (env *integrationEnv) expect(bark, telegram int) error {
+	barkMessages, telegramMessages := env.receiver.messages()
+	if len(barkMessages) != bark || len(telegramMessages) != telegram {
+		return fmt.Errorf("expected %d bark and %d telegram messages, got %d and %d",
+			bark, telegram, len(barkMessages), len(telegramMessages))
+	}
+	for channel, messages := range map[string][]fakeMessage{channelBark: barkMessages, channelTelegram: telegramMessages} {
+		seen := make(map[string]bool, len(messages))
+		for _, message := range messages {
+			if seen[message.Text] {
+				return fmt.Errorf("duplicate %s message: %s", channel, strings.SplitN(message.Text, "\n", 2)[0])
+			}
+			seen[message.Text] = true
+		}
+	}
+	return nil
+}
+
+func (env *integrationEnv) cursor(ctx context.Context) string {
+	cursor, err := state.Get(ctx, swapCursorName())
+	if err != nil {
+		return err.Error()
+	}
+	return cursor.LastBlockNumber
+}
+
+// 检查处理进度已推进到最新生成的区块
+func (env *integrationEnv) expectCursor(ctx context.Context) error {
+	if block := env.cursor(ctx); block != strconv.FormatInt(env.block, 10) {
+		return fmt.Errorf("cursor at %s, expected %d", block, env.block)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package logic
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// Kafka 输出配置，每笔 Swap 以 JSON 写入主题，消息键为交易哈希，修改后需要重启
+type KafkaConfig struct {
+	Brokers       []string `json:"brokers"`       // 为空时不启用
+	Topic         string   `json:"topic"`         // 默认 swaps
+	SASLMechanism string   `json:"saslMechanism"` // plain / scram-sha-256 / scram-sha-512，为空时不认证
+	Username      string   `json:"username"`
+	Password      string   `json:"password"` // 支持密钥引用
+	TLS           bool     `json:"tls"`
+}
+
+func getKafkaConfig() KafkaConfig {
+	configMutex.RLock()
+	cfg := configData.Kafka
+	configMutex.RUnlock()
+	cfg.Password = secretValue(cfg.Password)
+	if cfg.Topic == "" {
+		cfg.Topic = "swaps"
+	}
+	return cfg
+}
+
+// 当前的生产者，启动、停止与发布可能在不同协程中同时进行
+var kafkaWriter atomic.Pointer[kafka.Writer]
+
+func kafkaSASL(cfg KafkaConfig) (sasl.Mechanism, error) {
+	switch strings.ToLower(cfg.SASLMechanism) {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	default:
+		return nil, fmt.Errorf("unsupported sasl mechanism %q", cfg.SASLMechanism)
+	}
+}
+
+// 创建异步写入的生产者，写入结果记录在渠道状态中
+func startKafkaOutput() {
+	cfg := getKafkaConfig()
+	if len(cfg.Brokers) == 0 {
+		return
+	}
+	mechanism, err := kafkaSASL(cfg)
+	if err != nil {
+		slog.Error("Failed to start kafka output", "error", err)
+		return
+	}
+	transport := &kafka.Transport{SASL: mechanism}
+	if cfg.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	previous := kafkaWriter.Swap(&kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: 100 * time.Millisecond,
+		Async:        true,
+		Transport:    transport,
+		Completion: func(messages []kafka.Message, err error) {
+			recordChannelResult("kafka", cfg.Topic, time.Now(), err)
+			if err != nil {
+				slog.Error("Failed to publish swaps to kafka", "topic", cfg.Topic, "count", len(messages), "error", err)
+			}
+		},
+	})
+	if previous != nil {
+		previous.Close()
+	}
+	slog.Info("Publishing swaps to kafka", "brokers", cfg.Brokers, "topic", cfg.Topic)
+}
+
+// 写入尚未发送的消息并关闭生产者
+func stopKafkaOutput() {
+	writer := kafkaWriter.Swap(nil)
+	if writer == nil {
+		return
+	}
+	if err := writer.Close(); err != nil {
+		slog.Error("Failed to close kafka writer", "error", err)
+	}
+}
+
+func publishKafka(ctx context.Context, event *SwapEvent) {
+	writer := kafkaWriter.Load()
+	if writer == nil {
+		return
+	}
+	value, err := json.Marshal(newSwapFeedEvent(event))
+	if err != nil {
+		slog.Error("Failed to encode swap for kafka", "error", err)
+		return
+	}
+	message := kafka.Message{Key: []byte(event.Swap.TransactionHash), Value: value, Time: event.Time}
+	if err := writer.WriteMessages(ctx, message); err != nil {
+		slog.Error("Failed to publish swap to kafka", "transactionHash", event.Swap.TransactionHash, "error", err)
+	}
+}
@@ -0,0 +1,85 @@
+package logic
+
+import "strings"
+
+// 已知地址类型
+const (
+	addressKindRouter     = "router"     // DEX 路由
+	addressKindAggregator = "aggregator" // 聚合器
+	addressKindMEVBot     = "mevbot"     // MEV 机器人
+)
+
+// 已知的路由、聚合器或机器人地址，可以在配置的 knownAddresses 中补充或覆盖
+type KnownAddress struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+	Kind    string `json:"kind"` // router、aggregator 或 mevbot
+}
+
+// 内置的已知地址（主网）
+var bundledKnownAddresses = []KnownAddress{
+	{Address: "0xe592427a0aece92de3edee1f18e0157c05861564", Name: "Uniswap V3 Router", Kind: addressKindRouter},
+	{Address: "0x68b3465833fb72a70ecdf485e0e4c7bd8665fc45", Name: "Uniswap Router02", Kind: addressKindRouter},
+	{Address: "0x3fc91a3afd70395cd496c647d5a6cc9d4b2b7fad", Name: "Uniswap Universal Router", Kind: addressKindRouter},
+	{Address: "0xef1c6e67703c7bd7107eed8303fbe6ec2554bf6b", Name: "Uniswap Universal Router", Kind: addressKindRouter},
+	{Address: "0x66a9893cc07d91d95644aedd05d03f95e1dba8af", Name: "Uniswap Universal Router V4", Kind: addressKindRouter},
+	{Address: "0x1111111254eeb25477b68fb85ed929f73a960582", Name: "1inch v5", Kind: addressKindAggregator},
+	{Address: "0x111111125421ca6dc452d289314280a0f8842a65", Name: "1inch v6", Kind: addressKindAggregator},
+	{Address: "0xdef1c0ded9bec7f1a1670819833240f027b25eff", Name: "0x Exchange Proxy", Kind: addressKindAggregator},
+	{Address: "0x9008d19f58aabd9ed0d60971565aa8510560ab41", Name: "CoW Protocol", Kind: addressKindAggregator},
+	{Address: "0xdef171fe48cf0115b1d80b88dc8eab59176fee57", Name: "ParaSwap", Kind: addressKindAggregator},
+	{Address: "0x6131b5fae19ea4f9d964eac0408e4408b66337b5", Name: "KyberSwap", Kind: addressKindAggregator},
+	{Address: "0x6352a56caadc4f1e25cd6c75970fa768a3304e64", Name: "OpenOcean", Kind: addressKindAggregator},
+	{Address: "0xae2fc483527b8ef99eb5d9b44875f005ba1fae13", Name: "jaredfromsubway", Kind: addressKindMEVBot},
+	{Address: "0x6b75d8af000000e20b7a7ddf000ba900b4009a80", Name: "jaredfromsubway v2", Kind: addressKindMEVBot},
+}
+
+func getKnownAddresses() []KnownAddress {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.KnownAddresses
+}
+
+// 查询已知地址，配置中的地址优先于内置地址
+func lookupKnownAddress(address string) (KnownAddress, bool) {
+	for _, list := range [][]KnownAddress{getKnownAddresses(), bundledKnownAddresses} {
+		for _, known := range list {
+			if strings.EqualFold(known.Address, address) {
+				return known, true
+			}
+		}
+	}
+	return KnownAddress{}, false
+}
+
+// 是否为公共路由或聚合器合约
+func isRouterAddress(address string) bool {
+	known, ok := lookupKnownAddress(address)
+	return ok && (known.Kind == addressKindRouter || known.Kind == addressKindAggregator)
+}
+
+// 交易发起方的类型，发送方或接收方为机器人时返回 mevbot，未知时返回空字符串
+func swapActorKind(swap *Swap) string {
+	for _, address := range []string{swap.Sender, swap.Recipient} {
+		if known, ok := lookupKnownAddress(address); ok && known.Kind == addressKindMEVBot {
+			return addressKindMEVBot
+		}
+	}
+	if known, ok := lookupKnownAddress(swap.Sender); ok {
+		return known.Kind
+	}
+	return ""
+}
+
+// 告警中的操作者标签，用于区分机器人与经过路由的普通用户
+func actorTag(swap *Swap) localizedText {
+	for _, address := range []string{swap.Sender, swap.Recipient} {
+		if known, ok := lookupKnownAddress(address); ok && known.Kind == addressKindMEVBot {
+			return i18nText("actor.bot", known.Name)
+		}
+	}
+	if known, ok := lookupKnownAddress(swap.Sender); ok {
+		return i18nText("actor.user", known.Name)
+	}
+	return localizedText{}
+}
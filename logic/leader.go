@@ -0,0 +1,262 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 主备选举后端
+const (
+	haBackendRedis    = "redis"    // SET NX 加过期时间的租约，连接参数使用 storage.redis
+	haBackendPostgres = "postgres" // 会话级 advisory lock，连接参数使用 storage.postgres
+)
+
+// 多实例部署配置。开启后只有持有锁的实例执行定时任务，其他实例待命，
+// 锁失效后由待命实例接管。处理进度需要保存在共享存储（redis 或 postgres）中
+type HAConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Backend    string `json:"backend"`    // redis 或 postgres
+	LockName   string `json:"lockName"`   // 锁名称，同一组实例使用相同的名称
+	TTLSeconds int    `json:"ttlSeconds"` // 租约时间，主实例失联后最多经过这么久由备实例接管
+	InstanceID string `json:"instanceId"` // 实例标识，默认为 主机名-进程号
+}
+
+func getHAConfig() HAConfig {
+	configMutex.RLock()
+	cfg := configData.HA
+	configMutex.RUnlock()
+	if cfg.Backend == "" {
+		cfg.Backend = haBackendRedis
+	}
+	if cfg.LockName == "" {
+		cfg.LockName = "message-push:leader"
+	}
+	if cfg.TTLSeconds <= 0 {
+		cfg.TTLSeconds = 15
+	}
+	if cfg.InstanceID == "" {
+		hostname, _ := os.Hostname()
+		cfg.InstanceID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	return cfg
+}
+
+// 分布式锁
+type leaderLock interface {
+	Acquire(ctx context.Context) (bool, error) // 获取或续期锁，返回当前是否持有
+	Release(ctx context.Context) error
+	Close() error
+}
+
+// 未开启时视为主实例
+var (
+	isLeader     atomic.Bool
+	leaderMutex  sync.Mutex
+	activeLock   leaderLock
+	stopElection context.CancelFunc
+	electionDone chan struct{} // 续期协程退出后关闭
+)
+
+func init() {
+	isLeader.Store(true)
+}
+
+// 当前实例是否应执行定时任务
+func leading() bool {
+	return isLeader.Load()
+}
+
+// 启动主备选举，首次选举完成后返回
+func startLeaderElection() {
+	cfg := getHAConfig()
+	if !cfg.Enabled {
+		return
+	}
+	lock, err := openLeaderLock(cfg)
+	if err != nil {
+		// 无法连接锁服务时不执行任务，避免重复通知
+		slog.Error("Failed to open leader lock, standing by", "backend", cfg.Backend, "error", err)
+		isLeader.Store(false)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	leaderMutex.Lock()
+	activeLock, stopElection, electionDone = lock, cancel, done
+	leaderMutex.Unlock()
+
+	isLeader.Store(false)
+	campaign(ctx, lock, cfg)
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(time.Duration(cfg.TTLSeconds) * time.Second / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				campaign(ctx, lock, cfg)
+			}
+		}
+	}()
+}
+
+// 获取或续期锁，续期失败时立即放弃主实例身份
+func campaign(ctx context.Context, lock leaderLock, cfg HAConfig) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.TTLSeconds)*time.Second/3)
+	defer cancel()
+	acquired, err := lock.Acquire(ctx)
+	if err != nil {
+		slog.Error("Leader election failed", "instance", cfg.InstanceID, "error", err)
+		acquired = false
+	}
+	if was := isLeader.Swap(acquired); was != acquired {
+		if acquired {
+			watchdog.reset(time.Now())
+			slog.Info("Became leader", "instance", cfg.InstanceID, "lock", cfg.LockName)
+		} else {
+			slog.Warn("Lost leadership, standing by", "instance", cfg.InstanceID, "lock", cfg.LockName)
+		}
+	}
+}
+
+// 停止选举并释放锁，便于备实例立即接管。先等待续期协程退出，避免与进行中的 Acquire 同时使用锁
+func stopLeaderElection() {
+	leaderMutex.Lock()
+	lock, cancel, done := activeLock, stopElection, electionDone
+	activeLock, stopElection, electionDone = nil, nil, nil
+	leaderMutex.Unlock()
+	if lock == nil {
+		return
+	}
+	cancel()
+	<-done
+	ctx, cancelRelease := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelRelease()
+	if isLeader.Swap(false) {
+		if err := lock.Release(ctx); err != nil {
+			slog.Error("Failed to release leader lock", "error", err)
+		}
+	}
+	lock.Close()
+}
+
+func openLeaderLock(cfg HAConfig) (leaderLock, error) {
+	storage := getStorageConfig()
+	switch cfg.Backend {
+	case haBackendRedis:
+		client := redis.NewClient(&redis.Options{Addr: storage.Redis.Addr, Password: storage.Redis.Password, DB: storage.Redis.DB})
+		return &redisLeaderLock{client: client, key: cfg.LockName, id: cfg.InstanceID, ttl: time.Duration(cfg.TTLSeconds) * time.Second}, nil
+	case haBackendPostgres:
+		db, err := sql.Open("pgx", storage.Postgres.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return &postgresLeaderLock{db: db, name: cfg.LockName}, nil
+	default:
+		return nil, fmt.Errorf("unknown ha backend %q", cfg.Backend)
+	}
+}
+
+// 只有锁的值仍是本实例时才续期或删除
+var (
+	redisRenewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	redisReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// Redis 租约锁，值为实例标识
+type redisLeaderLock struct {
+	client *redis.Client
+	key    string
+	id     string
+	ttl    time.Duration
+}
+
+func (l *redisLeaderLock) Acquire(ctx context.Context) (bool, error) {
+	acquired, err := l.client.SetNX(ctx, l.key, l.id, l.ttl).Result()
+	if err != nil || acquired {
+		return acquired, err
+	}
+	renewed, err := redisRenewScript.Run(ctx, l.client, []string{l.key}, l.id, l.ttl.Milliseconds()).Int()
+	return renewed == 1, err
+}
+
+func (l *redisLeaderLock) Release(ctx context.Context) error {
+	return redisReleaseScript.Run(ctx, l.client, []string{l.key}, l.id).Err()
+}
+
+func (l *redisLeaderLock) Close() error {
+	return l.client.Close()
+}
+
+// Postgres advisory lock 属于会话，需要固定使用同一个连接，连接断开时锁自动释放
+type postgresLeaderLock struct {
+	db   *sql.DB
+	conn *sql.Conn
+	name string
+	held bool
+}
+
+func (l *postgresLeaderLock) Acquire(ctx context.Context) (bool, error) {
+	if l.conn == nil {
+		conn, err := l.db.Conn(ctx)
+		if err != nil {
+			return false, err
+		}
+		l.conn = conn
+	}
+	if l.held {
+		// 已持有锁时确认连接仍然有效
+		if err := l.conn.PingContext(ctx); err != nil {
+			l.reset()
+			return false, err
+		}
+		return true, nil
+	}
+	var acquired bool
+	if err := l.conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, l.name).Scan(&acquired); err != nil {
+		l.reset()
+		return false, err
+	}
+	l.held = acquired
+	return acquired, nil
+}
+
+func (l *postgresLeaderLock) reset() {
+	if l.conn != nil {
+		l.conn.Close()
+	}
+	l.conn, l.held = nil, false
+}
+
+func (l *postgresLeaderLock) Release(ctx context.Context) error {
+	if l.conn == nil || !l.held {
+		return nil
+	}
+	_, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, l.name)
+	l.held = false
+	return err
+}
+
+func (l *postgresLeaderLock) Close() error {
+	l.reset()
+	return l.db.Close()
+}
@@ -0,0 +1,139 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 相同警告和错误日志的合并窗口，0 表示不合并
+var logDedupWindow atomic.Int64
+
+// 按配置设置合并窗口，配置重新加载后调用
+func applyLogSampling() {
+	seconds := getLogConfig().DedupSeconds
+	if seconds < 0 {
+		seconds = 0
+	}
+	logDedupWindow.Store(int64(time.Duration(seconds) * time.Second))
+}
+
+// 合并窗口内被跳过的重复日志
+type repeatedLog struct {
+	first   time.Time
+	record  slog.Record
+	handler slog.Handler
+	count   int
+}
+
+type logDedupState struct {
+	mu      sync.Mutex
+	entries map[string]*repeatedLog
+}
+
+// 相同的警告和错误日志在窗口内只输出第一条，窗口结束后输出一条汇总，
+// 例如 Graph 服务不可用时每秒一次的查询错误
+type dedupHandler struct {
+	next  slog.Handler
+	scope string // WithAttrs、WithGroup 附加的内容，参与判断是否相同
+	state *logDedupState
+}
+
+func newDedupHandler(next slog.Handler) *dedupHandler {
+	h := &dedupHandler{next: next, state: &logDedupState{entries: make(map[string]*repeatedLog)}}
+	go func() {
+		for now := range time.Tick(10 * time.Second) {
+			h.flush(now)
+		}
+	}()
+	return h
+}
+
+func (h *dedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *dedupHandler) Handle(ctx context.Context, record slog.Record) error {
+	window := time.Duration(logDedupWindow.Load())
+	if record.Level < slog.LevelWarn || window <= 0 {
+		return h.next.Handle(ctx, record)
+	}
+
+	key := h.key(record)
+	h.state.mu.Lock()
+	entry, ok := h.state.entries[key]
+	if ok && record.Time.Sub(entry.first) < window {
+		entry.count++
+		h.state.mu.Unlock()
+		return nil
+	}
+	h.state.entries[key] = &repeatedLog{first: record.Time, record: record.Clone(), handler: h.next}
+	h.state.mu.Unlock()
+
+	if ok {
+		entry.summarize(ctx, record.Time)
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *dedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scope := h.scope
+	for _, attr := range attrs {
+		scope += attr.String() + " "
+	}
+	return &dedupHandler{next: h.next.WithAttrs(attrs), scope: scope, state: h.state}
+}
+
+func (h *dedupHandler) WithGroup(name string) slog.Handler {
+	return &dedupHandler{next: h.next.WithGroup(name), scope: h.scope + name + ".", state: h.state}
+}
+
+func (h *dedupHandler) key(record slog.Record) string {
+	var b strings.Builder
+	b.WriteString(h.scope)
+	b.WriteString(record.Level.String())
+	b.WriteString(" ")
+	b.WriteString(record.Message)
+	record.Attrs(func(attr slog.Attr) bool {
+		b.WriteString(" ")
+		b.WriteString(attr.String())
+		return true
+	})
+	return b.String()
+}
+
+// 输出窗口已结束的汇总
+func (h *dedupHandler) flush(now time.Time) {
+	window := time.Duration(logDedupWindow.Load())
+	var expired []*repeatedLog
+	h.state.mu.Lock()
+	for key, entry := range h.state.entries {
+		if now.Sub(entry.first) >= window {
+			expired = append(expired, entry)
+			delete(h.state.entries, key)
+		}
+	}
+	h.state.mu.Unlock()
+	for _, entry := range expired {
+		entry.summarize(context.Background(), now)
+	}
+}
+
+// 有被跳过的日志时输出一条汇总，例如 "Error fetching swaps (repeated 240 times in last 4m0s)"
+func (e *repeatedLog) summarize(ctx context.Context, now time.Time) {
+	if e.count == 0 {
+		return
+	}
+	elapsed := now.Sub(e.first).Round(time.Second)
+	summary := slog.NewRecord(now, e.record.Level, fmt.Sprintf("%s (repeated %d times in last %s)", e.record.Message, e.count, elapsed), e.record.PC)
+	e.record.Attrs(func(attr slog.Attr) bool {
+		summary.AddAttrs(attr)
+		return true
+	})
+	summary.AddAttrs(slog.Int("repeated", e.count))
+	e.handler.Handle(ctx, summary)
+}
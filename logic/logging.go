@@ -0,0 +1,165 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// 日志配置，文件中每行一条 JSON，控制台输出便于阅读的文本
+type LogConfig struct {
+	Level      string `json:"level"`      // debug / info / warn / error，默认 info，修改后立即生效
+	File       string `json:"file"`       // 日志文件，为空时只输出到控制台
+	MaxSizeMB  int    `json:"maxSizeMB"`  // 单个日志文件的最大大小
+	MaxBackups int    `json:"maxBackups"` // 最多保留的旧日志文件数量
+	MaxAgeDays int    `json:"maxAgeDays"` // 旧日志文件保留的天数
+	Compress   bool   `json:"compress"`   // 是否压缩旧日志
+
+	DedupSeconds int `json:"dedupSeconds"` // 相同的警告和错误日志在该时间内只输出一次，之后汇总重复次数，默认 60，-1 表示不合并
+}
+
+func getLogConfig() LogConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Log
+	if cfg.MaxSizeMB <= 0 {
+		cfg.MaxSizeMB = 50
+	}
+	if cfg.MaxBackups <= 0 {
+		cfg.MaxBackups = 20
+	}
+	if cfg.MaxAgeDays <= 0 {
+		cfg.MaxAgeDays = 2
+	}
+	if cfg.DedupSeconds == 0 {
+		cfg.DedupSeconds = 60
+	}
+	return cfg
+}
+
+// 当前日志级别，所有输出共用
+var logLevel = new(slog.LevelVar)
+
+func parseLogLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if level == "" {
+		return slog.LevelInfo, nil
+	}
+	err := l.UnmarshalText([]byte(level))
+	return l, err
+}
+
+// 按配置设置日志级别，配置重新加载后调用
+func applyLogLevel() {
+	level, err := parseLogLevel(getLogConfig().Level)
+	if err != nil {
+		slog.Error("Invalid log level, keeping current level", "level", getLogConfig().Level)
+		return
+	}
+	logLevel.Set(level)
+}
+
+// 运行时修改日志级别，重新加载配置后恢复为配置中的级别
+func setLogLevel(level string) (slog.Level, error) {
+	if level == "" {
+		return logLevel.Level(), errors.New("level is required")
+	}
+	l, err := parseLogLevel(level)
+	if err != nil {
+		return logLevel.Level(), err
+	}
+	logLevel.Set(l)
+	return l, nil
+}
+
+// 当前为 debug 时恢复配置的级别，否则切换到 debug
+func toggleDebugLogging() slog.Level {
+	if logLevel.Level() == slog.LevelDebug {
+		applyLogLevel()
+		if logLevel.Level() == slog.LevelDebug {
+			logLevel.Set(slog.LevelInfo)
+		}
+	} else {
+		logLevel.Set(slog.LevelDebug)
+	}
+	return logLevel.Level()
+}
+
+// SetupLogging 将 slog 默认输出设置为 控制台文本 + 轮转文件 JSON，标准库 log 也会经过同一个 handler
+func SetupLogging() error {
+	cfg := getLogConfig()
+	applyLogLevel()
+	applyLogSampling()
+
+	options := &slog.HandlerOptions{AddSource: true, Level: logLevel, ReplaceAttr: shortSource}
+	handlers := []slog.Handler{slog.NewTextHandler(os.Stdout, options)}
+	if cfg.File != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.File), 0755); err != nil {
+			return err
+		}
+		handlers = append(handlers, slog.NewJSONHandler(&lumberjack.Logger{
+			Filename:   cfg.File,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+		}, options))
+	}
+	slog.SetDefault(slog.New(newDedupHandler(fanoutHandler(handlers))))
+	watchLogLevelSignal()
+	slog.Info("Logger initialized", "file", cfg.File, "level", logLevel.Level())
+	return nil
+}
+
+// 源码位置只保留文件名和行号
+func shortSource(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.SourceKey && len(groups) == 0 {
+		if source, ok := a.Value.Any().(*slog.Source); ok {
+			a.Value = slog.StringValue(filepath.Base(source.File) + ":" + strconv.Itoa(source.Line))
+		}
+	}
+	return a
+}
+
+// 把日志同时写入多个 handler
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
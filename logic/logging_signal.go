@@ -0,0 +1,22 @@
+//go:build !windows
+
+package logic
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// 收到 SIGUSR1 时在 debug 与配置的级别之间切换
+func watchLogLevelSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	go func() {
+		for range signals {
+			level := toggleDebugLogging()
+			slog.Warn("Log level toggled by SIGUSR1", "level", level)
+		}
+	}()
+}
@@ -0,0 +1,4 @@
+package logic
+
+// Windows 没有 SIGUSR1，只能通过管理接口修改日志级别
+func watchLogLevelSignal() {}
@@ -0,0 +1,147 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// 查询结果缓存配置。代币元数据、池子代币、ENS 名称与 CEX 参考价变化很慢，由补充信息的各个模块共用同一套缓存，
+// 开启 persist 后代币与地址相关的缓存写入存储，重启后不需要重新查询
+type CacheConfig struct {
+	Persist            bool `json:"persist"`            // 写入存储，CEX 参考价只缓存在内存中
+	TokenMetadataHours int  `json:"tokenMetadataHours"` // 代币元数据与池子代币的缓存时间，默认 168
+	ENSHours           int  `json:"ensHours"`           // ENS 名称的缓存时间，默认 24
+}
+
+func getCacheConfig() CacheConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Cache
+}
+
+// 缓存命中、未命中和查询失败时使用过期值的次数，键为 缓存名.hit / miss / stale
+var lookupCacheCounter = expvar.NewMap("lookup_cache")
+
+type lookupCacheEntry[V any] struct {
+	Value     V         `json:"value"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// 带过期时间的查询缓存，同一个键同时只有一个查询
+type lookupCache[V any] struct {
+	name    string
+	durable bool // 长期有效的数据：开启 cache.persist 时写入存储，查询失败时继续使用过期的值
+	group   singleflight.Group
+	mu      sync.Mutex
+	entries map[string]lookupCacheEntry[V]
+	loaded  bool // 已从存储读取
+}
+
+func newLookupCache[V any](name string, durable bool) *lookupCache[V] {
+	return &lookupCache[V]{name: name, durable: durable, entries: make(map[string]lookupCacheEntry[V])}
+}
+
+// 存储中的设置类型
+func (c *lookupCache[V]) kind() string {
+	return "cache." + c.name
+}
+
+func (c *lookupCache[V]) persistent() bool {
+	return c.durable && getCacheConfig().Persist
+}
+
+// 读取缓存，不存在或已过期时调用 load 查询并缓存 ttl
+func (c *lookupCache[V]) get(ctx context.Context, key string, ttl time.Duration, load func() (V, error)) (V, error) {
+	c.mu.Lock()
+	c.loadLocked(ctx)
+	entry, cached := c.entries[key]
+	c.mu.Unlock()
+	if cached && time.Now().Before(entry.ExpiresAt) {
+		lookupCacheCounter.Add(c.name+".hit", 1)
+		return entry.Value, nil
+	}
+	lookupCacheCounter.Add(c.name+".miss", 1)
+
+	result, err, _ := c.group.Do(key, func() (interface{}, error) {
+		value, err := load()
+		if err != nil {
+			return value, err
+		}
+		c.set(ctx, key, value, ttl)
+		return value, nil
+	})
+	if err != nil {
+		if cached && c.durable {
+			lookupCacheCounter.Add(c.name+".stale", 1)
+			slog.Warn("Lookup failed, using expired cache entry", "cache", c.name, "key", key, "error", err)
+			return entry.Value, nil
+		}
+		var zero V
+		return zero, err
+	}
+	return result.(V), nil
+}
+
+func (c *lookupCache[V]) set(ctx context.Context, key string, value V, ttl time.Duration) {
+	entry := lookupCacheEntry[V]{Value: value, ExpiresAt: time.Now().Add(ttl)}
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+	if c.persistent() {
+		if err := saveSetting(ctx, c.kind(), key, entry); err != nil {
+			slog.Error("Failed to persist cache entry", "cache", c.name, "key", key, "error", err)
+		}
+	}
+}
+
+// 首次使用时读取存储中未过期的条目，调用时持有锁
+func (c *lookupCache[V]) loadLocked(ctx context.Context) {
+	if c.loaded || !c.persistent() {
+		return
+	}
+	c.loaded = true
+	stored, err := loadSettings[lookupCacheEntry[V]](ctx, c.kind())
+	if err != nil {
+		slog.Error("Failed to load cache from store", "cache", c.name, "error", err)
+		return
+	}
+	now := time.Now()
+	for key, entry := range stored {
+		if now.Before(entry.ExpiresAt) {
+			c.entries[key] = entry
+		}
+	}
+}
+
+// 清理存储中过期的条目，由存储清理任务调用
+func (c *lookupCache[V]) prune(ctx context.Context, now time.Time) error {
+	if !c.persistent() {
+		return nil
+	}
+	stored, err := getStore().LoadSettings(ctx, c.kind())
+	if err != nil {
+		return err
+	}
+	for key, data := range stored {
+		var entry lookupCacheEntry[json.RawMessage]
+		if json.Unmarshal(data, &entry) == nil && now.Before(entry.ExpiresAt) {
+			continue
+		}
+		if err := getStore().DeleteSetting(ctx, c.kind(), key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 清理各缓存在存储中过期的条目
+func pruneLookupCaches(ctx context.Context, now time.Time) error {
+	return errors.Join(tokenMetaCache.prune(ctx, now), poolTokenCache.prune(ctx, now), ensCache.prune(ctx, now))
+}
@@ -0,0 +1,279 @@
+package logic
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+const (
+	// NonfungiblePositionManager 合约地址（主网）
+	defaultPositionManager = "0xC36442b4a4522E871399CD717aBDD847Ab11FE88"
+
+	selectorPositions            = "0x99fbab88" // positions(uint256)
+	selectorSlot0                = "0x3850c7bd" // slot0()
+	selectorFeeGrowthGlobal0X128 = "0xf3058399" // feeGrowthGlobal0X128()
+	selectorFeeGrowthGlobal1X128 = "0x46141319" // feeGrowthGlobal1X128()
+	selectorTicks                = "0xf30dba93" // ticks(int24)
+)
+
+// LP 仓位跟踪配置
+type LPConfig struct {
+	Enabled         bool         `json:"enabled"`
+	PositionManager string       `json:"positionManager"` // NonfungiblePositionManager 地址
+	IntervalMinutes int          `json:"intervalMinutes"` // 定期推送仓位报告的间隔
+	Positions       []LPPosition `json:"positions"`
+}
+
+// 单个 LP 仓位，配置 TokenID 时从链上读取仓位信息，否则只跟踪 tick 区间
+type LPPosition struct {
+	Name      string `json:"name"`
+	TokenID   string `json:"tokenId"`
+	TickLower int32  `json:"tickLower"`
+	TickUpper int32  `json:"tickUpper"`
+}
+
+// 仓位当前状态
+type lpStatus struct {
+	InRange bool
+	Tick    int32
+	Amount0 float64 // 以代币为单位
+	Amount1 float64
+	Fees0   float64
+	Fees1   float64
+	HasNFT  bool
+}
+
+// 2^128 与 2^256
+var (
+	q128 = new(big.Int).Lsh(big.NewInt(1), 128)
+	q256 = new(big.Int).Lsh(big.NewInt(1), 256)
+)
+
+// 各仓位上一次是否在区间内
+var (
+	lpInRange      = make(map[string]bool)
+	lpInRangeMutex sync.Mutex
+)
+
+func getLPConfig() LPConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.LP
+	if cfg.PositionManager == "" {
+		cfg.PositionManager = defaultPositionManager
+	}
+	return cfg
+}
+
+// LPRangeTask 检查仓位是否离开区间，状态变化时立即告警
+func LPRangeTask(ctx context.Context) error {
+	return checkLPPositions(ctx, false)
+}
+
+// LPReportTask 定期推送仓位报告
+func LPReportTask(ctx context.Context) error {
+	return checkLPPositions(ctx, true)
+}
+
+func checkLPPositions(ctx context.Context, report bool) error {
+	cfg := getLPConfig()
+	pool := getPoolConfig().Address
+	if !cfg.Enabled || pool == "" {
+		return nil
+	}
+
+	for _, position := range cfg.Positions {
+		status, err := queryLPStatus(ctx, cfg, pool, position)
+		if err != nil {
+			slog.Error("Failed to query LP position", "name", position.Name, "error", err)
+			continue
+		}
+
+		lpInRangeMutex.Lock()
+		prev, known := lpInRange[position.Name]
+		lpInRange[position.Name] = status.InRange
+		lpInRangeMutex.Unlock()
+
+		if known && prev != status.InRange {
+			action := i18nText("alert.lpLeave")
+			if status.InRange {
+				action = i18nText("alert.lpEnter")
+			}
+			sendAlert(ctx, i18nText("alert.lpRange",
+				position.Name, action, status.Tick, position.TickLower, position.TickUpper), barkLevelTimeSensitive)
+		}
+		if report {
+			sendAlert(ctx, formatLPReport(position, status), barkLevelActive)
+		}
+	}
+	return nil
+}
+
+func formatLPReport(position LPPosition, status lpStatus) localizedText {
+	rangeText := i18nText("alert.lpInRange")
+	if !status.InRange {
+		rangeText = i18nText("alert.lpOutOfRange")
+	}
+	var value localizedText
+	if status.HasNFT {
+		value = i18nText("alert.lpValue", status.Amount0, status.Amount1, status.Fees0, status.Fees1)
+	}
+	return i18nText("alert.lpReport", position.Name, rangeText, status.Tick, position.TickLower, position.TickUpper, value)
+}
+
+// 查询仓位状态
+func queryLPStatus(ctx context.Context, cfg LPConfig, pool string, position LPPosition) (lpStatus, error) {
+	var status lpStatus
+	slot0, err := ethCallWords(ctx, pool, selectorSlot0)
+	if err != nil {
+		return status, err
+	}
+	sqrtPriceX96 := slot0[0]
+	status.Tick = int32(signedWord(slot0[1]).Int64())
+
+	var liquidity *big.Int
+	var insideLast0, insideLast1, owed0, owed1 *big.Int
+	if position.TokenID != "" {
+		tokenID, ok := new(big.Int).SetString(position.TokenID, 10)
+		if !ok {
+			return status, fmt.Errorf("invalid tokenId %q", position.TokenID)
+		}
+		words, err := ethCallWords(ctx, cfg.PositionManager, selectorPositions+encodeWord(tokenID))
+		if err != nil {
+			return status, err
+		}
+		if len(words) < 12 {
+			return status, fmt.Errorf("unexpected positions result")
+		}
+		position.TickLower = int32(signedWord(words[5]).Int64())
+		position.TickUpper = int32(signedWord(words[6]).Int64())
+		liquidity = words[7]
+		insideLast0, insideLast1 = words[8], words[9]
+		owed0, owed1 = words[10], words[11]
+		status.HasNFT = true
+	}
+	status.InRange = status.Tick >= position.TickLower && status.Tick < position.TickUpper
+	if !status.HasNFT {
+		return status, nil
+	}
+
+	token0, token1 := getPoolTokenMeta()
+	amount0, amount1 := positionAmounts(liquidity, sqrtPriceX96, position.TickLower, position.TickUpper)
+	status.Amount0 = amount0 / math.Pow10(token0.Decimals)
+	status.Amount1 = amount1 / math.Pow10(token1.Decimals)
+
+	inside0, inside1, err := feeGrowthInside(ctx, pool, status.Tick, position.TickLower, position.TickUpper)
+	if err != nil {
+		return status, err
+	}
+	status.Fees0 = tokenUnitsFloat(accruedFees(liquidity, inside0, insideLast0, owed0), token0.Decimals)
+	status.Fees1 = tokenUnitsFloat(accruedFees(liquidity, inside1, insideLast1, owed1), token1.Decimals)
+	return status, nil
+}
+
+// 计算区间内的手续费增长
+func feeGrowthInside(ctx context.Context, pool string, tick, tickLower, tickUpper int32) (*big.Int, *big.Int, error) {
+	global0, err := ethCallWords(ctx, pool, selectorFeeGrowthGlobal0X128)
+	if err != nil {
+		return nil, nil, err
+	}
+	global1, err := ethCallWords(ctx, pool, selectorFeeGrowthGlobal1X128)
+	if err != nil {
+		return nil, nil, err
+	}
+	lower, err := ethCallWords(ctx, pool, selectorTicks+encodeWord(big.NewInt(int64(tickLower))))
+	if err != nil {
+		return nil, nil, err
+	}
+	upper, err := ethCallWords(ctx, pool, selectorTicks+encodeWord(big.NewInt(int64(tickUpper))))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(lower) < 4 || len(upper) < 4 {
+		return nil, nil, fmt.Errorf("unexpected ticks result")
+	}
+
+	inside := func(global, outsideLower, outsideUpper *big.Int) *big.Int {
+		below := outsideLower
+		if tick < tickLower {
+			below = sub256(global, outsideLower)
+		}
+		above := outsideUpper
+		if tick >= tickUpper {
+			above = sub256(global, outsideUpper)
+		}
+		return sub256(sub256(global, below), above)
+	}
+	return inside(global0[0], lower[2], upper[2]), inside(global1[0], lower[3], upper[3]), nil
+}
+
+// 未领取手续费 = tokensOwed + liquidity * (inside - insideLast) / 2^128
+func accruedFees(liquidity, inside, insideLast, owed *big.Int) *big.Int {
+	fees := new(big.Int).Mul(liquidity, sub256(inside, insideLast))
+	fees.Div(fees, q128)
+	return fees.Add(fees, owed)
+}
+
+// 根据流动性和价格计算仓位中的代币数量（原始精度）
+func positionAmounts(liquidity, sqrtPriceX96 *big.Int, tickLower, tickUpper int32) (float64, float64) {
+	l, _ := new(big.Float).SetInt(liquidity).Float64()
+	sp, _ := new(big.Float).Quo(new(big.Float).SetInt(sqrtPriceX96), q96).Float64()
+	sa := math.Pow(1.0001, float64(tickLower)/2)
+	sb := math.Pow(1.0001, float64(tickUpper)/2)
+	switch {
+	case sp <= sa:
+		return l * (sb - sa) / (sa * sb), 0
+	case sp < sb:
+		return l * (sb - sp) / (sp * sb), l * (sp - sa)
+	default:
+		return 0, l * (sb - sa)
+	}
+}
+
+// 执行 eth_call 并按 32 字节拆分返回值
+func ethCallWords(ctx context.Context, to, data string) ([]*big.Int, error) {
+	result, err := ethCall(ctx, to, data)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	words := make([]*big.Int, 0, len(raw)/32)
+	for i := 0; i+32 <= len(raw); i += 32 {
+		words = append(words, new(big.Int).SetBytes(raw[i:i+32]))
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty result from %s", to)
+	}
+	return words, nil
+}
+
+// ABI 编码单个整数参数，负数使用补码
+func encodeWord(v *big.Int) string {
+	if v.Sign() < 0 {
+		v = new(big.Int).Add(q256, v)
+	}
+	return fmt.Sprintf("%064x", v)
+}
+
+// 将补码表示的 256 位整数转换为有符号整数
+func signedWord(v *big.Int) *big.Int {
+	if v.Bit(255) == 1 {
+		return new(big.Int).Sub(v, q256)
+	}
+	return v
+}
+
+// 模 2^256 减法
+func sub256(a, b *big.Int) *big.Int {
+	r := new(big.Int).Sub(a, b)
+	return r.Mod(r, q256)
+}
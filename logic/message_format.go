@@ -0,0 +1,140 @@
+package logic
+
+import (
+	"fmt"
+	"html"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// 消息格式。同一份模板数据按渠道渲染为不同格式：Bark 为纯文本，Telegram 默认为 MarkdownV2，
+// HTML 供 Telegram 的 HTML 模式以及以后的邮件渠道使用
+const (
+	formatPlain    = "plain"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+)
+
+// 渠道使用的消息格式
+func channelFormat(channel string) string {
+	switch channel {
+	case channelTelegram:
+		return getTelegramConfig().Format
+	default:
+		return formatPlain
+	}
+}
+
+// 模板名后缀对应的格式，后缀可以是格式名或渠道名，没有后缀时为纯文本
+func templateFormat(name string) string {
+	_, suffix, _ := strings.Cut(name, ".")
+	switch suffix {
+	case formatPlain, formatMarkdown, formatHTML:
+		return suffix
+	case "":
+		return formatPlain
+	default:
+		return channelFormat(suffix)
+	}
+}
+
+// Telegram MarkdownV2 中需要转义的字符
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+	">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// 链接地址中只需要转义 ) 和 \
+var markdownURLEscaper = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+
+// 把纯文本转义为指定格式
+func escapeText(format, text string) string {
+	switch format {
+	case formatMarkdown:
+		return markdownEscaper.Replace(text)
+	case formatHTML:
+		return html.EscapeString(text)
+	default:
+		return text
+	}
+}
+
+// 各格式模板中的 escape 与 url 函数。模板中的每个输出都会自动经过 escape，
+// 以 raw 或 url 结尾的输出除外，例如 [交易]({{url .Links.Tx}})、{{raw .Receipt}}
+func formatFuncs(format string) template.FuncMap {
+	funcs := template.FuncMap{
+		"escape": func(v interface{}) string { return escapeText(format, toText(v)) },
+		"raw":    func(v interface{}) string { return toText(v) },
+		"url":    func(v interface{}) string { return toText(v) },
+	}
+	switch format {
+	case formatMarkdown:
+		funcs["url"] = func(v interface{}) string { return markdownURLEscaper.Replace(toText(v)) }
+	case formatHTML:
+		funcs["url"] = func(v interface{}) string { return html.EscapeString(toText(v)) }
+	}
+	return funcs
+}
+
+// 与模板直接输出一致：指针取值后输出，nil 输出为空
+func toText(v interface{}) string {
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || (rv.Kind() == reflect.Pointer && rv.IsNil()) {
+		return ""
+	}
+	return fmt.Sprint(rv.Interface())
+}
+
+// 解析模板，非纯文本格式时在每个输出动作末尾追加 escape
+func parseFormatTemplate(name, text string) (*template.Template, error) {
+	format := templateFormat(name)
+	tmpl, err := template.New(name).Funcs(templateFuncs).Funcs(formatFuncs(format)).Parse(text)
+	if err != nil || format == formatPlain {
+		return tmpl, err
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && t.Tree.Root != nil {
+			addEscaper(t.Tree, t.Tree.Root)
+		}
+	}
+	return tmpl, nil
+}
+
+func addEscaper(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			addEscaper(tree, child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) == 0 {
+			return
+		}
+		last := n.Pipe.Cmds[len(n.Pipe.Cmds)-1]
+		if ident, ok := last.Args[0].(*parse.IdentifierNode); ok && (ident.Ident == "raw" || ident.Ident == "url" || ident.Ident == "escape") {
+			return
+		}
+		escape := parse.NewIdentifier("escape").SetTree(tree).SetPos(n.Pos)
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{NodeType: parse.NodeCommand, Pos: n.Pos, Args: []parse.Node{escape}})
+	case *parse.IfNode:
+		addEscaper(tree, n.List)
+		addEscaper(tree, n.ElseList)
+	case *parse.WithNode:
+		addEscaper(tree, n.List)
+		addEscaper(tree, n.ElseList)
+	case *parse.RangeNode:
+		addEscaper(tree, n.List)
+		addEscaper(tree, n.ElseList)
+	}
+}
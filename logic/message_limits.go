@@ -0,0 +1,296 @@
+package logic
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// 各渠道的默认长度上限。Bark 为推送地址的总字节数（服务端默认的读缓冲为 4KB，需留出请求头的空间），
+// Telegram 为消息的 UTF-16 字符数
+var defaultChannelLimits = map[string]int{
+	channelBark:     3000,
+	channelTelegram: 4096,
+}
+
+// 截断时追加的省略号
+const truncationMark = "…"
+
+var messageLinkPattern = regexp.MustCompile(`https?://[^\s<>"')\]]+`)
+
+// 渠道的长度上限，templates.limits 中配置为负数时不限制
+func channelLimit(channel string) int {
+	if limit, ok := getTemplatesConfig().Limits[channel]; ok && limit != 0 {
+		return max(limit, 0)
+	}
+	return defaultChannelLimits[channel]
+}
+
+// Telegram 按 UTF-16 计算消息长度
+func telegramLength(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// 按上限截断消息，format 为消息格式，size 为渠道计算长度的方式，limit 为 0 时不截断。
+// 首个非空行（金额）和包含链接的行优先保留，其余行按顺序放入剩余空间，放不下的行优先在空白处截断，
+// 空白处都放不下时在字符间截断，截断处以省略号结尾。截断位置不会落在数字、链接、转义序列、HTML 标签或实体中间，
+// 截断时仍未闭合的格式（MarkdownV2 的强调与代码、HTML 标签）在省略号后补上闭合。
+// 格式标记需要在同一行内闭合，跨行的标记在丢弃后续行时不会补全
+func truncateMessage(text, format string, limit int, size func(string) int) string {
+	if limit <= 0 || size(text) <= limit {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	used := make([]bool, len(lines))
+	first := -1
+	for i, line := range lines {
+		if first < 0 && strings.TrimSpace(line) != "" {
+			first = i
+			used[i] = true
+		}
+		if messageLinkPattern.MatchString(line) {
+			used[i] = true
+		}
+	}
+	out := append([]string(nil), lines...)
+	render := func() string {
+		parts := make([]string, 0, len(out))
+		for i, line := range out {
+			if used[i] {
+				parts = append(parts, line)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	// 在 out[i] 处截断，使整条消息不超过上限
+	cutLine := func(i int) string {
+		return cutText(lines[i], format, func(s string) bool {
+			out[i] = s
+			return size(render()) <= limit
+		})
+	}
+
+	if first < 0 {
+		return cutText(text, format, func(s string) bool { return size(s) <= limit })
+	}
+	// 优先保留的行已经超出上限时截断首行，仍然放不下时放弃链接
+	if size(render()) > limit {
+		if out[first] = cutLine(first); out[first] != "" {
+			return render()
+		}
+		return cutText(lines[first], format, func(s string) bool { return size(s) <= limit })
+	}
+	// 其余行按顺序放入，第一条放不下的行截断后停止
+	for i := range lines {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		if size(render()) <= limit {
+			continue
+		}
+		out[i] = cutLine(i)
+		used[i] = out[i] != ""
+		break
+	}
+	return render()
+}
+
+// 在空白处截断并追加省略号，返回满足 fits 的最长结果；空白处都放不下时在字符间截断，仍放不下时返回空字符串
+func cutText(s, format string, fits func(string) bool) string {
+	runes := []rune(s)
+	points := cutPoints(format, runes)
+	for i := len(points) - 1; i >= 0; i-- {
+		p := points[i]
+		if unicode.IsSpace(runes[p.pos]) && !unicode.IsSpace(runes[p.pos-1]) {
+			if candidate := string(runes[:p.pos]) + truncationMark + p.suffix; fits(candidate) {
+				return candidate
+			}
+		}
+	}
+	links := linkRuneRanges(s)
+	for i := len(points) - 1; i >= 0; i-- {
+		p := points[i]
+		if insideNumber(runes, p.pos) || insideRange(links, p.pos) {
+			continue
+		}
+		if candidate := string(runes[:p.pos]) + truncationMark + p.suffix; fits(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// 截断位置，保留 runes[:pos]，省略号后追加 suffix 闭合仍未结束的格式
+type cutPoint struct {
+	pos    int
+	suffix string
+}
+
+// 按格式找出可以截断的位置：不在转义序列、MarkdownV2 链接、HTML 标签或实体中间，也不紧跟在开始标记之后
+func cutPoints(format string, runes []rune) []cutPoint {
+	switch format {
+	case formatMarkdown:
+		return markdownCutPoints(runes)
+	case formatHTML:
+		return htmlCutPoints(runes)
+	default:
+		points := make([]cutPoint, 0, len(runes))
+		for i := 1; i < len(runes); i++ {
+			points = append(points, cutPoint{pos: i})
+		}
+		return points
+	}
+}
+
+// MarkdownV2：\ 转义下一个字符，*、_、__、~、|| 成对出现，` 与 ``` 之间为代码，[文字](地址) 为链接
+func markdownCutPoints(runes []rune) []cutPoint {
+	var points []cutPoint
+	var open []string // 尚未闭合的标记
+	inLink, inURL, opened := false, false, false
+	hasPrefix := func(i int, marker string) bool {
+		return strings.HasPrefix(string(runes[i:min(i+len(marker), len(runes))]), marker)
+	}
+	toggle := func(marker string) {
+		if n := len(open); n > 0 && open[n-1] == marker {
+			open, opened = open[:n-1], false
+		} else {
+			open, opened = append(open, marker), true
+		}
+	}
+	inCode := func() bool { return len(open) > 0 && strings.HasPrefix(open[len(open)-1], "`") }
+	for i := 0; i < len(runes); {
+		if i > 0 && !inLink && !inURL && !opened {
+			points = append(points, cutPoint{pos: i, suffix: closingMarkers(open)})
+		}
+		r := runes[i]
+		opened = false
+		switch {
+		case r == '\\':
+			i += 2
+			continue
+		case inURL:
+			inURL = r != ')'
+		case inCode():
+			if marker := open[len(open)-1]; hasPrefix(i, marker) {
+				toggle(marker)
+				i += len(marker)
+				continue
+			}
+		case r == '`':
+			marker := "`"
+			if hasPrefix(i, "```") {
+				marker = "```"
+			}
+			toggle(marker)
+			i += len(marker)
+			continue
+		case r == '*' || r == '~':
+			toggle(string(r))
+		case r == '_' || r == '|':
+			marker := string(r)
+			if hasPrefix(i, marker+marker) {
+				marker += marker
+			}
+			if marker != "|" {
+				toggle(marker)
+			}
+			i += len(marker)
+			continue
+		case r == '[':
+			inLink = true
+		case r == ']' && inLink:
+			inLink = false
+			if hasPrefix(i, "](") {
+				inURL = true
+				i += 2
+				continue
+			}
+		}
+		i++
+	}
+	return points
+}
+
+// HTML：跳过标签与实体，仍未闭合的标签按相反顺序补上结束标签
+func htmlCutPoints(runes []rune) []cutPoint {
+	var points []cutPoint
+	var open []string
+	opened := false
+	for i := 0; i < len(runes); {
+		if i > 0 && !opened {
+			suffix := make([]string, len(open))
+			for j, tag := range open {
+				suffix[len(open)-1-j] = "</" + tag + ">"
+			}
+			points = append(points, cutPoint{pos: i, suffix: strings.Join(suffix, "")})
+		}
+		opened = false
+		switch runes[i] {
+		case '<':
+			end := slices.Index(runes[i:], '>')
+			if end < 0 {
+				return points
+			}
+			tag := string(runes[i+1 : i+end])
+			if name, ok := strings.CutPrefix(tag, "/"); ok {
+				if n := len(open); n > 0 && open[n-1] == strings.TrimSpace(name) {
+					open = open[:n-1]
+				}
+			} else if fields := strings.Fields(tag); len(fields) > 0 {
+				open, opened = append(open, fields[0]), true
+			}
+			i += end + 1
+		case '&':
+			if end := slices.Index(runes[i:min(i+12, len(runes))], ';'); end > 0 {
+				i += end + 1
+			} else {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return points
+}
+
+// 按相反顺序闭合 MarkdownV2 标记
+func closingMarkers(open []string) string {
+	var b strings.Builder
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString(open[i])
+	}
+	return b.String()
+}
+
+// 截断位置两侧是否都属于同一个数字，数字中的 . 和 , 以及 MarkdownV2 的转义符号视为数字的一部分
+func insideNumber(runes []rune, pos int) bool {
+	prev, next := runes[pos-1], runes[pos]
+	if next == '\\' && pos+1 < len(runes) {
+		next = runes[pos+1]
+	}
+	numeric := func(r rune) bool { return unicode.IsDigit(r) || r == '.' || r == ',' }
+	return numeric(prev) && numeric(next) && (unicode.IsDigit(prev) || unicode.IsDigit(next))
+}
+
+// 文本中链接的位置，按字符计
+func linkRuneRanges(s string) [][2]int {
+	var ranges [][2]int
+	for _, loc := range messageLinkPattern.FindAllStringIndex(s, -1) {
+		start := utf8.RuneCountInString(s[:loc[0]])
+		ranges = append(ranges, [2]int{start, start + utf8.RuneCountInString(s[loc[0]:loc[1]])})
+	}
+	return ranges
+}
+
+func insideRange(ranges [][2]int, pos int) bool {
+	for _, r := range ranges {
+		if r[0] < pos && pos < r[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateMessage(t *testing.T) {
+	swap := "*10\\-17 08:00*\n1,234\\.5 USDC \\-\\> 0\\.5 ETH\n[查看交易](https://etherscan\\.io/tx/0x1)"
+	tests := []struct {
+		name   string
+		format string
+		text   string
+		limit  int
+		want   string
+	}{
+		{"fits", formatPlain, "1,234.5 USDC", 20, "1,234.5 USDC"},
+		{"plain at space", formatPlain, "1,234.5 USDC -> 0.5 ETH", 15, "1,234.5 USDC…"},
+		{"plain keeps numbers", formatPlain, "1234567890", 6, ""},
+		{"markdown closes bold", formatMarkdown, "*10\\-17 08:00*", 10, "*10\\-17…*"},
+		{"markdown closes bold between characters", formatMarkdown, "*价格上涨百分之五*", 6, "*价格上…*"},
+		{"markdown keeps escapes", formatMarkdown, "abc\\.\\.\\.", 5, "abc…"},
+		{"markdown cuts middle line", formatMarkdown, swap, 62,
+			"*10\\-17 08:00*\n1,234\\.5…\n[查看交易](https://etherscan\\.io/tx/0x1)"},
+		{"markdown drops line instead of cutting number", formatMarkdown, swap, 58,
+			"*10\\-17 08:00*\n[查看交易](https://etherscan\\.io/tx/0x1)"},
+		{"html closes tags", formatHTML, "<b>价格上涨百分之五</b>", 12, "<b>价格上涨…</b>"},
+		{"html closes nested tags", formatHTML, "<b><i>价格上涨百分之五</i></b>", 17, "<b><i>价格…</i></b>"},
+		{"html keeps entities", formatHTML, "x&amp;&amp;&amp;", 8, "x&amp;…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateMessage(tt.text, tt.format, tt.limit, utf8.RuneCountInString)
+			if got != tt.want {
+				t.Errorf("truncateMessage() = %q, want %q", got, tt.want)
+			}
+			if utf8.RuneCountInString(got) > tt.limit {
+				t.Errorf("length %d exceeds limit %d", utf8.RuneCountInString(got), tt.limit)
+			}
+		})
+	}
+}
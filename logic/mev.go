@@ -0,0 +1,132 @@
+package logic
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MEV 标签
+const (
+	mevTagVictim   = "sandwich-victim"   // 被夹交易
+	mevTagFrontrun = "sandwich-frontrun" // 抢跑交易
+	mevTagBackrun  = "sandwich-backrun"  // 尾随交易
+)
+
+// MEV 检测配置
+type MEVConfig struct {
+	Enabled     bool `json:"enabled"`
+	BlockWindow int  `json:"blockWindow"` // 抢跑与尾随交易允许相隔的区块数，0 表示只检测同一区块
+}
+
+// 跨轮询保留最近区块的 Swap，用于检测相邻区块的夹子
+type mevDetector struct {
+	mu     sync.Mutex
+	recent []Swap
+}
+
+var mev = &mevDetector{}
+
+func getMEVConfig() MEVConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.MEV
+}
+
+// 检测本轮 Swap 中的夹子交易，返回交易哈希 -> 标签
+func detectMEV(swaps []Swap) map[string]string {
+	cfg := getMEVConfig()
+	if !cfg.Enabled || len(swaps) == 0 {
+		return nil
+	}
+	return mev.analyze(swaps, cfg.BlockWindow)
+}
+
+func (d *mevDetector) analyze(batch []Swap, window int) map[string]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var all []Swap
+	for _, s := range append(d.recent, batch...) {
+		if !seen[s.ID] {
+			seen[s.ID] = true
+			all = append(all, s)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		bi, bj := swapBlock(all[i]), swapBlock(all[j])
+		if bi != bj {
+			return bi < bj
+		}
+		return swapLogIndex(all[i]) < swapLogIndex(all[j])
+	})
+
+	tags := make(map[string]string)
+	for v := range all {
+		victim := all[v]
+		for f := 0; f < v; f++ {
+			front := all[f]
+			if front.TransactionHash == victim.TransactionHash || swapBuys(front) != swapBuys(victim) {
+				continue
+			}
+			for b := v + 1; b < len(all); b++ {
+				back := all[b]
+				if swapBlock(back)-swapBlock(front) > int64(window) {
+					break
+				}
+				if back.TransactionHash == victim.TransactionHash || back.TransactionHash == front.TransactionHash {
+					continue
+				}
+				if swapBuys(back) == swapBuys(front) || !sameActor(front, back) || sameActor(front, victim) {
+					continue
+				}
+				tags[victim.TransactionHash] = mevTagVictim
+				tags[front.TransactionHash] = mevTagFrontrun
+				tags[back.TransactionHash] = mevTagBackrun
+			}
+		}
+	}
+
+	// 只保留窗口内的最新区块
+	if len(all) > 0 {
+		latest := swapBlock(all[len(all)-1])
+		d.recent = d.recent[:0]
+		for _, s := range all {
+			if latest-swapBlock(s) <= int64(window) {
+				d.recent = append(d.recent, s)
+			}
+		}
+	}
+	return tags
+}
+
+func swapBlock(s Swap) int64 {
+	block, _ := strconv.ParseInt(s.BlockNumber, 10, 64)
+	return block
+}
+
+// 子图 ID 通常为 {txHash}-{logIndex} 或 {txHash}#{logIndex}
+func swapLogIndex(s Swap) int64 {
+	idx := strings.LastIndexAny(s.ID, "-#")
+	if idx < 0 {
+		return 0
+	}
+	logIndex, _ := strconv.ParseInt(s.ID[idx+1:], 10, 64)
+	return logIndex
+}
+
+// amount0 为负表示从池子买入 token0
+func swapBuys(s Swap) bool {
+	return strings.HasPrefix(s.Amount0, "-")
+}
+
+// 发送方或接收方相同视为同一操作者，公共路由合约不作为判断依据
+func sameActor(a, b Swap) bool {
+	return sameNonRouter(a.Sender, b.Sender) || sameNonRouter(a.Recipient, b.Recipient)
+}
+
+func sameNonRouter(a, b string) bool {
+	return strings.EqualFold(a, b) && !isRouterAddress(a)
+}
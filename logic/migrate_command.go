@@ -0,0 +1,135 @@
+package logic
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// RunMigrate 将配置文件中的处理进度、已处理交易、设置和 JSON Lines 历史记录迁移到数据库存储，并校验迁移结果
+func RunMigrate(args []string) error {
+	cfg := getStorageConfig()
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	to := fs.String("to", cfg.Driver, "目标存储：sqlite / bolt / redis / postgres，默认使用配置中的 storage.driver")
+	skipHistory := fs.Bool("skip-history", false, "不迁移 Swap 历史记录")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *to == storageDriverJSON {
+		return fmt.Errorf("target storage must not be %q, use --to or set storage.driver", storageDriverJSON)
+	}
+
+	cfg.Driver = *to
+	dst, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	src := &jsonStore{}
+	ctx, stop := commandContext()
+	defer stop()
+
+	// 处理进度
+	cursors, err := src.ListCursors(ctx)
+	if err != nil {
+		return err
+	}
+	for name, cursor := range cursors {
+		if err := dst.SaveCursor(ctx, name, cursor); err != nil {
+			return fmt.Errorf("save cursor %s: %w", name, err)
+		}
+	}
+
+	// 已处理交易，按首次处理时间分组写入
+	seen := legacySeenTxHashes()
+	byTime := make(map[int64][]string)
+	for hash, seenAt := range seen {
+		byTime[seenAt] = append(byTime[seenAt], hash)
+	}
+	for seenAt, hashes := range byTime {
+		if err := dst.MarkSeen(ctx, hashes, time.Unix(seenAt, 0)); err != nil {
+			return fmt.Errorf("mark seen: %w", err)
+		}
+	}
+
+	// 运行中修改的设置
+	settings, err := exportSettings(ctx, src)
+	if err != nil {
+		return err
+	}
+	if _, err := importSettings(ctx, dst, settings); err != nil {
+		return fmt.Errorf("save settings: %w", err)
+	}
+
+	// Swap 历史，目标中已有历史时跳过，避免重复执行时写入重复记录
+	migrated := 0
+	if !*skipHistory {
+		since, until := time.Unix(0, 0), time.Now().Add(time.Hour)
+		existing, err := dst.QuerySwaps(ctx, since, until)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			fmt.Printf("target already has %d swap records, skipping history\n", len(existing))
+		} else {
+			for _, record := range history.query(since, until) {
+				if err := dst.AppendSwap(ctx, record); err != nil {
+					return fmt.Errorf("append swap %s: %w", record.TxHash, err)
+				}
+				migrated++
+			}
+		}
+	}
+
+	if err := verifyMigration(ctx, dst, cursors, seen); err != nil {
+		return err
+	}
+	fmt.Printf("migrated %d cursors, %d seen transactions, %d swap records to %s\n", len(cursors), len(seen), migrated, *to)
+	return nil
+}
+
+// 配置文件中的已处理交易，旧版 currentTxHashes 视为当前时间处理
+func legacySeenTxHashes() map[string]int64 {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	seen := make(map[string]int64, len(configData.SeenTxHashes)+len(configData.CurrentTxHashes))
+	for hash, seenAt := range configData.SeenTxHashes {
+		seen[hash] = seenAt
+	}
+	now := time.Now().Unix()
+	for _, hash := range configData.CurrentTxHashes {
+		if _, ok := seen[hash]; !ok {
+			seen[hash] = now
+		}
+	}
+	return seen
+}
+
+// 校验处理进度和已处理交易是否完整写入目标存储
+func verifyMigration(ctx context.Context, dst Store, cursors map[string]Cursor, seen map[string]int64) error {
+	for name, cursor := range cursors {
+		migrated, found, err := dst.LoadCursor(ctx, name)
+		if err != nil {
+			return err
+		}
+		if !found || migrated.LastBlockNumber != cursor.LastBlockNumber {
+			return fmt.Errorf("cursor %s mismatch: want %s, got %s", name, cursor.LastBlockNumber, migrated.LastBlockNumber)
+		}
+	}
+
+	hashes := make([]string, 0, len(seen))
+	for hash := range seen {
+		hashes = append(hashes, hash)
+	}
+	found, err := dst.SeenTx(ctx, hashes)
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if !found[hash] {
+			return fmt.Errorf("seen transaction %s missing in target", hash)
+		}
+	}
+	return nil
+}
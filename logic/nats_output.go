@@ -0,0 +1,144 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATS JetStream 输出配置，每笔 Swap 发布到 <subjectPrefix>.<chain>.<pool>，修改后需要重启
+type NATSConfig struct {
+	URL           string `json:"url"`   // 例如 nats://127.0.0.1:4222，多个地址用逗号分隔，为空时不启用
+	Token         string `json:"token"` // 支持密钥引用
+	Username      string `json:"username"`
+	Password      string `json:"password"`      // 支持密钥引用
+	CredsFile     string `json:"credsFile"`     // NGS 等使用的凭据文件
+	Stream        string `json:"stream"`        // JetStream 流名称，默认 SWAPS，不存在时自动创建
+	SubjectPrefix string `json:"subjectPrefix"` // 默认 swaps
+	MaxAgeDays    int    `json:"maxAgeDays"`    // 流中消息保留天数，默认 7
+}
+
+func getNATSConfig() NATSConfig {
+	configMutex.RLock()
+	cfg := configData.NATS
+	configMutex.RUnlock()
+	cfg.URL = secretValue(cfg.URL)
+	cfg.Token = secretValue(cfg.Token)
+	cfg.Password = secretValue(cfg.Password)
+	if cfg.Stream == "" {
+		cfg.Stream = "SWAPS"
+	}
+	if cfg.SubjectPrefix == "" {
+		cfg.SubjectPrefix = "swaps"
+	}
+	if cfg.MaxAgeDays <= 0 {
+		cfg.MaxAgeDays = 7
+	}
+	return cfg
+}
+
+var (
+	natsConn      *nats.Conn
+	natsJetStream jetstream.JetStream
+)
+
+// 连接 NATS 并确保流存在，连接断开后由客户端自动重连
+func startNATSOutput() {
+	cfg := getNATSConfig()
+	if cfg.URL == "" {
+		return
+	}
+	options := []nats.Option{nats.Name("message-push"), nats.MaxReconnects(-1), nats.RetryOnFailedConnect(true)}
+	switch {
+	case cfg.CredsFile != "":
+		options = append(options, nats.UserCredentials(cfg.CredsFile))
+	case cfg.Token != "":
+		options = append(options, nats.Token(cfg.Token))
+	case cfg.Username != "":
+		options = append(options, nats.UserInfo(cfg.Username, cfg.Password))
+	}
+	conn, err := nats.Connect(cfg.URL, options...)
+	if err != nil {
+		slog.Error("Failed to connect to nats", "error", err)
+		return
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		slog.Error("Failed to create jetstream context", "error", err)
+		conn.Close()
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       cfg.Stream,
+		Subjects:   []string{cfg.SubjectPrefix + ".>"},
+		Storage:    jetstream.FileStorage,
+		MaxAge:     time.Duration(cfg.MaxAgeDays) * 24 * time.Hour,
+		Duplicates: time.Hour,
+	})
+	if err != nil {
+		// 流可能由运维预先创建且没有修改权限，仍然尝试发布
+		slog.Warn("Failed to create or update jetstream stream", "stream", cfg.Stream, "error", err)
+	}
+	natsConn, natsJetStream = conn, js
+	slog.Info("Publishing swaps to nats", "stream", cfg.Stream, "subject", natsSubject(cfg))
+}
+
+func stopNATSOutput() {
+	if natsConn == nil {
+		return
+	}
+	if err := natsConn.Drain(); err != nil {
+		natsConn.Close()
+	}
+	natsConn, natsJetStream = nil, nil
+}
+
+// 按链和池子区分主题，订阅方可以用 swaps.> 或 swaps.<chain>.* 过滤
+func natsSubject(cfg NATSConfig) string {
+	chain := getExplorerConfig().Chain
+	if chain == "" {
+		chain = "unknown"
+	}
+	pool := strings.ToLower(getPoolConfig().Address)
+	if pool == "" {
+		pool = "default"
+	}
+	return cfg.SubjectPrefix + "." + chain + "." + pool
+}
+
+// 异步发布，以交易哈希作为消息 ID，JetStream 在 duplicates 窗口内去重
+func publishNATS(ctx context.Context, event *SwapEvent) {
+	js := natsJetStream
+	if js == nil {
+		return
+	}
+	cfg := getNATSConfig()
+	data, err := json.Marshal(newSwapFeedEvent(event))
+	if err != nil {
+		slog.Error("Failed to encode swap for nats", "error", err)
+		return
+	}
+	subject := natsSubject(cfg)
+	future, err := js.PublishAsync(subject, data, jetstream.WithMsgID(event.Swap.TransactionHash))
+	if err != nil {
+		recordChannelResult("nats", subject, time.Now(), err)
+		slog.Error("Failed to publish swap to nats", "transactionHash", event.Swap.TransactionHash, "error", err)
+		return
+	}
+	go func() {
+		select {
+		case <-future.Ok():
+			recordChannelResult("nats", subject, time.Now(), nil)
+		case err := <-future.Err():
+			recordChannelResult("nats", subject, time.Now(), err)
+			slog.Error("Failed to publish swap to nats", "transactionHash", event.Swap.TransactionHash, "error", err)
+		}
+	}()
+}
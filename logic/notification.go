@@ -0,0 +1,76 @@
+package logic
+
+import "strings"
+
+// 结构化的通知，各渠道按自身能力映射字段：Bark 对应标题、副标题、正文、点击链接和图片，
+// Telegram 将加粗的标题、副标题和正文拼成一条消息，Alertmanager 以标题为 summary、正文为 description
+type notification struct {
+	Title    string
+	Subtitle string
+	Body     string
+	URL      string
+	Image    string
+}
+
+// 标题与副标题模板的后缀，例如 swap_title
+const (
+	titleSuffix    = "_title"
+	subtitleSuffix = "_subtitle"
+)
+
+// 内置的 Swap 标题与副标题，例如 "🐋🟢 1.20000 WBTC → UNIBTC" 和 "$120000.00 @ 1.00010"
+const (
+	defaultSwapTitleTemplate    = `{{with .Emoji}}{{.}} {{end}}{{amount .AmountIn}} {{.TokenIn}} → {{.TokenOut}}`
+	defaultSwapSubtitleTemplate = `{{usd .VolumeUSD}}{{with .Price}} @ {{price .}}{{end}}`
+)
+
+// 按渠道渲染通知的标题、副标题和正文，内容均为渠道使用的格式
+func renderNotification(kind, channel string, data interface{}) notification {
+	if swap, ok := data.(swapTemplateData); ok {
+		data = swap.withEmoji(channel)
+	}
+	title, _ := renderTemplate(kind+titleSuffix, channel, data)
+	subtitle, _ := renderTemplate(kind+subtitleSuffix, channel, data)
+	return notification{
+		Title:    strings.TrimSpace(title),
+		Subtitle: strings.TrimSpace(subtitle),
+		Body:     renderMessage(kind, channel, data),
+	}
+}
+
+// Bark 推送选项，配置中已指定的标题（例如大额交易分级的标题）优先
+func (n notification) barkOptions(opts barkOptions) barkOptions {
+	if opts.Title == "" {
+		opts.Title = n.Title
+	}
+	if opts.Subtitle == "" {
+		opts.Subtitle = n.Subtitle
+	}
+	if opts.URL == "" {
+		opts.URL = n.URL
+	}
+	if opts.Image == "" {
+		opts.Image = n.Image
+	}
+	return opts
+}
+
+// 拼成一条 Telegram 消息，标题按消息格式加粗。各字段已按 format 格式化
+func (n notification) telegramText(format string) string {
+	title := n.Title
+	if title != "" {
+		switch format {
+		case formatMarkdown:
+			title = "*" + title + "*"
+		case formatHTML:
+			title = "<b>" + title + "</b>"
+		}
+	}
+	var parts []string
+	for _, part := range []string{title, n.Subtitle, n.Body} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,143 @@
+package logic
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 通知中的数字格式。小数位为空时使用默认值，0 表示不保留小数
+type NumberFormatConfig struct {
+	AmountDecimals     *int   `json:"amountDecimals"`     // 代币数量的小数位，默认 5
+	AmountSignificant  int    `json:"amountSignificant"`  // 大于 0 时代币数量按有效数字输出，忽略 amountDecimals
+	USDDecimals        *int   `json:"usdDecimals"`        // 美元金额的小数位，默认 2
+	PriceDecimals      *int   `json:"priceDecimals"`      // 价格的小数位，默认 5
+	ThousandsSeparator string `json:"thousandsSeparator"` // 千位分隔符，例如 ","，默认不分隔
+	CompactDigest      bool   `json:"compactDigest"`      // 汇总中的美元金额使用紧凑格式，例如 $1.2M
+}
+
+// 解析默认值后的数字格式
+type numberFormat struct {
+	amountDecimals    int
+	amountSignificant int
+	usdDecimals       int
+	priceDecimals     int
+	separator         string
+	compactDigest     bool
+}
+
+func getNumberFormat() numberFormat {
+	configMutex.RLock()
+	cfg := configData.Numbers
+	configMutex.RUnlock()
+	decimals := func(v *int, def int) int {
+		if v == nil || *v < 0 {
+			return def
+		}
+		return min(*v, 18)
+	}
+	return numberFormat{
+		amountDecimals:    decimals(cfg.AmountDecimals, 5),
+		amountSignificant: min(max(cfg.AmountSignificant, 0), 17),
+		usdDecimals:       decimals(cfg.USDDecimals, 2),
+		priceDecimals:     decimals(cfg.PriceDecimals, 5),
+		separator:         cfg.ThousandsSeparator,
+		compactDigest:     cfg.CompactDigest,
+	}
+}
+
+// 代币数量
+func formatAmount(v float64) string {
+	f := getNumberFormat()
+	if f.amountSignificant > 0 {
+		return groupThousands(significant(v, f.amountSignificant), f.separator)
+	}
+	return groupThousands(strconv.FormatFloat(v, 'f', f.amountDecimals, 64), f.separator)
+}
+
+// 美元金额，带 $ 前缀
+func formatUSD(v float64) string {
+	f := getNumberFormat()
+	return signedUSD(v, groupThousands(strconv.FormatFloat(math.Abs(v), 'f', f.usdDecimals, 64), f.separator))
+}
+
+// 价格
+func formatPrice(v float64) string {
+	f := getNumberFormat()
+	return groupThousands(strconv.FormatFloat(v, 'f', f.priceDecimals, 64), f.separator)
+}
+
+// 汇总中的美元金额，默认取整，开启 compactDigest 时使用紧凑格式
+func formatDigestUSD(v float64) string {
+	f := getNumberFormat()
+	if f.compactDigest {
+		return compactUSD(v)
+	}
+	return signedUSD(v, groupThousands(strconv.FormatFloat(math.Abs(v), 'f', 0, 64), f.separator))
+}
+
+// 紧凑格式的美元金额，例如 $950、$12K、$1.2M、$3.4B
+func compactUSD(v float64) string {
+	abs := math.Abs(v)
+	if math.Round(abs) < 1000 {
+		return signedUSD(v, strconv.FormatFloat(abs, 'f', 0, 64))
+	}
+	units := []struct {
+		size   float64
+		suffix string
+	}{{1e3, "K"}, {1e6, "M"}, {1e9, "B"}, {1e12, "T"}}
+	for i, unit := range units {
+		// 按四舍五入后的值选择单位，999950 显示为 $1M 而不是 $1000K
+		rounded := math.Round(abs/unit.size*10) / 10
+		if rounded < 1000 || i == len(units)-1 {
+			return signedUSD(v, strings.TrimSuffix(strconv.FormatFloat(rounded, 'f', 1, 64), ".0")+unit.suffix)
+		}
+	}
+	return ""
+}
+
+func signedUSD(v float64, digits string) string {
+	if v < 0 {
+		return "-$" + digits
+	}
+	return "$" + digits
+}
+
+// 保留指定有效数字，不使用科学计数法
+func significant(v float64, digits int) string {
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', digits, 64), 64)
+	if err != nil {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// 为整数部分添加千位分隔符
+func groupThousands(number, separator string) string {
+	if separator == "" {
+		return number
+	}
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(number, ".")
+	if len(integer) <= 3 {
+		return sign + number
+	}
+	var b strings.Builder
+	head := len(integer) % 3
+	if head > 0 {
+		b.WriteString(integer[:head])
+	}
+	for i := head; i < len(integer); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(separator)
+		}
+		b.WriteString(integer[i : i+3])
+	}
+	if hasFraction {
+		b.WriteString("." + fraction)
+	}
+	return sign + b.String()
+}
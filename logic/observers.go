@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// 观察者会收到每一笔新的 Swap（按时间正序），用于价格跟踪、统计等不直接产生单笔通知的功能
+type swapObserver func(ctx context.Context, event *SwapEvent)
+
+// 已注册的观察者
+var swapObservers = []swapObserver{
+	recordSwap,
+	recordPricePoint,
+	observeDepeg,
+	observeVolumeSpike,
+	observePrice,
+	observeArbitrage,
+	observeTVLPrice,
+	publishSwapFeed,
+	publishKafka,
+	publishNATS,
+	publishRedis,
+	publishAMQP,
+	publishHomeAssistant,
+}
+
+// 有上限的哈希集合，超过上限时淘汰最早记录的
+type observedHashes struct {
+	mu    sync.Mutex
+	set   map[string]bool
+	order []string
+	limit int
+}
+
+// 最近交给观察者的交易哈希。通知失败或超时时进度不前进，同一批交易会在下一轮重新获取，
+// 已观察过的交易不再交给观察者，避免重复记录历史和重复发布
+var observed = &observedHashes{set: make(map[string]bool), limit: 10000}
+
+// 记录哈希，已记录过时返回 false。超过上限时淘汰最早的
+func (o *observedHashes) add(hash string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.set[hash] {
+		return false
+	}
+	o.set[hash] = true
+	o.order = append(o.order, hash)
+	if len(o.order) > o.limit {
+		delete(o.set, o.order[0])
+		o.order = o.order[1:]
+	}
+	return true
+}
+
+// 是否已记录过
+func (o *observedHashes) has(hash string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.set[hash]
+}
+
+// 将新的 Swap 按时间正序交给所有观察者，每笔交易只观察一次
+func observeSwaps(ctx context.Context, swaps []Swap, seen map[string]bool) {
+	for i := len(swaps) - 1; i >= 0; i-- {
+		if seen[swaps[i].TransactionHash] || !observed.add(swaps[i].TransactionHash) {
+			continue
+		}
+		event, err := normalizeSwap(&swaps[i])
+		if err != nil {
+			slog.Debug("Skip observing swap", "transactionHash", swaps[i].TransactionHash, "error", err)
+			continue
+		}
+		for _, observe := range swapObservers {
+			observe(ctx, event)
+		}
+	}
+}
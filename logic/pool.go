@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ERC20 与 Uniswap V3 池子的方法选择器
+const (
+	selectorToken0    = "0x0dfe1681" // token0()
+	selectorToken1    = "0xd21220a7" // token1()
+	selectorLiquidity = "0x1a686502" // liquidity()
+	selectorBalanceOf = "0x70a08231" // balanceOf(address)
+)
+
+// 监控的池子配置
+type PoolConfig struct {
+	Address string `json:"address"` // 池子合约地址
+}
+
+// 池子的代币地址，首次查询后缓存
+type poolTokens struct {
+	Token0 string
+	Token1 string
+}
+
+var poolTokenCache = newLookupCache[poolTokens]("pool_tokens", true)
+
+func getPoolConfig() PoolConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Pool
+}
+
+// 查询池子的 token0 和 token1 地址
+func getPoolTokens(ctx context.Context, pool string) (poolTokens, error) {
+	pool = strings.ToLower(pool)
+	return poolTokenCache.get(ctx, pool, tokenMetadataTTL(), func() (poolTokens, error) {
+		var tokens poolTokens
+		result, err := ethCall(ctx, pool, selectorToken0)
+		if err != nil {
+			return tokens, err
+		}
+		if tokens.Token0, err = decodeAddress(result); err != nil {
+			return tokens, err
+		}
+		result, err = ethCall(ctx, pool, selectorToken1)
+		if err != nil {
+			return tokens, err
+		}
+		if tokens.Token1, err = decodeAddress(result); err != nil {
+			return tokens, err
+		}
+		return tokens, nil
+	})
+}
+
+// 查询 ERC20 余额
+func tokenBalanceOf(ctx context.Context, token, owner string) (*big.Int, error) {
+	data := selectorBalanceOf + fmt.Sprintf("%064s", strings.TrimPrefix(strings.ToLower(owner), "0x"))
+	result, err := ethCall(ctx, token, data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeUint(result)
+}
+
+// 查询池子当前的流动性
+func poolLiquidity(ctx context.Context, pool string) (*big.Int, error) {
+	result, err := ethCall(ctx, pool, selectorLiquidity)
+	if err != nil {
+		return nil, err
+	}
+	return decodeUint(result)
+}
+
+// 解析 ABI 编码的 uint 返回值
+func decodeUint(result string) (*big.Int, error) {
+	raw := strings.TrimPrefix(result, "0x")
+	if raw == "" {
+		return nil, fmt.Errorf("empty result")
+	}
+	if len(raw) > 64 {
+		raw = raw[:64]
+	}
+	value, ok := new(big.Int).SetString(raw, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid uint result %q", result)
+	}
+	return value, nil
+}
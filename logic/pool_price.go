@@ -0,0 +1,51 @@
+package logic
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// 计算精度，sqrtPriceX96 平方后超过 float64 精度
+const pricePrec = 256
+
+// 2^96
+var q96 = new(big.Float).SetPrec(pricePrec).SetMantExp(big.NewFloat(1), 96)
+
+var bigOne = big.NewFloat(1)
+
+// 将 sqrtPriceX96 转换为价格（token1 / token0）。price = sqrtPriceX96² / 2^192，除以 2 的幂只调整指数，结果与除法相同
+func sqrtPriceX96ToPrice(sqrtPriceX96 *big.Float) *big.Float {
+	price := new(big.Float).SetPrec(pricePrec).Mul(sqrtPriceX96, sqrtPriceX96)
+	return price.SetMantExp(price, -192)
+}
+
+// 解析 Swap 中的价格字段，计算交易后池子价格和价格影响
+// 池子在当前 tick 区间内满足 Δ√P = Δy / L，可由交易后价格和 token1 变化量反推交易前价格
+func computePoolPrice(swap *Swap) (price, impact *big.Float, err error) {
+	sqrtPriceAfter, ok := new(big.Float).SetPrec(pricePrec).SetString(swap.SqrtPriceX96)
+	if !ok || sqrtPriceAfter.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("invalid sqrtPriceX96 %q", swap.SqrtPriceX96)
+	}
+	price = sqrtPriceX96ToPrice(sqrtPriceAfter)
+
+	liquidity, ok := new(big.Float).SetPrec(pricePrec).SetString(swap.Liquidity)
+	if !ok || liquidity.Sign() <= 0 {
+		return price, nil, fmt.Errorf("invalid liquidity %q", swap.Liquidity)
+	}
+	amount1, ok := new(big.Float).SetPrec(pricePrec).SetString(swap.Amount1)
+	if !ok {
+		return price, nil, fmt.Errorf("invalid amount1 %q", swap.Amount1)
+	}
+
+	delta := new(big.Float).SetPrec(pricePrec).SetMantExp(amount1, 96)
+	delta.Quo(delta, liquidity)
+	sqrtPriceBefore := new(big.Float).SetPrec(pricePrec).Sub(sqrtPriceAfter, delta)
+	if sqrtPriceBefore.Sign() <= 0 {
+		return price, nil, fmt.Errorf("swap crossed initialized ticks, cannot estimate impact")
+	}
+	priceBefore := sqrtPriceX96ToPrice(sqrtPriceBefore)
+
+	impact = new(big.Float).SetPrec(pricePrec).Quo(price, priceBefore)
+	impact.Sub(impact, bigOne)
+	return price, impact.Abs(impact), nil
+}
@@ -0,0 +1,159 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// 池子 TVL 监控配置
+type TVLConfig struct {
+	Enabled         bool    `json:"enabled"`
+	IntervalSeconds int     `json:"intervalSeconds"` // 查询间隔
+	ChangePct       float64 `json:"changePct"`       // 窗口内 TVL 变化超过该百分比时告警
+	WindowMinutes   int     `json:"windowMinutes"`   // 变化统计窗口
+	CooldownMinutes int     `json:"cooldownMinutes"` // 告警最小间隔
+}
+
+// TVL 采样点
+type tvlSample struct {
+	Time      time.Time
+	TVL       float64 // 以 BTC 计
+	Liquidity *big.Int
+}
+
+// TVL 监控状态
+type tvlTracker struct {
+	mu          sync.Mutex
+	samples     []tvlSample
+	btcPrice    float64 // 最近一笔 Swap 的 BTC 价格，用于换算美元
+	lastAlerted time.Time
+}
+
+var poolTVL = &tvlTracker{}
+
+func getTVLConfig() TVLConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.TVL
+}
+
+// PoolTVLTask 查询池子 TVL 并检查变化
+func PoolTVLTask(ctx context.Context) error {
+	cfg := getTVLConfig()
+	pool := getPoolConfig().Address
+	if !cfg.Enabled || pool == "" {
+		return nil
+	}
+
+	sample, err := queryPoolTVL(ctx, pool)
+	if err != nil {
+		return err
+	}
+	if message := poolTVL.observe(cfg, sample); !message.empty() {
+		slog.Warn("Pool TVL alert", "message", message)
+		sendAlert(ctx, message, barkLevelTimeSensitive)
+	}
+	return nil
+}
+
+// 查询池子的代币余额与流动性
+func queryPoolTVL(ctx context.Context, pool string) (tvlSample, error) {
+	sample := tvlSample{Time: time.Now()}
+	tokens, err := getPoolTokens(ctx, pool)
+	if err != nil {
+		return sample, err
+	}
+	balance0, err := tokenBalanceOf(ctx, tokens.Token0, pool)
+	if err != nil {
+		return sample, err
+	}
+	balance1, err := tokenBalanceOf(ctx, tokens.Token1, pool)
+	if err != nil {
+		return sample, err
+	}
+	if sample.Liquidity, err = poolLiquidity(ctx, pool); err != nil {
+		return sample, err
+	}
+
+	// 池子中两种代币都锚定 BTC，直接相加得到以 BTC 计的 TVL
+	token0, token1 := getPoolTokenMeta()
+	sample.TVL = tokenUnitsFloat(balance0, token0.Decimals) + tokenUnitsFloat(balance1, token1.Decimals)
+	return sample, nil
+}
+
+// 记录采样并返回告警内容
+func (t *tvlTracker) observe(cfg TVLConfig, sample tvlSample) localizedText {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	window := time.Duration(cfg.WindowMinutes) * time.Minute
+	if window <= 0 {
+		window = time.Hour
+	}
+	cutoff := sample.Time.Add(-window)
+	kept := t.samples[:0]
+	for _, s := range t.samples {
+		if !s.Time.Before(cutoff) {
+			kept = append(kept, s)
+		}
+	}
+	t.samples = append(kept, sample)
+
+	first := t.samples[0]
+	if cfg.ChangePct <= 0 || first.TVL == 0 {
+		return localizedText{}
+	}
+	changePct := (sample.TVL/first.TVL - 1) * 100
+	cooldown := time.Duration(cfg.CooldownMinutes) * time.Minute
+	if cooldown <= 0 {
+		cooldown = time.Hour
+	}
+	if math.Abs(changePct) <= cfg.ChangePct || sample.Time.Sub(t.lastAlerted) < cooldown {
+		return localizedText{}
+	}
+	t.lastAlerted = sample.Time
+
+	action := i18nText("alert.tvlUp")
+	if changePct < 0 {
+		action = i18nText("alert.tvlDown")
+	}
+	return i18nText("alert.tvlChange",
+		sample.Time.Sub(first.Time).Round(time.Minute).String(), action, math.Abs(changePct), first.TVL, sample.TVL, t.usdSuffix(sample.TVL))
+}
+
+// 美元换算，没有 BTC 价格时返回空文本
+func (t *tvlTracker) usdSuffix(tvl float64) localizedText {
+	if t.btcPrice <= 0 {
+		return localizedText{}
+	}
+	return i18nText("tvl.usd", tvl*t.btcPrice)
+}
+
+// 观察每笔 Swap 的 BTC 价格
+func observeTVLPrice(ctx context.Context, event *SwapEvent) {
+	btcPrice := newConditionEnv(event).BtcPrice
+	if btcPrice <= 0 {
+		return
+	}
+	poolTVL.mu.Lock()
+	poolTVL.btcPrice = btcPrice
+	poolTVL.mu.Unlock()
+}
+
+// 最新的池子深度，用于附加在 Swap 告警中，没有数据时返回空文本
+func latestTVLSummary() localizedText {
+	if !getTVLConfig().Enabled {
+		return localizedText{}
+	}
+	poolTVL.mu.Lock()
+	defer poolTVL.mu.Unlock()
+	if len(poolTVL.samples) == 0 {
+		return localizedText{}
+	}
+	latest := poolTVL.samples[len(poolTVL.samples)-1]
+	return i18nText("tvl.summary", latest.TVL, poolTVL.usdSuffix(latest.TVL))
+}
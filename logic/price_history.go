@@ -0,0 +1,119 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+)
+
+// 价格历史来源
+const (
+	priceSourceSwap = "swap" // 来自 Swap 事件
+	priceSourcePoll = "poll" // 来自定时查询池子 slot0
+)
+
+// 价格历史配置
+type PriceHistoryConfig struct {
+	Path          string `json:"path"`          // 价格历史文件（JSON Lines），为空时只保存在内存中
+	RetentionDays int    `json:"retentionDays"` // 保留天数
+	PollSeconds   int    `json:"pollSeconds"`   // 定时查询池子价格的间隔，0 表示不查询
+}
+
+// 价格采样记录
+type pricePointRecord struct {
+	Time   time.Time `json:"time"`
+	Price  float64   `json:"price"` // token1 / token0
+	Tick   int32     `json:"tick"`
+	Block  int64     `json:"block,omitempty"`
+	Source string    `json:"source"`
+}
+
+func (r pricePointRecord) timestamp() time.Time { return r.Time }
+
+// 价格历史
+var priceHistory = newTimeSeries[pricePointRecord](func() (string, time.Duration) {
+	cfg := getPriceHistoryConfig()
+	return cfg.Path, time.Duration(cfg.RetentionDays) * 24 * time.Hour
+})
+
+func getPriceHistoryConfig() PriceHistoryConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.PriceHistory
+	if cfg.RetentionDays <= 0 {
+		cfg.RetentionDays = 30
+	}
+	return cfg
+}
+
+// 观察每笔 Swap 的价格
+func recordPricePoint(ctx context.Context, event *SwapEvent) {
+	if event.PoolPrice == nil {
+		return
+	}
+	price := decimalFloat(*event.PoolPrice)
+	priceHistory.append(pricePointRecord{
+		Time:   event.Time,
+		Price:  price,
+		Tick:   event.Swap.Tick,
+		Block:  int64(newConditionEnv(event).Block),
+		Source: priceSourceSwap,
+	})
+}
+
+// PricePollTask 定时查询池子当前价格
+func PricePollTask(ctx context.Context) error {
+	pool := getPoolConfig().Address
+	if pool == "" {
+		return nil
+	}
+	slot0, err := ethCallWords(ctx, pool, selectorSlot0)
+	if err != nil {
+		return err
+	}
+	if len(slot0) < 2 {
+		return fmt.Errorf("unexpected slot0 result")
+	}
+	price, _ := sqrtPriceX96ToPrice(new(big.Float).SetInt(slot0[0])).Float64()
+	priceHistory.append(pricePointRecord{
+		Time:   time.Now(),
+		Price:  price,
+		Tick:   int32(signedWord(slot0[1]).Int64()),
+		Source: priceSourcePoll,
+	})
+	return nil
+}
+
+// RunPriceQuery 查询价格历史，输出 JSON Lines 或 CSV
+func RunPriceQuery(args []string) error {
+	fs := flag.NewFlagSet("prices", flag.ContinueOnError)
+	since := fs.Duration("since", 24*time.Hour, "查询最近多长时间的价格")
+	format := fs.String("format", "jsonl", "输出格式：jsonl / csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	records := priceHistory.query(now.Add(-*since), now.Add(time.Second))
+	switch *format {
+	case "csv":
+		fmt.Println("time,price,tick,block,source")
+		for _, r := range records {
+			fmt.Printf("%s,%.8f,%d,%d,%s\n", r.Time.Format(time.RFC3339), r.Price, r.Tick, r.Block, r.Source)
+		}
+	case "jsonl":
+		encoder := json.NewEncoder(os.Stdout)
+		for _, r := range records {
+			if err := encoder.Encode(r); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// 均线类型
+const (
+	maTypeSMA = "sma"
+	maTypeEMA = "ema"
+)
+
+// 价格告警配置
+type PriceAlertConfig struct {
+	Enabled       bool        `json:"enabled"`
+	SampleMinutes int         `json:"sampleMinutes"` // K 线周期（分钟），以周期收盘价计算均线
+	MAType        string      `json:"maType"`        // sma / ema
+	FastPeriod    int         `json:"fastPeriod"`    // 快线周期数
+	SlowPeriod    int         `json:"slowPeriod"`    // 慢线周期数
+	Bands         []PriceBand `json:"bands"`         // 价格区间，进入或离开时告警
+}
+
+// 价格区间
+type PriceBand struct {
+	Name  string  `json:"name"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// 价格跟踪状态
+type priceTracker struct {
+	mu         sync.Mutex
+	period     int64           // 当前周期序号
+	last       float64         // 当前周期最新价格
+	closes     []float64       // 已收盘周期的收盘价
+	fastAbove  *bool           // 上一次快线是否在慢线之上
+	insideBand map[string]bool // 各区间当前是否处于区间内
+	emas       map[int]float64 // 周期数 -> EMA 值
+	emaCount   map[int]int     // 周期数 -> 参与 EMA 计算的样本数
+}
+
+var prices = &priceTracker{
+	insideBand: make(map[string]bool),
+	emas:       make(map[int]float64),
+	emaCount:   make(map[int]int),
+}
+
+func getPriceAlertConfig() PriceAlertConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.PriceAlert
+}
+
+// 观察每笔 Swap 的池子价格
+func observePrice(ctx context.Context, event *SwapEvent) {
+	cfg := getPriceAlertConfig()
+	if !cfg.Enabled {
+		return
+	}
+	price := event.PoolPrice
+	if price == nil {
+		price = event.ExecutionPrice
+	}
+	if price == nil {
+		return
+	}
+	p, _ := price.Float64()
+	for _, message := range prices.observe(cfg, event.Time, p) {
+		slog.Info("Price alert", "message", message)
+		sendAlert(ctx, message, barkLevelTimeSensitive)
+	}
+}
+
+// 记录价格并返回需要发送的告警
+func (t *priceTracker) observe(cfg PriceAlertConfig, ts time.Time, price float64) []localizedText {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var alerts []localizedText
+	alerts = append(alerts, t.checkBands(cfg, price)...)
+
+	sampleMinutes := cfg.SampleMinutes
+	if sampleMinutes <= 0 {
+		sampleMinutes = 5
+	}
+	period := ts.Unix() / int64(sampleMinutes*60)
+	if t.period != 0 && period > t.period {
+		// 上一个周期收盘
+		if message := t.closePeriod(cfg, t.last); !message.empty() {
+			alerts = append(alerts, message)
+		}
+	}
+	if period >= t.period {
+		t.period = period
+		t.last = price
+	}
+	return alerts
+}
+
+// 周期收盘时更新均线并检查交叉
+func (t *priceTracker) closePeriod(cfg PriceAlertConfig, closePrice float64) localizedText {
+	fastPeriod, slowPeriod := cfg.FastPeriod, cfg.SlowPeriod
+	if fastPeriod <= 0 {
+		fastPeriod = 12
+	}
+	if slowPeriod <= fastPeriod {
+		slowPeriod = fastPeriod * 2
+	}
+
+	t.closes = append(t.closes, closePrice)
+	if len(t.closes) > slowPeriod {
+		t.closes = t.closes[len(t.closes)-slowPeriod:]
+	}
+
+	var fast, slow float64
+	var ok bool
+	if cfg.MAType == maTypeEMA {
+		fast, _ = t.updateEMA(fastPeriod, closePrice)
+		slow, ok = t.updateEMA(slowPeriod, closePrice)
+	} else {
+		fast, _ = sma(t.closes, fastPeriod)
+		slow, ok = sma(t.closes, slowPeriod)
+	}
+	if !ok {
+		return localizedText{}
+	}
+
+	above := fast > slow
+	defer func() { t.fastAbove = &above }()
+	if t.fastAbove == nil || *t.fastAbove == above {
+		return localizedText{}
+	}
+	cross := i18nText("alert.deathCross")
+	if above {
+		cross = i18nText("alert.goldenCross")
+	}
+	token0, _ := getPoolTokenMeta()
+	return i18nText("alert.maCross",
+		token0.Symbol, cross, cfg.MAType, fastPeriod, fast, cfg.MAType, slowPeriod, slow, closePrice)
+}
+
+// 更新 EMA，样本数不足周期数时返回 false
+func (t *priceTracker) updateEMA(period int, price float64) (float64, bool) {
+	k := 2 / float64(period+1)
+	if t.emaCount[period] == 0 {
+		t.emas[period] = price
+	} else {
+		t.emas[period] = price*k + t.emas[period]*(1-k)
+	}
+	t.emaCount[period]++
+	return t.emas[period], t.emaCount[period] >= period
+}
+
+// 计算最近 period 个收盘价的简单均线，样本数不足时返回 false
+func sma(closes []float64, period int) (float64, bool) {
+	if len(closes) < period {
+		return 0, false
+	}
+	var sum float64
+	for _, c := range closes[len(closes)-period:] {
+		sum += c
+	}
+	return sum / float64(period), true
+}
+
+// 检查价格是否进入或离开区间
+func (t *priceTracker) checkBands(cfg PriceAlertConfig, price float64) []localizedText {
+	var alerts []localizedText
+	for _, band := range cfg.Bands {
+		inside := price >= band.Lower && price <= band.Upper
+		prev, known := t.insideBand[band.Name]
+		t.insideBand[band.Name] = inside
+		if !known || prev == inside {
+			continue
+		}
+		action := i18nText("alert.bandLeave")
+		if inside {
+			action = i18nText("alert.bandEnter")
+		}
+		token0, _ := getPoolTokenMeta()
+		alerts = append(alerts, i18nText("alert.band",
+			token0.Symbol, action, band.Name, band.Lower, band.Upper, price))
+	}
+	return alerts
+}
@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+)
+
+// 交易回执补充信息配置
+type ReceiptConfig struct {
+	Enabled bool `json:"enabled"` // 是否通过 RPC 查询告警交易的回执
+}
+
+// 交易回执中需要的字段
+type txReceipt struct {
+	From              string `json:"from"`
+	To                string `json:"to"`
+	GasUsed           string `json:"gasUsed"`
+	EffectiveGasPrice string `json:"effectiveGasPrice"`
+	Status            string `json:"status"`
+}
+
+// 回执补充信息
+type receiptInfo struct {
+	GasUsed     uint64
+	GasPriceWei *big.Int
+	Router      string // 经过的已知路由名称，直接调用池子时为空
+}
+
+func getReceiptConfig() ReceiptConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Receipt
+}
+
+// 查询交易回执
+func fetchReceipt(ctx context.Context, txHash string) (*receiptInfo, error) {
+	var receipt *txReceipt
+	if err := rpcCall(ctx, "eth_getTransactionReceipt", &receipt, txHash); err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, fmt.Errorf("receipt for %s not found", txHash)
+	}
+
+	info := &receiptInfo{}
+	if isRouterAddress(receipt.To) {
+		known, _ := lookupKnownAddress(receipt.To)
+		info.Router = known.Name
+	}
+	if gasUsed, ok := new(big.Int).SetString(strings.TrimPrefix(receipt.GasUsed, "0x"), 16); ok {
+		info.GasUsed = gasUsed.Uint64()
+	}
+	if gasPrice, ok := new(big.Int).SetString(strings.TrimPrefix(receipt.EffectiveGasPrice, "0x"), 16); ok {
+		info.GasPriceWei = gasPrice
+	}
+	return info, nil
+}
+
+// 生成回执信息的消息片段，未启用或查询失败时返回空文本
+func receiptSummary(ctx context.Context, txHash string) localizedText {
+	if !getReceiptConfig().Enabled {
+		return localizedText{}
+	}
+	info, err := fetchReceipt(ctx, txHash)
+	if err != nil {
+		slog.Error("Failed to fetch receipt", "transactionHash", txHash, "error", err)
+		return localizedText{}
+	}
+
+	var gasPrice, via localizedText
+	if info.GasPriceWei != nil {
+		gwei := new(big.Float).Quo(new(big.Float).SetInt(info.GasPriceWei), big.NewFloat(1e9))
+		gasPrice = i18nText("receipt.gasPrice", gwei.Text('f', 2))
+	}
+	if info.Router != "" {
+		via = i18nText("receipt.via", info.Router)
+	}
+	return i18nText("receipt.gas", info.GasUsed, gasPrice, via)
+}
@@ -0,0 +1,91 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 输出配置，channel 与 stream 至少配置一个时启用，修改后需要重启
+type RedisOutputConfig struct {
+	Addr         string `json:"addr"`     // 为空时使用 storage.redis 的连接配置
+	Password     string `json:"password"` // 支持密钥引用
+	DB           int    `json:"db"`
+	Channel      string `json:"channel"`      // PUBLISH 的频道，例如 swaps
+	Stream       string `json:"stream"`       // XADD 的流，例如 swaps:stream
+	StreamMaxLen int64  `json:"streamMaxLen"` // 流中大约保留的条数，默认 10000
+}
+
+func getRedisOutputConfig() RedisOutputConfig {
+	configMutex.RLock()
+	cfg := configData.RedisOutput
+	configMutex.RUnlock()
+	cfg.Password = secretValue(cfg.Password)
+	if cfg.Addr == "" {
+		storage := getStorageConfig().Redis
+		cfg.Addr, cfg.Password, cfg.DB = storage.Addr, storage.Password, storage.DB
+	}
+	if cfg.StreamMaxLen <= 0 {
+		cfg.StreamMaxLen = 10000
+	}
+	return cfg
+}
+
+var redisOutput *redis.Client
+
+func startRedisOutput() {
+	cfg := getRedisOutputConfig()
+	if cfg.Channel == "" && cfg.Stream == "" {
+		return
+	}
+	if cfg.Addr == "" {
+		slog.Error("Redis output is configured without an address")
+		return
+	}
+	redisOutput = redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+	slog.Info("Publishing swaps to redis", "addr", cfg.Addr, "channel", cfg.Channel, "stream", cfg.Stream)
+}
+
+func stopRedisOutput() {
+	if redisOutput == nil {
+		return
+	}
+	redisOutput.Close()
+	redisOutput = nil
+}
+
+// 发布到频道并追加到流，流中的条目包含 tx 与 event 两个字段
+func publishRedis(ctx context.Context, event *SwapEvent) {
+	client := redisOutput
+	if client == nil {
+		return
+	}
+	cfg := getRedisOutputConfig()
+	data, err := json.Marshal(newSwapFeedEvent(event))
+	if err != nil {
+		slog.Error("Failed to encode swap for redis", "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	pipe := client.Pipeline()
+	if cfg.Channel != "" {
+		pipe.Publish(ctx, cfg.Channel, data)
+	}
+	if cfg.Stream != "" {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: cfg.Stream,
+			MaxLen: cfg.StreamMaxLen,
+			Approx: true,
+			Values: map[string]interface{}{"tx": event.Swap.TransactionHash, "event": data},
+		})
+	}
+	_, err = pipe.Exec(ctx)
+	recordChannelResult("redis", cfg.Addr, time.Now(), err)
+	if err != nil {
+		slog.Error("Failed to publish swap to redis", "transactionHash", event.Swap.TransactionHash, "error", err)
+	}
+}
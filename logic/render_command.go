@@ -0,0 +1,241 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RunRender 按当前配置渲染消息模板并输出各渠道的标题、副标题和正文，用于部署前检查模板修改
+//
+//	render [-template bark|telegram|all] [-kind swap|alert|digest] [-fixture swap.json] [-tx <hash>] [-lang en-US]
+//
+// -fixture 为 Graph 返回的 Swap 对象、对象数组或完整响应，-tx 从存储的 Swap 历史中查找交易，
+// 两者都未指定时使用内置的示例交易。任一模板无法编译或执行时返回错误
+func RunRender(args []string) error {
+	defer CloseStore()
+	ctx, stop := commandContext()
+	defer stop()
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	channel := fs.String("template", "all", "渲染的渠道模板：bark / telegram / all")
+	kind := fs.String("kind", templateSwap, "消息类型：swap / alert / digest")
+	fixture := fs.String("fixture", "", "Swap 样例文件（JSON）")
+	tx := fs.String("tx", "", "从 Swap 历史中查找的交易哈希")
+	since := fs.String("since", "30d", "-tx 查找的时间范围，支持 d 表示天")
+	message := fs.String("message", "", "alert / digest 的消息内容，默认使用自检测试消息")
+	lang := fs.String("lang", "", "渲染语言，默认使用 i18n.language")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	channels := []string{channelBark, channelTelegram}
+	switch *channel {
+	case "all":
+	case channelBark, channelTelegram:
+		channels = []string{*channel}
+	default:
+		return fmt.Errorf("unknown template %q, expected bark, telegram or all", *channel)
+	}
+	locale := defaultLocale()
+	if *lang != "" {
+		if locale.Lang = normalizeLanguage(*lang); locale.Lang == "" {
+			return fmt.Errorf("unsupported language %q", *lang)
+		}
+	}
+
+	var samples []interface{}
+	switch *kind {
+	case templateSwap:
+		swaps, err := renderSwaps(ctx, *fixture, *tx, *since)
+		if err != nil {
+			return err
+		}
+		for i := range swaps {
+			event, err := normalizeSwap(&swaps[i])
+			if err != nil {
+				return fmt.Errorf("swap %s: %w", swaps[i].TransactionHash, err)
+			}
+			data := newSwapTemplateData(ctx, event)
+			data.localeData, data.TimeText = locale, locale.FormatTime(event.Time)
+			data.Level = whaleBarkOptions(event).Level
+			samples = append(samples, data)
+		}
+	case templateAlert, templateDigest:
+		text := *message
+		if text == "" {
+			text = i18nText("alert.selfTest").in(locale.Lang)
+		}
+		now := time.Now()
+		samples = append(samples, messageTemplateData{localeData: locale, Message: text, Level: barkLevelActive, Time: now, TimeText: locale.FormatTime(now)})
+	default:
+		return fmt.Errorf("unknown kind %q", *kind)
+	}
+
+	errs := checkMessageTemplates(*kind, samples)
+	for i, data := range samples {
+		for _, ch := range channels {
+			notice := renderNotification(*kind, ch, data)
+			fmt.Printf("== %s #%d %s (%s, %s, %s) ==\n", *kind, i+1, ch, locale.Lang, channelFormat(ch), channelVerbosity(ch))
+			writeNotification(os.Stdout, ch, notice)
+			fmt.Println()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 输出渲染结果的标题、副标题和正文，Telegram 另外输出实际发送的消息
+func writeNotification(w io.Writer, channel string, notice notification) {
+	fmt.Fprintf(w, "title:    %s\n", notice.Title)
+	fmt.Fprintf(w, "subtitle: %s\n", notice.Subtitle)
+	fmt.Fprintf(w, "body:\n%s\n", notice.Body)
+	if channel == channelTelegram {
+		fmt.Fprintf(w, "message:\n%s\n", notice.telegramText(channelFormat(channel)))
+	}
+}
+
+// 读取样例 Swap：指定文件、历史中的交易或内置示例
+func renderSwaps(ctx context.Context, fixture, tx, since string) ([]Swap, error) {
+	switch {
+	case fixture != "":
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			return nil, err
+		}
+		return parseSwapFixture(data)
+	case tx != "":
+		window, err := parseDays(since)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		records, err := getStore().QuerySwaps(ctx, now.Add(-window), now.Add(time.Second))
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			if strings.EqualFold(record.TxHash, tx) {
+				return []Swap{swapFromRecord(record)}, nil
+			}
+		}
+		return nil, fmt.Errorf("swap %s not found in the last %s of history", tx, since)
+	default:
+		return []Swap{sampleSwap(time.Now())}, nil
+	}
+}
+
+// 解析样例文件，支持单个 Swap、Swap 数组和 Graph 的完整响应
+func parseSwapFixture(data []byte) ([]Swap, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		var swaps []Swap
+		if err := json.Unmarshal(data, &swaps); err != nil {
+			return nil, fmt.Errorf("invalid fixture: %w", err)
+		}
+		return swaps, nil
+	}
+	var response GraphResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("invalid fixture: %w", err)
+	}
+	if len(response.Data.Swaps) > 0 {
+		return response.Data.Swaps, nil
+	}
+	var swap Swap
+	if err := json.Unmarshal(data, &swap); err != nil {
+		return nil, fmt.Errorf("invalid fixture: %w", err)
+	}
+	if swap.Amount0 == "" || swap.Amount1 == "" {
+		return nil, errors.New("invalid fixture: no swap found")
+	}
+	return []Swap{swap}, nil
+}
+
+// 由历史记录还原 Swap，历史中没有池子价格相关的原始字段，tick 和流动性为空
+func swapFromRecord(r swapRecord) Swap {
+	token0, token1 := getPoolTokenMeta()
+	amountIn, amountOut := strconv.FormatFloat(r.AmountIn, 'f', -1, 64), strconv.FormatFloat(-r.AmountOut, 'f', -1, 64)
+	swap := Swap{
+		ID:              r.TxHash,
+		Sender:          r.Sender,
+		Recipient:       r.Recipient,
+		BlockNumber:     strconv.FormatInt(r.Block, 10),
+		BlockTimestamp:  strconv.FormatInt(r.Time.Unix(), 10),
+		TransactionHash: r.TxHash,
+	}
+	if r.Direction == directionBuy {
+		swap.Amount0, swap.Amount1 = rawAmount(amountOut, token0.Decimals), rawAmount(amountIn, token1.Decimals)
+	} else {
+		swap.Amount0, swap.Amount1 = rawAmount(amountIn, token0.Decimals), rawAmount(amountOut, token1.Decimals)
+	}
+	if r.AmountIn > 0 {
+		swap.BtcPrice = strconv.FormatFloat(r.VolumeUSD/r.AmountIn, 'f', -1, 64)
+	}
+	return swap
+}
+
+// 代币单位的数量换算为链上的原始数量
+func rawAmount(amount string, decimals int) string {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return "0"
+	}
+	return d.Shift(int32(decimals)).Truncate(0).String()
+}
+
+// 内置的示例交易：1.2 token0 换出 1.19 token1
+func sampleSwap(now time.Time) Swap {
+	token0, token1 := getPoolTokenMeta()
+	return Swap{
+		ID:              "0x0000000000000000000000000000000000000000000000000000000000000001#0",
+		Sender:          "0x1111111111111111111111111111111111111111",
+		Recipient:       "0x2222222222222222222222222222222222222222",
+		Amount0:         rawAmount("1.2", token0.Decimals),
+		Amount1:         rawAmount("-1.19", token1.Decimals),
+		Liquidity:       "1000000000000",
+		BlockNumber:     "20000000",
+		BlockTimestamp:  strconv.FormatInt(now.Add(-2*time.Minute).Unix(), 10),
+		TransactionHash: "0x0000000000000000000000000000000000000000000000000000000000000001",
+		BtcPrice:        "100000",
+	}
+}
+
+// 编译所有模板，并用样例数据执行该类型的全部模板，返回编译或执行错误
+func checkMessageTemplates(kind string, samples []interface{}) []error {
+	sources := messageTemplateSources()
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		tmpl, err := parseFormatTemplate(name, sources[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("template %s: %w", name, err))
+			continue
+		}
+		base, _, _ := strings.Cut(name, ".")
+		if base != kind && !strings.HasPrefix(base, kind+"_") {
+			continue
+		}
+		for _, data := range samples {
+			if err := tmpl.Execute(&bytes.Buffer{}, data); err != nil {
+				errs = append(errs, fmt.Errorf("template %s: %w", name, err))
+				break
+			}
+		}
+	}
+	return errs
+}
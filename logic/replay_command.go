@@ -0,0 +1,243 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// replay 输出的一行：一笔交易的通知决策或一次失败的查询
+type replayRow struct {
+	Time     time.Time
+	Source   string
+	Block    string
+	TxHash   string
+	Decision string
+	Reason   string
+	Detail   string
+}
+
+// 录制的查询失败时的决策
+const replayGraphError = "graph_error"
+
+// RunReplay 按时间顺序重新处理录制的 Graph 响应，输出每笔交易的通知决策与原因，用于排查某个时间点为什么没有收到通知
+//
+//	replay [-dir recordings] [-since 24h | -from 2025-02-18T03:00:00+08:00 -to ...] [-tx <hash>] [-send] [-v]
+//
+// 在临时目录中使用空的存储和当前配置中的过滤规则、成交额下限、小额刷单合并、分级与模板处理，时钟为录制时间，
+// 不修改当前目录的处理进度和数据，也不调用输出与告警。默认不发送通知，也不执行脚本钩子；-send 时按当前配置实际推送
+func RunReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	dir := fs.String("dir", "", "录制目录，默认使用 recording.dir")
+	since := fs.String("since", "24h", "重放最近多长时间的录制，支持 d 表示天")
+	from := fs.String("from", "", "开始时间（RFC 3339），指定时忽略 -since")
+	to := fs.String("to", "", "结束时间（RFC 3339），默认当前时间")
+	tx := fs.String("tx", "", "只显示指定交易哈希（支持前缀）")
+	send := fs.Bool("send", false, "按当前配置实际发送通知")
+	verbose := fs.Bool("v", false, "输出运行日志")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	until := time.Now()
+	if *to != "" {
+		t, err := time.Parse(time.RFC3339, *to)
+		if err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+		until = t
+	}
+	start := until
+	if *from != "" {
+		t, err := time.Parse(time.RFC3339, *from)
+		if err != nil {
+			return fmt.Errorf("invalid -from: %w", err)
+		}
+		start = t
+	} else {
+		window, err := parseDays(*since)
+		if err != nil {
+			return err
+		}
+		start = until.Add(-window)
+	}
+	if *dir == "" {
+		*dir = getRecordingConfig().Dir
+	}
+	if *dir == "" {
+		return fmt.Errorf("no recording directory, set recording.dir in %s or use -dir", configFile)
+	}
+	recordings, err := readGraphRecordings(*dir, start, until)
+	if err != nil {
+		return err
+	}
+	if len(recordings) == 0 {
+		return fmt.Errorf("no recordings in %s between %s and %s", *dir, start.Format(time.RFC3339), until.Format(time.RFC3339))
+	}
+
+	cfg := replayConfig(getConfigSnapshot(), *send)
+	leave, err := enterTempDir("message-push-replay-")
+	if err != nil {
+		return err
+	}
+	defer leave()
+	CloseStore()
+	applyConfig(cfg)
+	defer CloseStore()
+	if !*verbose {
+		previous := slog.Default()
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+		defer slog.SetDefault(previous)
+	}
+
+	ctx, stop := commandContext()
+	defer stop()
+	loadTokenMetadata(ctx)
+	var rows []replayRow
+	for _, recording := range recordings {
+		rows = append(rows, replayRecording(ctx, recording, *send)...)
+	}
+	if *send {
+		if err := flushDeliveryQueues(ctx); err != nil {
+			return err
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tSOURCE\tBLOCK\tTX\tDECISION\tREASON\tDETAIL")
+	for _, r := range rows {
+		if *tx != "" && !strings.HasPrefix(strings.ToLower(r.TxHash), strings.ToLower(*tx)) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Time.Format(time.RFC3339), r.Source, orDash(r.Block),
+			orDash(r.TxHash), r.Decision, orDash(r.Reason), r.Detail)
+	}
+	return w.Flush()
+}
+
+// 重放使用的配置：只保留影响通知决策和消息格式的部分，相对路径转换为绝对路径。
+// send 时保留推送渠道、推送队列与脚本钩子
+func replayConfig(current Config, send bool) Config {
+	cfg := Config{
+		LimitPrice:     current.LimitPrice,
+		HTTP:           current.HTTP,
+		Rules:          current.Rules,
+		WhaleTiers:     current.WhaleTiers,
+		RPCURL:         current.RPCURL,
+		AddressLabels:  current.AddressLabels,
+		KnownAddresses: current.KnownAddresses,
+		Explorer:       current.Explorer,
+		MEV:            current.MEV,
+		Pool:           current.Pool,
+		Spam:           current.Spam,
+		Secrets:        current.Secrets,
+		Templates:      current.Templates,
+		I18n:           current.I18n,
+		Numbers:        current.Numbers,
+		Emoji:          current.Emoji,
+	}
+	cfg.Templates.Dir = absolutePath(cfg.Templates.Dir)
+	cfg.I18n.Dir = absolutePath(cfg.I18n.Dir)
+	if send {
+		cfg.BarkAPIURLs = current.BarkAPIURLs
+		cfg.Telegram = current.Telegram
+		cfg.Delivery = current.Delivery
+		cfg.Script = current.Script
+		cfg.Script.Path = absolutePath(cfg.Script.Path)
+	}
+	return cfg
+}
+
+func absolutePath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// 重新处理一次录制的响应，返回其中每笔交易的决策
+func replayRecording(ctx context.Context, recording graphRecording, send bool) []replayRow {
+	failed := func(detail string) []replayRow {
+		return []replayRow{{Time: recording.Time, Source: recording.Source, Decision: replayGraphError, Detail: detail}}
+	}
+	if recording.Status != 200 {
+		return failed(fmt.Sprintf("HTTP %d from %s", recording.Status, recording.Endpoint))
+	}
+	var page GraphResponse
+	if err := json.Unmarshal([]byte(recording.Body), &page); err != nil {
+		return failed("invalid response: " + err.Error())
+	}
+	if len(page.Errors) > 0 {
+		return failed(newGraphQueryError(recording.Endpoint, page.Errors).Error())
+	}
+
+	notifier := clockNotifier(fixedClock(recording.Time))
+	if !send {
+		notifier = NotifierFunc(func(ctx context.Context, swap Swap, mevTag string) error {
+			event, ok := filterSwap(ctx, &swap, recording.Time)
+			if !ok {
+				return nil
+			}
+			summary, _, _ := strings.Cut(strings.TrimSpace(formatSwapEvent(ctx, event)), "\n")
+			auditLog.append(auditRecord{Time: time.Now(), TxHash: swap.TransactionHash, Block: swap.BlockNumber,
+				Decision: auditSent, Detail: summary, VolumeUSD: event.Volume.StringFixed(2)})
+			return nil
+		})
+	}
+	watcher := &Watcher{
+		Sources:  []Source{recordedPage{name: recording.Source, swaps: page.Data.Swaps}},
+		Store:    getStore(),
+		Notifier: notifier,
+		Clock:    fixedClock(recording.Time),
+	}
+
+	before := len(auditLog.query(time.Time{}, time.Now().Add(time.Second)))
+	err := watcher.Run(ctx)
+	var rows []replayRow
+	for _, r := range auditLog.query(time.Time{}, time.Now().Add(time.Second))[before:] {
+		rows = append(rows, replayRow{Time: recording.Time, Source: recording.Source, Block: r.Block, TxHash: r.TxHash,
+			Decision: r.Decision, Reason: r.Reason, Detail: r.Detail})
+	}
+	if err != nil {
+		rows = append(rows, replayRow{Time: recording.Time, Source: recording.Source, Decision: "error", Detail: err.Error()})
+	}
+	return rows
+}
+
+// 一次录制的响应中的交易，重放时作为数据源。分页查询的后续页面与前一页有重叠，由已处理交易去重
+type recordedPage struct {
+	name  string
+	swaps []Swap
+}
+
+func (p recordedPage) Name() string { return p.name }
+
+func (p recordedPage) CursorName() string { return defaultCursorName + ":" + p.name }
+
+func (p recordedPage) LoadCursor(ctx context.Context, store Store) (Cursor, error) {
+	cursor, _, err := store.LoadCursor(ctx, p.CursorName())
+	return cursor, err
+}
+
+// 按区块从新到旧返回全部交易，不按处理进度过滤
+func (p recordedPage) FetchSwaps(ctx context.Context, from string) ([]Swap, error) {
+	swaps := append([]Swap(nil), p.swaps...)
+	sort.SliceStable(swaps, func(i, j int) bool {
+		a, _ := strconv.ParseInt(swaps[i].BlockNumber, 10, 64)
+		b, _ := strconv.ParseInt(swaps[j].BlockNumber, 10, 64)
+		return a > b
+	})
+	return swaps, nil
+}
@@ -0,0 +1,57 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// 历史记录保留策略
+type RetentionConfig struct {
+	SwapDays             int `json:"swapDays"`             // Swap 历史保留天数，0 时使用 history.retentionDays
+	NotificationDays     int `json:"notificationDays"`     // 通知记录保留天数
+	PruneIntervalMinutes int `json:"pruneIntervalMinutes"` // 清理间隔
+	CompactIntervalHours int `json:"compactIntervalHours"` // 嵌入式数据库压缩间隔
+}
+
+func getRetentionConfig() RetentionConfig {
+	cfg := getStorageConfig().Retention
+	if cfg.SwapDays <= 0 {
+		cfg.SwapDays = getHistoryConfig().RetentionDays
+	}
+	if cfg.NotificationDays <= 0 {
+		cfg.NotificationDays = 30
+	}
+	if cfg.PruneIntervalMinutes <= 0 {
+		cfg.PruneIntervalMinutes = 60
+	}
+	if cfg.CompactIntervalHours <= 0 {
+		cfg.CompactIntervalHours = 24
+	}
+	return cfg
+}
+
+// StoragePruneTask 按保留策略删除过期的 Swap 与通知记录
+func StoragePruneTask(ctx context.Context) error {
+	cfg := getRetentionConfig()
+	now := time.Now()
+	deleted, err := getStore().Prune(ctx, now.AddDate(0, 0, -cfg.SwapDays), now.AddDate(0, 0, -cfg.NotificationDays))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		slog.Info("Pruned expired records", "deleted", deleted)
+	}
+	return errors.Join(pruneLookupCaches(ctx, now), pruneGraphRecordings(now))
+}
+
+// StorageCompactTask 压缩 SQLite 与 BoltDB，回收已删除记录占用的空间
+func StorageCompactTask(ctx context.Context) error {
+	start := time.Now()
+	if err := getStore().Compact(ctx); err != nil {
+		return err
+	}
+	slog.Info("Compacted store", "driver", getStorageConfig().Driver, "duration", time.Since(start))
+	return nil
+}
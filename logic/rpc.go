@@ -0,0 +1,92 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 没有配置 RPC 地址
+var errNoRPC = errors.New("rpc url not configured")
+
+// JSON-RPC 请求 ID
+var rpcRequestID atomic.Int64
+
+// JSON-RPC 错误
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+func getRPCURL(ctx context.Context) string {
+	configMutex.RLock()
+	rpcURL := configData.RPCURL
+	configMutex.RUnlock()
+	return secretValueContext(ctx, rpcURL)
+}
+
+// 调用以太坊 JSON-RPC 方法，结果解析到 result
+func rpcCall(ctx context.Context, method string, result interface{}, params ...interface{}) (err error) {
+	ctx, span := startSpan(ctx, "rpc "+method, attribute.String("rpc.method", method))
+	defer func() { endSpan(span, err) }()
+	rpcURL := getRPCURL(ctx)
+	if rpcURL == "" {
+		return errNoRPC
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      rpcRequestID.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rpcURL, bytes.NewReader(requestBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rpc %s returned %s", method, resp.Status)
+	}
+
+	var rpcResponse struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResponse); err != nil {
+		return err
+	}
+	if rpcResponse.Error != nil {
+		return rpcResponse.Error
+	}
+	return json.Unmarshal(rpcResponse.Result, result)
+}
+
+// 在最新区块上执行 eth_call，返回十六进制结果
+func ethCall(ctx context.Context, to, data string) (string, error) {
+	var result string
+	err := rpcCall(ctx, "eth_call", &result, map[string]string{"to": to, "data": data}, "latest")
+	return result, err
+}
@@ -0,0 +1,95 @@
+package logic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 通知过滤规则，未配置的条件不生效
+type RulesConfig struct {
+	MinVolumeUSD    float64  `json:"minVolumeUSD"`    // 最小成交额（美元）
+	Direction       string   `json:"direction"`       // 只通知指定方向：buy / sell，为空表示不限
+	SenderAllowList []string `json:"senderAllowList"` // 发送方白名单，非空时只通知名单内地址
+	SenderDenyList  []string `json:"senderDenyList"`  // 发送方黑名单
+	MinTick         *int32   `json:"minTick"`         // tick 下限
+	MaxTick         *int32   `json:"maxTick"`         // tick 上限
+	Conditions      []string `json:"conditions"`      // 表达式条件，满足任意一个即通过
+}
+
+// 单条过滤规则，返回是否通过以及不通过的原因
+type rule func(event *SwapEvent, cfg RulesConfig) (bool, string)
+
+// 按顺序执行的过滤规则
+var rules = []rule{
+	minVolumeRule,
+	directionRule,
+	senderRule,
+	tickRangeRule,
+	conditionRule,
+}
+
+func getRulesConfig() RulesConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Rules
+}
+
+// 依次执行所有规则，任意一条不通过即过滤
+func evaluateRules(event *SwapEvent) (bool, string) {
+	cfg := getRulesConfig()
+	for _, r := range rules {
+		if ok, reason := r(event, cfg); !ok {
+			return false, reason
+		}
+	}
+	return true, ""
+}
+
+func minVolumeRule(event *SwapEvent, cfg RulesConfig) (bool, string) {
+	if cfg.MinVolumeUSD <= 0 {
+		return true, ""
+	}
+	if vol := event.VolumeUSD(); vol < cfg.MinVolumeUSD {
+		return false, fmt.Sprintf("volume %.2f below %.2f", vol, cfg.MinVolumeUSD)
+	}
+	return true, ""
+}
+
+func directionRule(event *SwapEvent, cfg RulesConfig) (bool, string) {
+	if cfg.Direction == "" || strings.EqualFold(cfg.Direction, event.Direction) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("direction %s not %s", event.Direction, cfg.Direction)
+}
+
+func senderRule(event *SwapEvent, cfg RulesConfig) (bool, string) {
+	sender := event.Swap.Sender
+	if containsAddress(cfg.SenderDenyList, sender) {
+		return false, fmt.Sprintf("sender %s in deny list", sender)
+	}
+	if len(cfg.SenderAllowList) > 0 && !containsAddress(cfg.SenderAllowList, sender) {
+		return false, fmt.Sprintf("sender %s not in allow list", sender)
+	}
+	return true, ""
+}
+
+func tickRangeRule(event *SwapEvent, cfg RulesConfig) (bool, string) {
+	tick := event.Swap.Tick
+	if cfg.MinTick != nil && tick < *cfg.MinTick {
+		return false, fmt.Sprintf("tick %d below %d", tick, *cfg.MinTick)
+	}
+	if cfg.MaxTick != nil && tick > *cfg.MaxTick {
+		return false, fmt.Sprintf("tick %d above %d", tick, *cfg.MaxTick)
+	}
+	return true, ""
+}
+
+// 地址比较忽略大小写
+func containsAddress(list []string, address string) bool {
+	for _, a := range list {
+		if strings.EqualFold(a, address) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// run-once 的退出码
+const (
+	ExitOK        = 0 // 本轮处理完成（包括没有新交易）
+	ExitFailed    = 1 // 查询或处理失败，进度未前进
+	ExitNotLeader = 3 // 开启多实例主备时锁被其他实例持有，本轮跳过
+)
+
+// ExitError 带退出码的错误
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// RunOnce 执行一轮 获取-处理-通知 后退出，供系统 cron 或定时触发的无服务器函数调用。
+// 小额交易合并等依赖进程内状态的功能在该模式下只在单轮内生效
+func RunOnce(args []string) error {
+	fs := flag.NewFlagSet("run-once", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 0, "本轮处理的超时时间，默认使用 graph_task 的任务超时")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *timeout <= 0 {
+		*timeout = getTaskTimeout("graph_task")
+	}
+	defer CloseStore()
+	defer flushStore()
+	initTracing(context.Background())
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTracing(ctx)
+	}()
+
+	if getHAConfig().Enabled {
+		startLeaderElection()
+		defer stopLeaderElection()
+		if !leading() {
+			return &ExitError{Code: ExitNotLeader, Err: errors.New("another instance holds the leader lock, skipped")}
+		}
+	}
+
+	ctx, stop := commandContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	start := time.Now()
+	loadTokenMetadata(ctx)
+	err := GraphTask(ctx)
+	drainDeliveryQueues(*timeout)
+	waitExecHooks(*timeout)
+	if err != nil {
+		return &ExitError{Code: ExitFailed, Err: err}
+	}
+	slog.Info("Run once finished", "duration", time.Since(start))
+	return nil
+}
+
+// 命令行子命令使用的 context，收到 SIGINT 或 SIGTERM 时取消
+func commandContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
@@ -0,0 +1,172 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// 脚本钩子配置，脚本需要定义 on_swap(swap) 函数：
+//   - 返回 nil 表示不做修改
+//   - 返回 false 表示丢弃该通知
+//   - 返回 table 时可以设置 message（替换消息内容）、drop（丢弃）、targets（发送的 Bark 地址列表）
+type ScriptConfig struct {
+	Path    string `json:"path"`    // Lua 脚本路径，为空时不启用
+	Timeout int    `json:"timeout"` // 单次执行超时（毫秒）
+}
+
+// 脚本执行结果
+type hookResult struct {
+	Drop    bool
+	Message string
+	Targets []string
+}
+
+var (
+	scriptProto *lua.FunctionProto // 编译后的脚本
+	scriptPath  string             // 已编译脚本的路径
+	scriptMutex sync.Mutex
+)
+
+func getScriptConfig() ScriptConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Script
+}
+
+// 加载并编译脚本，路径未变化时复用已编译结果
+func loadScript(path string) (*lua.FunctionProto, error) {
+	scriptMutex.Lock()
+	defer scriptMutex.Unlock()
+	if scriptProto != nil && scriptPath == path {
+		return scriptProto, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	chunk, err := parse.Parse(file, path)
+	if err != nil {
+		return nil, err
+	}
+	proto, err := lua.Compile(chunk, path)
+	if err != nil {
+		return nil, err
+	}
+	scriptProto, scriptPath = proto, path
+	return proto, nil
+}
+
+// 配置变更后丢弃已编译的脚本
+func resetScript() {
+	scriptMutex.Lock()
+	defer scriptMutex.Unlock()
+	scriptProto, scriptPath = nil, ""
+}
+
+// 执行脚本钩子，未配置脚本或执行失败时原样返回
+func runScriptHook(ctx context.Context, event *SwapEvent, message string, targets []string) hookResult {
+	result := hookResult{Message: message, Targets: targets}
+	cfg := getScriptConfig()
+	if cfg.Path == "" {
+		return result
+	}
+
+	proto, err := loadScript(cfg.Path)
+	if err != nil {
+		slog.Error("Failed to load script", "path", cfg.Path, "error", err)
+		return result
+	}
+
+	hooked, err := callScript(ctx, proto, cfg, event, result)
+	if err != nil {
+		slog.Error("Failed to run script", "path", cfg.Path, "error", err)
+		return result
+	}
+	return hooked
+}
+
+func callScript(ctx context.Context, proto *lua.FunctionProto, cfg ScriptConfig, event *SwapEvent, result hookResult) (hookResult, error) {
+	L := lua.NewState()
+	defer L.Close()
+
+	timeout := time.Duration(cfg.Timeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	L.Push(L.NewFunctionFromProto(proto))
+	if err := L.PCall(0, lua.MultRet, nil); err != nil {
+		return result, err
+	}
+
+	onSwap, ok := L.GetGlobal("on_swap").(*lua.LFunction)
+	if !ok {
+		return result, fmt.Errorf("script does not define on_swap")
+	}
+
+	err := L.CallByParam(lua.P{Fn: onSwap, NRet: 1, Protect: true}, newSwapTable(L, event, result))
+	if err != nil {
+		return result, err
+	}
+	ret := L.Get(-1)
+	L.Pop(1)
+
+	switch v := ret.(type) {
+	case lua.LBool:
+		result.Drop = !bool(v)
+	case *lua.LTable:
+		if drop, ok := v.RawGetString("drop").(lua.LBool); ok {
+			result.Drop = bool(drop)
+		}
+		if msg, ok := v.RawGetString("message").(lua.LString); ok {
+			result.Message = string(msg)
+		}
+		if targets, ok := v.RawGetString("targets").(*lua.LTable); ok {
+			result.Targets = nil
+			targets.ForEach(func(_, value lua.LValue) {
+				result.Targets = append(result.Targets, value.String())
+			})
+		}
+	}
+	return result, nil
+}
+
+// 构造传给脚本的 swap 表
+func newSwapTable(L *lua.LState, event *SwapEvent, result hookResult) *lua.LTable {
+	env := newConditionEnv(event)
+	table := L.NewTable()
+	table.RawSetString("vol_usd", lua.LNumber(env.VolUSD))
+	table.RawSetString("amount_in", lua.LNumber(env.AmountIn))
+	table.RawSetString("amount_out", lua.LNumber(env.AmountOut))
+	table.RawSetString("token_in", lua.LString(env.TokenIn))
+	table.RawSetString("token_out", lua.LString(env.TokenOut))
+	table.RawSetString("direction", lua.LString(env.Direction))
+	table.RawSetString("sender", lua.LString(env.Sender))
+	table.RawSetString("recipient", lua.LString(env.Recipient))
+	table.RawSetString("tick", lua.LNumber(env.Tick))
+	table.RawSetString("block", lua.LNumber(env.Block))
+	table.RawSetString("btc_price", lua.LNumber(env.BtcPrice))
+	table.RawSetString("tx_hash", lua.LString(env.TxHash))
+	table.RawSetString("ts", lua.LNumber(env.Ts.Unix()))
+	table.RawSetString("message", lua.LString(result.Message))
+
+	targets := L.NewTable()
+	for _, target := range result.Targets {
+		targets.Append(lua.LString(target))
+	}
+	table.RawSetString("targets", targets)
+	return table
+}
@@ -0,0 +1,289 @@
+package logic
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// 外部密钥管理配置。配置值中可以使用 ${provider:reference} 引用密钥，例如
+//
+//	https://api.day.app/${vault:secret/data/message-push#bark_key}/交易提醒/
+//	${aws-sm:message-push/rpc#url}
+//	${gcp-sm:projects/my-project/secrets/rpc-url/versions/latest}
+//	${env:RPC_URL}
+//
+// 引用末尾的 #field 表示从 JSON 格式的密钥中取出对应字段
+type SecretsConfig struct {
+	VaultAddr    string `json:"vaultAddr"`    // Vault 地址，为空时读取 VAULT_ADDR，令牌只从 VAULT_TOKEN 读取
+	AWSRegion    string `json:"awsRegion"`    // AWS 区域，为空时使用默认凭证链中的配置
+	CacheMinutes int    `json:"cacheMinutes"` // 密钥缓存时间
+}
+
+// 密钥引用
+var secretRefPattern = regexp.MustCompile(`\$\{(vault|aws-sm|gcp-sm|env):([^}]+)\}`)
+
+// 已解析的密钥缓存。查询失败同样缓存，按连续失败次数退避，避免密钥服务不可用时每次读取配置都发起请求；
+// 之前查询成功过的密钥在失败期间继续使用上次的值
+type secretCacheEntry struct {
+	value     string // 最近一次成功查询的值
+	err       error  // 最近一次查询的错误，成功时为 nil
+	failures  int
+	expiresAt time.Time
+}
+
+// 查询失败后的退避时间，从 5 秒开始翻倍，最长 5 分钟
+func secretBackoff(failures int) time.Duration {
+	backoff := 5 * time.Second
+	for i := 1; i < failures && backoff < 5*time.Minute; i++ {
+		backoff *= 2
+	}
+	return min(backoff, 5*time.Minute)
+}
+
+// 单次查询密钥的超时时间
+const secretFetchTimeout = 10 * time.Second
+
+var (
+	secretCache      = make(map[string]secretCacheEntry)
+	secretCacheMutex sync.Mutex
+)
+
+func getSecretsConfig() SecretsConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Secrets
+	if cfg.VaultAddr == "" {
+		cfg.VaultAddr = os.Getenv("VAULT_ADDR")
+	}
+	if cfg.CacheMinutes <= 0 {
+		cfg.CacheMinutes = 10
+	}
+	return cfg
+}
+
+// 替换值中的所有密钥引用
+func resolveSecretRefs(ctx context.Context, value string) (string, error) {
+	var resolveErr error
+	resolved := secretRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		match := secretRefPattern.FindStringSubmatch(ref)
+		secret, err := lookupSecret(ctx, match[1], match[2])
+		if err != nil && resolveErr == nil {
+			resolveErr = fmt.Errorf("resolve %s secret %q: %w", match[1], match[2], err)
+		}
+		return secret
+	})
+	return resolved, resolveErr
+}
+
+// 查询密钥，结果按 cacheMinutes 缓存。失败时在退避时间内不再查询，期间返回上次成功的值，
+// 从未成功过时返回错误
+func lookupSecret(ctx context.Context, provider, ref string) (string, error) {
+	cfg := getSecretsConfig()
+	cacheKey := provider + ":" + ref
+	secretCacheMutex.Lock()
+	entry, ok := secretCache[cacheKey]
+	secretCacheMutex.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		if entry.value != "" {
+			return entry.value, nil
+		}
+		return "", entry.err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, secretFetchTimeout)
+	defer cancel()
+	name, field, _ := strings.Cut(ref, "#")
+	var value string
+	var err error
+	switch provider {
+	case "env":
+		value = os.Getenv(name)
+		if value == "" {
+			err = fmt.Errorf("environment variable %s is empty", name)
+		}
+	case "vault":
+		value, err = fetchVaultSecret(ctx, cfg, name, field)
+		field = "" // Vault 返回的已经是字段值
+	case "aws-sm":
+		value, err = fetchAWSSecret(ctx, cfg, name)
+	case "gcp-sm":
+		value, err = fetchGCPSecret(ctx, name)
+	}
+	if err == nil && field != "" {
+		value, err = secretField(value, field)
+	}
+	if err != nil {
+		failures := 1
+		if ok && entry.err != nil {
+			failures = entry.failures + 1
+		}
+		backoff := secretBackoff(failures)
+		secretCacheMutex.Lock()
+		secretCache[cacheKey] = secretCacheEntry{value: entry.value, err: err, failures: failures, expiresAt: time.Now().Add(backoff)}
+		secretCacheMutex.Unlock()
+		if entry.value != "" {
+			slog.Warn("Failed to refresh secret, using last value", "provider", provider, "failures", failures, "retryIn", backoff, "error", err)
+			return entry.value, nil
+		}
+		return "", err
+	}
+
+	secretCacheMutex.Lock()
+	secretCache[cacheKey] = secretCacheEntry{value: value, expiresAt: time.Now().Add(time.Duration(cfg.CacheMinutes) * time.Minute)}
+	secretCacheMutex.Unlock()
+	return value, nil
+}
+
+// 从 JSON 格式的密钥中取出字段
+func secretField(value, field string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object: %w", err)
+	}
+	v, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found", field)
+	}
+	return fmt.Sprint(v), nil
+}
+
+// 读取 Vault KV 密钥，同时兼容 KV v1 与 v2 的返回格式
+func fetchVaultSecret(ctx context.Context, cfg SecretsConfig, path, field string) (string, error) {
+	if cfg.VaultAddr == "" {
+		return "", fmt.Errorf("vault address not configured")
+	}
+	if field == "" {
+		return "", fmt.Errorf("vault reference requires #field")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(cfg.VaultAddr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+
+	var result struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := doSecretRequest(req, &result); err != nil {
+		return "", err
+	}
+	data := result.Data
+	if nested, ok := data["data"]; ok {
+		var v2 map[string]json.RawMessage
+		if err := json.Unmarshal(nested, &v2); err == nil {
+			data = v2
+		}
+	}
+	raw, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found", field)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return string(raw), nil
+	}
+	return value, nil
+}
+
+// 读取 AWS Secrets Manager 密钥，凭证使用 SDK 默认凭证链（环境变量、配置文件、实例角色）
+func fetchAWSSecret(ctx context.Context, cfg SecretsConfig, secretID string) (string, error) {
+	var options []func(*awsconfig.LoadOptions) error
+	if cfg.AWSRegion != "" {
+		options = append(options, awsconfig.WithRegion(cfg.AWSRegion))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return "", err
+	}
+	output, err := secretsmanager.NewFromConfig(awsCfg).GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: &secretID})
+	if err != nil {
+		return "", err
+	}
+	if output.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no string value", secretID)
+	}
+	return *output.SecretString, nil
+}
+
+// 读取 GCP Secret Manager 密钥，访问令牌依次从 GOOGLE_OAUTH_ACCESS_TOKEN 和 GCE 元数据服务获取
+func fetchGCPSecret(ctx context.Context, name string) (string, error) {
+	token, err := gcpAccessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://secretmanager.googleapis.com/v1/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var result struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := doSecretRequest(req, &result); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func gcpAccessToken(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var result struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doSecretRequest(req, &result); err != nil {
+		return "", fmt.Errorf("metadata server: %w", err)
+	}
+	return result.AccessToken, nil
+}
+
+func doSecretRequest(req *http.Request, result interface{}) error {
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return json.Unmarshal(body, result)
+}
+
+// 配置重新加载后清空缓存，下次使用时重新读取
+func resetSecretCache() {
+	secretCacheMutex.Lock()
+	secretCache = make(map[string]secretCacheEntry)
+	secretCacheMutex.Unlock()
+	slog.Debug("Secret cache cleared")
+}
@@ -0,0 +1,41 @@
+package logic
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// 缓存过期后刷新失败时继续使用上次成功的值，从未成功过时返回错误
+func TestLookupSecretKeepsLastValue(t *testing.T) {
+	quietLogs(t)
+	resetSecretCache()
+	t.Cleanup(resetSecretCache)
+	ctx := context.Background()
+
+	t.Setenv("MESSAGE_PUSH_TEST_SECRET", "bark-key")
+	if value, err := lookupSecret(ctx, "env", "MESSAGE_PUSH_TEST_SECRET"); err != nil || value != "bark-key" {
+		t.Fatalf("got %q, %v", value, err)
+	}
+
+	os.Unsetenv("MESSAGE_PUSH_TEST_SECRET")
+	expire := func() {
+		secretCacheMutex.Lock()
+		entry := secretCache["env:MESSAGE_PUSH_TEST_SECRET"]
+		entry.expiresAt = time.Now().Add(-time.Second)
+		secretCache["env:MESSAGE_PUSH_TEST_SECRET"] = entry
+		secretCacheMutex.Unlock()
+	}
+	expire()
+	for i := 0; i < 2; i++ {
+		// 第一次刷新失败，第二次在退避时间内直接使用缓存
+		if value, err := lookupSecret(ctx, "env", "MESSAGE_PUSH_TEST_SECRET"); err != nil || value != "bark-key" {
+			t.Fatalf("attempt %d: got %q, %v", i, value, err)
+		}
+	}
+
+	if _, err := lookupSecret(ctx, "env", "MESSAGE_PUSH_TEST_MISSING"); err == nil {
+		t.Fatal("expected error for a secret that never resolved")
+	}
+}
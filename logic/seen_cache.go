@@ -0,0 +1,187 @@
+package logic
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"sync"
+)
+
+// 配置文件存储的已处理交易缓存。哈希按时间分桶精确保存，超过 maxEntries 时淘汰最旧的桶，
+// 开启 bloom 后淘汰的哈希写入布隆过滤器，在保留期内继续去重（存在极低的误判率，误判时会漏发通知）。
+// 只有精确保存的最近几个桶会写入配置文件
+type SeenCacheConfig struct {
+	MaxEntries        int     `json:"maxEntries"`        // 内存中精确保存的哈希数上限，默认 100000
+	BucketMinutes     int     `json:"bucketMinutes"`     // 时间桶的长度，默认 60
+	Bloom             bool    `json:"bloom"`             // 淘汰的哈希写入布隆过滤器
+	FalsePositiveRate float64 `json:"falsePositiveRate"` // 布隆过滤器的误判率，默认 0.000001
+}
+
+func getSeenCacheConfig() SeenCacheConfig {
+	cfg := getStorageConfig().SeenCache
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 100000
+	}
+	if cfg.BucketMinutes <= 0 {
+		cfg.BucketMinutes = 60
+	}
+	if cfg.FalsePositiveRate <= 0 || cfg.FalsePositiveRate >= 1 {
+		cfg.FalsePositiveRate = 0.000001
+	}
+	return cfg
+}
+
+// 一个时间桶内的哈希 -> 首次处理时间（Unix 秒）
+type seenBucket struct {
+	start  int64
+	hashes map[string]int64
+}
+
+// 淘汰的哈希，按写入顺序分代，整代过期后丢弃
+type bloomGeneration struct {
+	filter *bloomFilter
+	newest int64 // 最近写入的哈希的处理时间
+}
+
+type seenCache struct {
+	mu          sync.Mutex
+	buckets     []*seenBucket // 按时间升序
+	size        int
+	generations []*bloomGeneration
+}
+
+// 按时间分桶加入哈希，返回因超出上限被淘汰的哈希
+func (c *seenCache) add(hash string, at int64, cfg SeenCacheConfig) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.containsLocked(hash) {
+		return nil
+	}
+	width := int64(cfg.BucketMinutes) * 60
+	start := at - at%width
+	i := sort.Search(len(c.buckets), func(i int) bool { return c.buckets[i].start >= start })
+	if i == len(c.buckets) || c.buckets[i].start != start {
+		c.buckets = append(c.buckets, nil)
+		copy(c.buckets[i+1:], c.buckets[i:])
+		c.buckets[i] = &seenBucket{start: start, hashes: make(map[string]int64)}
+	}
+	c.buckets[i].hashes[hash] = at
+	c.size++
+
+	var evicted []string
+	// 至少保留最新的一个桶，单个桶超出上限时不再淘汰
+	for c.size > cfg.MaxEntries && len(c.buckets) > 1 {
+		oldest := c.buckets[0]
+		c.buckets = c.buckets[1:]
+		c.size -= len(oldest.hashes)
+		for h, seenAt := range oldest.hashes {
+			evicted = append(evicted, h)
+			if cfg.Bloom {
+				c.addBloomLocked(h, seenAt, cfg)
+			}
+		}
+	}
+	return evicted
+}
+
+func (c *seenCache) addBloomLocked(hash string, at int64, cfg SeenCacheConfig) {
+	var current *bloomGeneration
+	if n := len(c.generations); n > 0 && c.generations[n-1].filter.count < c.generations[n-1].filter.capacity {
+		current = c.generations[n-1]
+	} else {
+		current = &bloomGeneration{filter: newBloomFilter(cfg.MaxEntries, cfg.FalsePositiveRate)}
+		c.generations = append(c.generations, current)
+	}
+	current.filter.add(hash)
+	current.newest = max(current.newest, at)
+}
+
+func (c *seenCache) contains(hash string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.containsLocked(hash)
+}
+
+func (c *seenCache) containsLocked(hash string) bool {
+	for i := len(c.buckets) - 1; i >= 0; i-- {
+		if _, ok := c.buckets[i].hashes[hash]; ok {
+			return true
+		}
+	}
+	for _, g := range c.generations {
+		if g.filter.contains(hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// 删除 before 之前处理的哈希，布隆过滤器整代过期后丢弃
+func (c *seenCache) prune(before int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kept := c.buckets[:0]
+	for _, b := range c.buckets {
+		for h, seenAt := range b.hashes {
+			if seenAt < before {
+				delete(b.hashes, h)
+				c.size--
+			}
+		}
+		if len(b.hashes) > 0 {
+			kept = append(kept, b)
+		}
+	}
+	c.buckets = kept
+	generations := c.generations[:0]
+	for _, g := range c.generations {
+		if g.newest >= before {
+			generations = append(generations, g)
+		}
+	}
+	c.generations = generations
+}
+
+// 布隆过滤器，使用 FNV 的两个哈希值组合出 k 个位置
+type bloomFilter struct {
+	bits     []uint64
+	m        uint64
+	k        uint64
+	count    int
+	capacity int
+}
+
+func newBloomFilter(capacity int, falsePositiveRate float64) *bloomFilter {
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := uint64(math.Round(float64(m) / float64(capacity) * math.Ln2))
+	return &bloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: max(k, 1), capacity: capacity}
+}
+
+func (f *bloomFilter) locations(s string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	a := h.Sum64()
+	h.Write([]byte{0})
+	return a, h.Sum64() | 1
+}
+
+func (f *bloomFilter) add(s string) {
+	a, b := f.locations(s)
+	for i := uint64(0); i < f.k; i++ {
+		pos := (a + i*b) % f.m
+		f.bits[pos/64] |= 1 << (pos % 64)
+	}
+	f.count++
+}
+
+func (f *bloomFilter) contains(s string) bool {
+	a, b := f.locations(s)
+	for i := uint64(0); i < f.k; i++ {
+		pos := (a + i*b) % f.m
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// 启动自检配置
+type SelfTestConfig struct {
+	OnStartup             bool `json:"onStartup"`             // 启动时执行自检，失败时退出
+	TestChannelsOnStartup bool `json:"testChannelsOnStartup"` // 启动自检时也向推送渠道发送测试消息
+}
+
+func getSelfTestConfig() SelfTestConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.SelfTest
+}
+
+// 单项检查
+type selfTestCheck struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+type selfTestResult struct {
+	name     string
+	err      error
+	duration time.Duration
+}
+
+// 依次检查 Graph API、RPC、存储和推送渠道，channels 为 false 时不发送测试消息
+func runSelfTest(ctx context.Context, channels bool) []selfTestResult {
+	var checks []selfTestCheck
+	for i, endpoint := range getGraphAPIURLs() {
+		endpoint := endpoint
+		checks = append(checks, selfTestCheck{
+			name: fmt.Sprintf("graph[%d] %s", i, urlHost(endpoint)),
+			run: func(ctx context.Context) error {
+				err := querySwaps(ctx, endpoint, swapsQuery(1, 0, false, true), new(GraphResponse))
+				return err
+			},
+		})
+	}
+	if rpcURL := getRPCURL(ctx); rpcURL != "" {
+		checks = append(checks, selfTestCheck{name: "rpc " + urlHost(rpcURL), run: checkRPC})
+	}
+	checks = append(checks, selfTestCheck{name: "store " + getStorageConfig().Driver, run: checkStoreWritable})
+	if channels {
+		for i, target := range allBarkTargets(ctx) {
+			target := target
+			checks = append(checks, selfTestCheck{
+				name: fmt.Sprintf("bark[%d] %s", i, urlHost(target)),
+				run: func(ctx context.Context) error {
+					return pushBarkTarget(ctx, target, translate(defaultLanguage(), "alert.selfTest"), barkOptions{Level: barkLevelActive})
+				},
+			})
+		}
+		if cfg := getTelegramConfig(); cfg.BotToken != "" {
+			checks = append(checks, selfTestCheck{
+				name: "telegram getMe",
+				run: func(ctx context.Context) error {
+					return telegramCall(ctx, cfg, "getMe", map[string]interface{}{}, nil)
+				},
+			})
+			for _, chat := range allTelegramChats(ctx) {
+				chat := chat
+				checks = append(checks, selfTestCheck{
+					name: "telegram chat " + strconv.FormatInt(chat, 10),
+					run: func(ctx context.Context) error {
+						return sendTelegramMessage(ctx, cfg, chat, translate(defaultLanguage(), "alert.selfTest"), formatPlain)
+					},
+				})
+			}
+		}
+	}
+
+	results := make([]selfTestResult, 0, len(checks))
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		start := time.Now()
+		err := check.run(checkCtx)
+		cancel()
+		results = append(results, selfTestResult{name: check.name, err: err, duration: time.Since(start)})
+	}
+	return results
+}
+
+func checkRPC(ctx context.Context) error {
+	var block string
+	if err := rpcCall(ctx, "eth_blockNumber", &block); err != nil {
+		return err
+	}
+	if _, err := strconv.ParseUint(block, 0, 64); err != nil {
+		return fmt.Errorf("unexpected block number %q", block)
+	}
+	return nil
+}
+
+// 直接打开配置的存储并读写一条独立类型的设置，检查后删除，不写入已处理交易
+func checkStoreWritable(ctx context.Context) error {
+	cfg := getStorageConfig()
+	if cfg.Driver == storageDriverJSON {
+		probe := configFile + ".selftest"
+		defer os.Remove(probe)
+		return writeFileAtomic(probe, []byte("{}\n"), 0644)
+	}
+
+	store, err := openStore(cfg)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer store.Close()
+	key := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := store.SaveSetting(ctx, settingsSelfTest, key, []byte(`{}`)); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	defer store.DeleteSetting(ctx, settingsSelfTest, key)
+	written, err := store.LoadSettings(ctx, settingsSelfTest)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	if _, ok := written[key]; !ok {
+		return errors.New("written entry not found")
+	}
+	return nil
+}
+
+// 只展示地址的主机名，地址中可能包含密钥
+func urlHost(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(invalid url)"
+	}
+	return u.Host
+}
+
+func printSelfTest(results []selfTestResult) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tRESULT\tTIME\tDETAIL")
+	failed := 0
+	for _, result := range results {
+		status, detail := "ok", "-"
+		if result.err != nil {
+			status, detail = "FAIL", result.err.Error()
+			// 请求错误中包含完整地址，只保留原因
+			var urlErr *url.Error
+			if errors.As(result.err, &urlErr) {
+				detail = urlErr.Op + ": " + urlErr.Err.Error()
+			}
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.name, status, result.duration.Round(time.Millisecond), detail)
+	}
+	w.Flush()
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
+
+// RunSelfTest 执行全部自检并向每个推送渠道发送测试消息
+func RunSelfTest(args []string) error {
+	ctx, stop := commandContext()
+	defer stop()
+	return printSelfTest(runSelfTest(ctx, true))
+}
+
+// StartupSelfTest 按配置在启动时执行自检，有检查失败时返回错误
+func StartupSelfTest() error {
+	cfg := getSelfTestConfig()
+	if !cfg.OnStartup {
+		return nil
+	}
+	return printSelfTest(runSelfTest(context.Background(), cfg.TestChannelsOnStartup))
+}
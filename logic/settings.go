@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// 运行中修改并保存在存储中的设置类型
+const (
+	settingsTelegramChats = "telegram_chats" // Telegram 会话设置，键为会话 ID
+	settingsSubscribers   = "subscribers"    // 订阅者，键为名称
+	settingsSelfTest      = "selftest"       // 自检写入的临时条目，检查后删除
+)
+
+// 备份与迁移时需要复制的设置类型
+var settingKinds = []string{settingsTelegramChats, settingsSubscribers}
+
+// 读取某一类的全部设置，无法解析的条目跳过
+func loadSettings[T any](ctx context.Context, kind string) (map[string]T, error) {
+	raw, err := getStore().LoadSettings(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[string]T, len(raw))
+	for key, data := range raw {
+		var value T
+		if err := json.Unmarshal(data, &value); err != nil {
+			slog.Error("Skip invalid setting", "kind", kind, "key", key, "error", err)
+			continue
+		}
+		settings[key] = value
+	}
+	return settings, nil
+}
+
+func saveSetting(ctx context.Context, kind, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return getStore().SaveSetting(ctx, kind, key, data)
+}
+
+// 导出全部设置，类型 -> 键 -> 值
+func exportSettings(ctx context.Context, store Store) (map[string]map[string]json.RawMessage, error) {
+	all := make(map[string]map[string]json.RawMessage, len(settingKinds))
+	for _, kind := range settingKinds {
+		settings, err := store.LoadSettings(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+		if len(settings) == 0 {
+			continue
+		}
+		all[kind] = make(map[string]json.RawMessage, len(settings))
+		for key, value := range settings {
+			all[kind][key] = value
+		}
+	}
+	return all, nil
+}
+
+// 写入导出的设置
+func importSettings(ctx context.Context, store Store, all map[string]map[string]json.RawMessage) (int, error) {
+	count := 0
+	for kind, settings := range all {
+		for key, value := range settings {
+			if err := store.SaveSetting(ctx, kind, key, value); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
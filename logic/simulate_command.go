@@ -0,0 +1,233 @@
+package logic
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// RunSimulate 按指定速率生成模拟交易，经过完整的 过滤-格式化-推送 流程，不查询 Graph API，
+// 用于对推送渠道、推送队列的限流与合并、小额刷单合并等功能做压力测试
+//
+//	simulate [-rate 5/s] [-whale-every 30s] [-duration 5m]
+//
+// 使用当前目录的配置和存储，模拟交易与真实交易一样写入历史并发送到推送渠道和各输出，
+// 请在单独的目录中使用测试配置运行。处理进度使用独立的 swap:simulate，不影响真实进度
+func RunSimulate(args []string) error {
+	defer CloseStore()
+	defer flushStore()
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	rate := fs.String("rate", "1/s", "生成速率，例如 5/s、30/m、100/h")
+	whaleEvery := fs.Duration("whale-every", 0, "每隔多长时间生成一笔大额交易，0 表示不生成")
+	duration := fs.Duration("duration", 0, "运行时间，0 表示直到收到中断信号")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	perSecond, err := parseRate(*rate)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := commandContext()
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+	loadTokenMetadata(ctx)
+
+	source := newSimulatedSource(perSecond, *whaleEvery, time.Now())
+	var calls, failures int64 // 交给推送流程的交易数与失败数
+	var mu sync.Mutex
+	watcher := newWatcher()
+	watcher.Sources = []Source{source}
+	notifier := watcher.Notifier
+	watcher.Notifier = NotifierFunc(func(ctx context.Context, swap Swap, mevTag string) error {
+		err := notifier.Notify(ctx, swap, mevTag)
+		mu.Lock()
+		calls++
+		if err != nil {
+			failures++
+		}
+		mu.Unlock()
+		return err
+	})
+	fmt.Printf("simulating %s swaps/s", strconv.FormatFloat(perSecond, 'f', -1, 64))
+	if *whaleEvery > 0 {
+		fmt.Printf(", a whale every %s", *whaleEvery)
+	}
+	fmt.Println(", press Ctrl+C to stop")
+
+	start := time.Now()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for ctx.Err() == nil {
+		watcher.Run(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+		}
+	}
+	drainDeliveryQueues(30 * time.Second)
+	waitExecHooks(30 * time.Second)
+
+	generated, whales := source.counts()
+	fmt.Printf("\ngenerated %d swaps (%d whales) in %s, processed %d, failed %d\n",
+		generated, whales, time.Since(start).Round(time.Second), calls-failures, failures)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHANNEL\tENQUEUED\tMAX DEPTH\tBLOCKED\tCOLLAPSED\tDROPPED")
+	for _, q := range listDeliveryQueues() {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\n", q.Channel, q.Enqueued, q.MaxDepth, q.Blocked, q.Collapsed, q.Dropped)
+	}
+	return w.Flush()
+}
+
+// 解析 N/s、N/m、N/h 形式的速率，返回每秒的数量
+func parseRate(s string) (float64, error) {
+	count, unit, ok := strings.Cut(s, "/")
+	if !ok {
+		unit = "s"
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	switch unit {
+	case "s":
+		return n, nil
+	case "m":
+		return n / 60, nil
+	case "h":
+		return n / 3600, nil
+	default:
+		return 0, fmt.Errorf("invalid rate unit %q, expected s, m or h", unit)
+	}
+}
+
+// 模拟的数据源：每次获取时按经过的时间生成交易，区块约 12 秒一个，成交额分布在 limitPrice 上下，
+// 少量地址重复出现以覆盖刷单合并与地址标记
+type simulatedSource struct {
+	mu         sync.Mutex
+	rand       *rand.Rand
+	perSecond  float64
+	whaleEvery time.Duration
+	started    time.Time
+	last       time.Time
+	carry      float64 // 上次未生成的小数部分
+	lastWhale  time.Time
+	btcPrice   float64
+	generated  int
+	whales     int
+	senders    []string
+}
+
+func newSimulatedSource(perSecond float64, whaleEvery time.Duration, now time.Time) *simulatedSource {
+	r := rand.New(rand.NewSource(now.UnixNano()))
+	senders := make([]string, 20)
+	for i := range senders {
+		senders[i] = fmt.Sprintf("0x%016x%016x%08x", r.Uint64(), r.Uint64(), r.Uint32())
+	}
+	return &simulatedSource{
+		rand:       r,
+		perSecond:  perSecond,
+		whaleEvery: whaleEvery,
+		started:    now,
+		last:       now,
+		lastWhale:  now,
+		btcPrice:   97000,
+		senders:    senders,
+	}
+}
+
+func (s *simulatedSource) Name() string { return "simulate" }
+
+func (s *simulatedSource) CursorName() string { return defaultCursorName + ":simulate" }
+
+func (s *simulatedSource) LoadCursor(ctx context.Context, store Store) (Cursor, error) {
+	cursor, _, err := store.LoadCursor(ctx, s.CursorName())
+	return cursor, err
+}
+
+func (s *simulatedSource) counts() (generated, whales int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generated, s.whales
+}
+
+// 生成上次获取以来到期的交易，按区块从新到旧
+func (s *simulatedSource) FetchSwaps(ctx context.Context, from string) ([]Swap, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	due := now.Sub(s.last).Seconds()*s.perSecond + s.carry
+	n := int(due)
+	s.carry = due - float64(n)
+	s.last = now
+
+	swaps := make([]Swap, 0, n+1)
+	for i := 0; i < n; i++ {
+		swaps = append(swaps, s.swap(now, s.normalVolume()))
+	}
+	if s.whaleEvery > 0 && now.Sub(s.lastWhale) >= s.whaleEvery {
+		s.lastWhale = now
+		s.whales++
+		swaps = append(swaps, s.swap(now, s.whaleVolume()))
+	}
+	// 同一批交易在同一区块内，新的在前
+	for i, j := 0, len(swaps)-1; i < j; i, j = i+1, j-1 {
+		swaps[i], swaps[j] = swaps[j], swaps[i]
+	}
+	return swaps, nil
+}
+
+// 普通交易的成交额：limitPrice 的 1/4 到 8 倍之间按对数均匀分布
+func (s *simulatedSource) normalVolume() float64 {
+	limit := math.Max(float64(getLimitPrice()), 1)
+	return limit / 4 * math.Exp(s.rand.Float64()*math.Log(32))
+}
+
+// 大额交易的成交额：超过最高的分级下限，没有分级时为 limitPrice 的 100 倍
+func (s *simulatedSource) whaleVolume() float64 {
+	var tierAmount float64
+	for _, tier := range getWhaleTiers() {
+		tierAmount = math.Max(tierAmount, tier.MinAmount)
+	}
+	if tierAmount > 0 {
+		return tierAmount * 1.2 * s.btcPrice
+	}
+	return math.Max(float64(getLimitPrice()), 1) * 100
+}
+
+func (s *simulatedSource) swap(now time.Time, volumeUSD float64) Swap {
+	token0, token1 := getPoolTokenMeta()
+	s.generated++
+	s.btcPrice *= 1 + (s.rand.Float64()-0.5)*0.001
+	amountIn := volumeUSD / s.btcPrice
+	amountOut := amountIn * (0.997 + (s.rand.Float64()-0.5)*0.004)
+	in, out := strconv.FormatFloat(amountIn, 'f', 8, 64), "-"+strconv.FormatFloat(amountOut, 'f', 8, 64)
+	swap := Swap{
+		Sender:          s.senders[s.rand.Intn(len(s.senders))],
+		Liquidity:       "52340917733",
+		BlockNumber:     strconv.FormatInt(21000000+int64(now.Sub(s.started).Seconds()/12), 10),
+		BlockTimestamp:  strconv.FormatInt(now.Unix(), 10),
+		TransactionHash: fmt.Sprintf("0x%016x%016x%016x%016x", s.rand.Uint64(), s.rand.Uint64(), s.rand.Uint64(), s.rand.Uint64()),
+		BtcPrice:        strconv.FormatFloat(s.btcPrice, 'f', 2, 64),
+	}
+	swap.ID = swap.TransactionHash + "#0"
+	swap.Recipient = swap.Sender
+	if s.rand.Intn(2) == 0 {
+		swap.Amount0, swap.Amount1 = rawAmount(in, token0.Decimals), rawAmount(out, token1.Decimals)
+	} else {
+		swap.Amount0, swap.Amount1 = rawAmount(out, token0.Decimals), rawAmount(in, token1.Decimals)
+	}
+	return swap
+}
@@ -0,0 +1,110 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 小额刷单过滤配置，最小成交额过滤使用 rules.minVolumeUSD
+type SpamConfig struct {
+	Enabled           bool    `json:"enabled"`
+	TinyVolumeUSD     float64 `json:"tinyVolumeUSD"`     // 低于该成交额的交易视为小额交易
+	MaxTinyPerWindow  int     `json:"maxTinyPerWindow"`  // 同一地址窗口内小额交易超过该数量后合并通知
+	WindowSeconds     int     `json:"windowSeconds"`     // 统计窗口
+	AggregateMinCount int     `json:"aggregateMinCount"` // 合并通知的最少笔数，低于该值时不发送
+}
+
+// 单个地址在窗口内的小额交易统计
+type spamWindow struct {
+	start      time.Time
+	count      int     // 窗口内小额交易总数
+	suppressed int     // 被合并的交易数
+	volumeUSD  float64 // 被合并的交易成交额
+}
+
+// 小额刷单过滤状态
+type spamFilter struct {
+	mu      sync.Mutex
+	windows map[string]*spamWindow
+}
+
+var spam = &spamFilter{windows: make(map[string]*spamWindow)}
+
+func getSpamConfig() SpamConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Spam
+	if cfg.MaxTinyPerWindow <= 0 {
+		cfg.MaxTinyPerWindow = 3
+	}
+	if cfg.WindowSeconds <= 0 {
+		cfg.WindowSeconds = 60
+	}
+	return cfg
+}
+
+// 交易的实际操作者，经过公共路由时使用接收方
+func swapActor(swap *Swap) string {
+	if isRouterAddress(swap.Sender) {
+		return strings.ToLower(swap.Recipient)
+	}
+	return strings.ToLower(swap.Sender)
+}
+
+// 判断是否需要合并该交易，返回 true 表示不单独通知
+func (f *spamFilter) suppress(cfg SpamConfig, event *SwapEvent, now time.Time) bool {
+	if !cfg.Enabled || event.VolumeUSD() >= cfg.TinyVolumeUSD {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	actor := swapActor(event.Swap)
+	window, ok := f.windows[actor]
+	if !ok {
+		window = &spamWindow{start: now}
+		f.windows[actor] = window
+	}
+	window.count++
+	if window.count <= cfg.MaxTinyPerWindow {
+		return false
+	}
+	window.suppressed++
+	window.volumeUSD += event.VolumeUSD()
+	return true
+}
+
+// 结束已过期的窗口，返回需要发送的合并通知
+func (f *spamFilter) flush(ctx context.Context, cfg SpamConfig, now time.Time) []localizedText {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var messages []localizedText
+	windowLength := time.Duration(cfg.WindowSeconds) * time.Second
+	for actor, window := range f.windows {
+		if now.Sub(window.start) < windowLength {
+			continue
+		}
+		delete(f.windows, actor)
+		if window.suppressed > 0 && window.suppressed >= cfg.AggregateMinCount {
+			messages = append(messages, i18nText("alert.spam",
+				displayAddress(ctx, actor), cfg.WindowSeconds, window.count, window.suppressed, window.volumeUSD))
+		}
+	}
+	return messages
+}
+
+// 发送到期的合并通知
+func flushSpamAggregates(ctx context.Context, now time.Time) {
+	cfg := getSpamConfig()
+	if !cfg.Enabled {
+		return
+	}
+	for _, message := range spam.flush(ctx, cfg, now) {
+		slog.Info("Sending aggregated spam alert", "message", message)
+		sendAlert(ctx, message, barkLevelActive)
+	}
+}
@@ -0,0 +1,459 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 存储后端
+const (
+	storageDriverJSON     = "json"     // 处理进度保存在配置文件中，历史记录使用 JSON Lines
+	storageDriverSQLite   = "sqlite"   // 全部数据保存在 SQLite 数据库中
+	storageDriverRedis    = "redis"    // 全部数据保存在 Redis 中，适用于多实例或无持久磁盘的容器
+	storageDriverPostgres = "postgres" // 全部数据保存在 Postgres 中，适用于长期保存历史
+	storageDriverBolt     = "bolt"     // 全部数据保存在嵌入式 BoltDB 文件中
+)
+
+// 存储配置，修改后需要重启才能生效
+type StorageConfig struct {
+	Driver            string                `json:"driver"`            // json、sqlite、bolt、redis 或 postgres，默认为 json
+	Path              string                `json:"path"`              // SQLite 或 BoltDB 数据库文件
+	SeenRetentionDays int                   `json:"seenRetentionDays"` // 已处理交易哈希的保留天数
+	TimeoutSeconds    int                   `json:"timeoutSeconds"`    // 单次读写超时，默认 5，对 sqlite、redis 和 postgres 生效
+	SeenCache         SeenCacheConfig       `json:"seenCache"`         // 配置文件存储时内存中的已处理交易上限
+	Retention         RetentionConfig       `json:"retention"`         // 历史记录保留策略
+	Redis             RedisStorageConfig    `json:"redis"`
+	Postgres          PostgresStorageConfig `json:"postgres"`
+}
+
+// 默认的处理进度名称，未配置池子时使用，也是旧版单一进度的名称
+const defaultCursorName = "swaps"
+
+// 处理进度
+type Cursor struct {
+	LastBlockNumber string    `json:"lastBlockNumber"` // 上次处理的区块号
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// 已发送的通知
+type NotificationRecord struct {
+	Time    time.Time `json:"time"`
+	TxHash  string    `json:"txHash"` // 系统告警时为空
+	Targets []string  `json:"targets"`
+	Message string    `json:"message"`
+}
+
+// Store 保存处理进度、已处理交易、Swap 历史、通知记录与运行时修改的设置
+type Store interface {
+	LoadCursor(ctx context.Context, name string) (Cursor, bool, error) // 进度不存在时返回 false
+	SaveCursor(ctx context.Context, name string, cursor Cursor) error
+	ListCursors(ctx context.Context) (map[string]Cursor, error)
+	SeenTx(ctx context.Context, txHashes []string) (map[string]bool, error) // 返回其中已处理过的交易哈希
+	MarkSeen(ctx context.Context, txHashes []string, at time.Time) error
+	PruneSeen(ctx context.Context, before time.Time) error
+	ListSeen(ctx context.Context) (map[string]time.Time, error)
+	AppendSwap(ctx context.Context, record swapRecord) error
+	QuerySwaps(ctx context.Context, since, until time.Time) ([]swapRecord, error)
+	LogNotification(ctx context.Context, record NotificationRecord) error
+	QueryNotifications(ctx context.Context, since, until time.Time) ([]NotificationRecord, error)
+	Prune(ctx context.Context, swapsBefore, notificationsBefore time.Time) (int64, error) // 删除过期的 Swap 与通知记录，返回删除的条数
+	Compact(ctx context.Context) error                                                    // 回收已删除记录占用的空间
+	LoadSettings(ctx context.Context, kind string) (map[string][]byte, error)             // 读取某一类的全部设置，键 -> JSON
+	SaveSetting(ctx context.Context, kind, key string, value []byte) error
+	DeleteSetting(ctx context.Context, kind, key string) error
+	Close() error
+}
+
+var (
+	activeStore Store
+	storeMutex  sync.Mutex
+)
+
+// 为单次读写设置超时，调用方的截止时间更早时使用调用方的
+func storeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	configMutex.RLock()
+	seconds := configData.Storage.TimeoutSeconds
+	configMutex.RUnlock()
+	return context.WithTimeout(ctx, secondsOrDefault(seconds, 5))
+}
+
+func getStorageConfig() StorageConfig {
+	configMutex.RLock()
+	cfg := configData.Storage
+	configMutex.RUnlock()
+	cfg.Redis.Password = secretValue(cfg.Redis.Password)
+	cfg.Postgres.DSN = secretValue(cfg.Postgres.DSN)
+	if cfg.Driver == "" {
+		cfg.Driver = storageDriverJSON
+	}
+	if cfg.Path == "" {
+		cfg.Path = "message-push.db"
+	}
+	if cfg.SeenRetentionDays <= 0 {
+		cfg.SeenRetentionDays = 7
+	}
+	return cfg
+}
+
+// 获取当前存储，首次使用时根据配置打开。打开失败时不缓存、不退回到配置文件存储，
+// 返回的存储每次读写都返回打开时的错误，任务失败后下次调用重新打开
+func getStore() Store {
+	store, err := openActiveStore()
+	if err != nil {
+		return unavailableStore{err: err}
+	}
+	return store
+}
+
+// 打开并缓存配置的存储
+func openActiveStore() (Store, error) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	if activeStore != nil {
+		return activeStore, nil
+	}
+
+	cfg := getStorageConfig()
+	store, err := openStore(cfg)
+	if err != nil {
+		slog.Error("Failed to open store", "driver", cfg.Driver, "error", err)
+		return nil, fmt.Errorf("open %s store: %w", cfg.Driver, err)
+	}
+	if getEncryptionConfig().Enabled {
+		store = &encryptedStore{Store: store}
+	}
+	activeStore = store
+	return activeStore, nil
+}
+
+func openStore(cfg StorageConfig) (Store, error) {
+	switch cfg.Driver {
+	case storageDriverJSON:
+		return &jsonStore{}, nil
+	case storageDriverSQLite:
+		return openSQLiteStore(cfg.Path)
+	case storageDriverBolt:
+		return openBoltStore(cfg.Path)
+	case storageDriverRedis:
+		return openRedisStore(cfg.Redis)
+	case storageDriverPostgres:
+		return openPostgresStore(cfg.Postgres)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// CloseStore 关闭当前存储
+func CloseStore() error {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	if activeStore == nil {
+		return nil
+	}
+	err := activeStore.Close()
+	activeStore = nil
+	return err
+}
+
+// 停止前写入尚未持久化的数据，json 存储的已处理交易只在保存进度时写入配置文件
+func flushStore() {
+	if getStorageConfig().Driver == storageDriverJSON {
+		saveConfig()
+	}
+}
+
+// Swap 数据源的进度名称，按链和池子区分
+func swapCursorName() string {
+	pool := strings.ToLower(getPoolConfig().Address)
+	if pool == "" {
+		return defaultCursorName
+	}
+	chain := getExplorerConfig().Chain
+	if chain == "" {
+		chain = "ethereum"
+	}
+	return defaultCursorName + ":" + chain + ":" + pool
+}
+
+// 处理进度的并发安全访问，任务执行时间重叠时保证读取、处理、保存依次进行
+type cursorState struct {
+	mu sync.Mutex
+}
+
+var state = &cursorState{}
+
+// Get 读取指定的处理进度
+func (s *cursorState) Get(ctx context.Context, name string) (Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return loadCursor(ctx, getStore(), name)
+}
+
+// Set 手动设置处理进度，用于回退重新处理
+func (s *cursorState) Set(ctx context.Context, name string, cursor Cursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return getStore().SaveCursor(ctx, name, cursor)
+}
+
+// 按指定方式从 store 读取处理进度
+func (s *cursorState) getWith(ctx context.Context, store Store, load func(context.Context, Store) (Cursor, error)) (Cursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return load(ctx, store)
+}
+
+// Update 读取处理进度并交给 fn 修改，fn 成功且进度有变化时保存，同时清理过期的已处理交易
+func (s *cursorState) Update(ctx context.Context, name string, fn func(cursor *Cursor) error) error {
+	load := func(ctx context.Context, store Store) (Cursor, error) { return loadCursor(ctx, store, name) }
+	return s.updateWith(ctx, getStore(), name, load, time.Now(), fn)
+}
+
+// 与 Update 相同，按指定方式读取 store 中的处理进度，按 now 清理过期的已处理交易
+func (s *cursorState) updateWith(ctx context.Context, store Store, name string, load func(context.Context, Store) (Cursor, error),
+	now time.Time, fn func(cursor *Cursor) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursor, err := load(ctx, store)
+	if err != nil {
+		return err
+	}
+	updated := cursor
+	if err := fn(&updated); err != nil {
+		return err
+	}
+	if updated.LastBlockNumber == cursor.LastBlockNumber {
+		return nil
+	}
+	if err := store.SaveCursor(ctx, name, updated); err != nil {
+		return err
+	}
+	retention := time.Duration(getStorageConfig().SeenRetentionDays) * 24 * time.Hour
+	return store.PruneSeen(ctx, now.Add(-retention))
+}
+
+// 读取处理进度，不存在时依次使用旧版的单一进度和配置文件中的区块号
+func loadCursor(ctx context.Context, store Store, name string) (Cursor, error) {
+	for _, candidate := range []string{name, defaultCursorName} {
+		cursor, found, err := store.LoadCursor(ctx, candidate)
+		if err != nil || found {
+			return cursor, err
+		}
+	}
+	return Cursor{LastBlockNumber: getLastBlockNumber()}, nil
+}
+
+// 查询 [since, until) 区间内的 Swap 历史，失败时返回空列表
+func querySwapHistory(ctx context.Context, since, until time.Time) []swapRecord {
+	records, err := getStore().QuerySwaps(ctx, since, until)
+	if err != nil {
+		slog.Error("Failed to query swap history", "error", err)
+	}
+	return records
+}
+
+// 记录已发送的通知
+func logNotification(ctx context.Context, txHash string, targets []string, message string) {
+	record := NotificationRecord{Time: time.Now(), TxHash: txHash, Targets: targets, Message: message}
+	rememberNotification(record)
+	if err := getStore().LogNotification(ctx, record); err != nil {
+		slog.Error("Failed to log notification", "error", err)
+	}
+}
+
+// 旧版存储：处理进度保存在配置文件中，历史记录使用 JSON Lines，不记录通知。
+// 已处理交易只在内存中修改，随 SaveCursor 一起写入配置文件
+type jsonStore struct{}
+
+// 默认进度保存在 lastBlockNumber 中，其他进度保存在 cursors 中
+func (s *jsonStore) LoadCursor(ctx context.Context, name string) (Cursor, bool, error) {
+	if name == defaultCursorName {
+		return Cursor{LastBlockNumber: getLastBlockNumber()}, true, nil
+	}
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	block, ok := configData.Cursors[name]
+	return Cursor{LastBlockNumber: block}, ok, nil
+}
+
+func (s *jsonStore) SaveCursor(ctx context.Context, name string, cursor Cursor) error {
+	if name == defaultCursorName {
+		setLastBlockNumber(cursor.LastBlockNumber)
+	} else {
+		configMutex.Lock()
+		if configData.Cursors == nil {
+			configData.Cursors = make(map[string]string)
+		}
+		configData.Cursors[name] = cursor.LastBlockNumber
+		configMutex.Unlock()
+	}
+	saveConfig()
+	return nil
+}
+
+func (s *jsonStore) ListCursors(ctx context.Context) (map[string]Cursor, error) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cursors := map[string]Cursor{defaultCursorName: {LastBlockNumber: configData.LastBlockNumber}}
+	for name, block := range configData.Cursors {
+		cursors[name] = Cursor{LastBlockNumber: block}
+	}
+	return cursors, nil
+}
+
+// 内存中的已处理交易，见 SeenCacheConfig。seenTxHashes 只保存其中精确记录的部分
+var (
+	jsonSeen     seenCache
+	jsonSeenOnce sync.Once
+)
+
+// 首次使用时从配置文件载入已处理交易
+func loadJSONSeen() *seenCache {
+	jsonSeenOnce.Do(func() {
+		cfg := getSeenCacheConfig()
+		var evicted []string
+		for hash, seenAt := range legacySeenTxHashes() {
+			evicted = append(evicted, jsonSeen.add(hash, seenAt, cfg)...)
+		}
+		forgetSeenTxHashes(evicted)
+	})
+	return &jsonSeen
+}
+
+// 从配置文件中删除已淘汰或过期的哈希
+func forgetSeenTxHashes(hashes []string) {
+	if len(hashes) == 0 {
+		return
+	}
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	for _, hash := range hashes {
+		delete(configData.SeenTxHashes, hash)
+	}
+}
+
+// 旧版配置中的 currentTxHashes 同样视为已处理
+func (s *jsonStore) SeenTx(ctx context.Context, txHashes []string) (map[string]bool, error) {
+	cache := loadJSONSeen()
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	seen := make(map[string]bool)
+	for _, hash := range txHashes {
+		if cache.contains(hash) || contains(configData.CurrentTxHashes, hash) {
+			seen[hash] = true
+		}
+	}
+	return seen, nil
+}
+
+func (s *jsonStore) MarkSeen(ctx context.Context, txHashes []string, at time.Time) error {
+	cache, cfg := loadJSONSeen(), getSeenCacheConfig()
+	configMutex.Lock()
+	hashes := append(configData.CurrentTxHashes, txHashes...)
+	configData.CurrentTxHashes = nil
+	if configData.SeenTxHashes == nil {
+		configData.SeenTxHashes = make(map[string]int64)
+	}
+	for _, hash := range hashes {
+		if _, ok := configData.SeenTxHashes[hash]; !ok && !cache.contains(hash) {
+			configData.SeenTxHashes[hash] = at.Unix()
+		}
+	}
+	configMutex.Unlock()
+
+	var evicted []string
+	for _, hash := range hashes {
+		evicted = append(evicted, cache.add(hash, at.Unix(), cfg)...)
+	}
+	forgetSeenTxHashes(evicted)
+	return nil
+}
+
+func (s *jsonStore) PruneSeen(ctx context.Context, before time.Time) error {
+	loadJSONSeen().prune(before.Unix())
+	configMutex.Lock()
+	for hash, seenAt := range configData.SeenTxHashes {
+		if seenAt < before.Unix() {
+			delete(configData.SeenTxHashes, hash)
+		}
+	}
+	configMutex.Unlock()
+	return nil
+}
+
+func (s *jsonStore) ListSeen(ctx context.Context) (map[string]time.Time, error) {
+	seen := make(map[string]time.Time)
+	for hash, seenAt := range legacySeenTxHashes() {
+		seen[hash] = time.Unix(seenAt, 0)
+	}
+	return seen, nil
+}
+
+func (s *jsonStore) AppendSwap(ctx context.Context, record swapRecord) error {
+	history.append(record)
+	return nil
+}
+
+func (s *jsonStore) QuerySwaps(ctx context.Context, since, until time.Time) ([]swapRecord, error) {
+	return history.query(since, until), nil
+}
+
+func (s *jsonStore) LogNotification(ctx context.Context, record NotificationRecord) error {
+	return nil
+}
+
+func (s *jsonStore) QueryNotifications(ctx context.Context, since, until time.Time) ([]NotificationRecord, error) {
+	return nil, nil
+}
+
+// JSON Lines 历史在写入时按 history.retentionDays 清理
+func (s *jsonStore) Prune(ctx context.Context, swapsBefore, notificationsBefore time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *jsonStore) Compact(ctx context.Context) error {
+	return nil
+}
+
+// 设置保存在配置文件的 settings 中
+func (s *jsonStore) LoadSettings(ctx context.Context, kind string) (map[string][]byte, error) {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	settings := make(map[string][]byte, len(configData.Settings[kind]))
+	for key, value := range configData.Settings[kind] {
+		settings[key] = append([]byte(nil), value...)
+	}
+	return settings, nil
+}
+
+func (s *jsonStore) SaveSetting(ctx context.Context, kind, key string, value []byte) error {
+	configMutex.Lock()
+	if configData.Settings == nil {
+		configData.Settings = make(map[string]map[string]json.RawMessage)
+	}
+	if configData.Settings[kind] == nil {
+		configData.Settings[kind] = make(map[string]json.RawMessage)
+	}
+	configData.Settings[kind][key] = append(json.RawMessage(nil), value...)
+	configMutex.Unlock()
+	saveConfig()
+	return nil
+}
+
+func (s *jsonStore) DeleteSetting(ctx context.Context, kind, key string) error {
+	configMutex.Lock()
+	delete(configData.Settings[kind], key)
+	configMutex.Unlock()
+	saveConfig()
+	return nil
+}
+
+func (s *jsonStore) Close() error {
+	return nil
+}
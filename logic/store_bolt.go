@@ -0,0 +1,325 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltCursors       = []byte("cursors")
+	boltSeenHashes    = []byte("seen_hashes")
+	boltSwaps         = []byte("swaps")
+	boltNotifications = []byte("notifications")
+	boltSettings      = []byte("settings")
+)
+
+// BoltDB 存储，单文件嵌入式数据库。Swap 以 时间(毫秒)+序号 为键按时间排序
+type boltStore struct {
+	mu   sync.RWMutex // 压缩时需要替换数据库文件
+	db   *bolt.DB
+	path string
+}
+
+func openBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltCursors, boltSeenHashes, boltSwaps, boltNotifications, boltSettings} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltStore{db: db, path: path}, nil
+}
+
+func (s *boltStore) view(fn func(tx *bolt.Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db.View(fn)
+}
+
+func (s *boltStore) update(fn func(tx *bolt.Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db.Update(fn)
+}
+
+// 大端序编码，保证键按数值排序
+func boltUint64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func (s *boltStore) LoadCursor(ctx context.Context, name string) (Cursor, bool, error) {
+	var cursor Cursor
+	var found bool
+	err := s.view(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltCursors).Get([]byte(name))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &cursor)
+	})
+	return cursor, found, err
+}
+
+func (s *boltStore) SaveCursor(ctx context.Context, name string, cursor Cursor) error {
+	cursor.UpdatedAt = time.Now()
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltCursors).Put([]byte(name), data)
+	})
+}
+
+func (s *boltStore) ListCursors(ctx context.Context) (map[string]Cursor, error) {
+	cursors := make(map[string]Cursor)
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltCursors).ForEach(func(k, v []byte) error {
+			var cursor Cursor
+			if err := json.Unmarshal(v, &cursor); err != nil {
+				return err
+			}
+			cursors[string(k)] = cursor
+			return nil
+		})
+	})
+	return cursors, err
+}
+
+func (s *boltStore) SeenTx(ctx context.Context, txHashes []string) (map[string]bool, error) {
+	seen := make(map[string]bool)
+	err := s.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSeenHashes)
+		for _, hash := range txHashes {
+			if bucket.Get([]byte(hash)) != nil {
+				seen[hash] = true
+			}
+		}
+		return nil
+	})
+	return seen, err
+}
+
+func (s *boltStore) MarkSeen(ctx context.Context, txHashes []string, at time.Time) error {
+	if len(txHashes) == 0 {
+		return nil
+	}
+	seenAt := boltUint64(uint64(at.UnixMilli()))
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSeenHashes)
+		for _, hash := range txHashes {
+			if bucket.Get([]byte(hash)) != nil {
+				continue
+			}
+			if err := bucket.Put([]byte(hash), seenAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// 已处理交易以哈希为键，清理时需要遍历整个 bucket
+func (s *boltStore) PruneSeen(ctx context.Context, before time.Time) error {
+	cutoff := boltUint64(uint64(before.UnixMilli()))
+	return s.update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltSeenHashes).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if bytes.Compare(v, cutoff) < 0 {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStore) ListSeen(ctx context.Context) (map[string]time.Time, error) {
+	seen := make(map[string]time.Time)
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSeenHashes).ForEach(func(k, v []byte) error {
+			seen[string(k)] = time.UnixMilli(int64(binary.BigEndian.Uint64(v)))
+			return nil
+		})
+	})
+	return seen, err
+}
+
+func (s *boltStore) AppendSwap(ctx context.Context, record swapRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSwaps)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := append(boltUint64(uint64(record.Time.UnixMilli())), boltUint64(seq)...)
+		return bucket.Put(key, data)
+	})
+}
+
+func (s *boltStore) QuerySwaps(ctx context.Context, since, until time.Time) ([]swapRecord, error) {
+	from := boltUint64(uint64(since.UnixMilli()))
+	to := boltUint64(uint64(until.UnixMilli()))
+
+	var records []swapRecord
+	err := s.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltSwaps).Cursor()
+		for k, v := c.Seek(from); k != nil && bytes.Compare(k[:8], to) < 0; k, v = c.Next() {
+			var record swapRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	return records, err
+}
+
+func (s *boltStore) LogNotification(ctx context.Context, record NotificationRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltNotifications)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		return bucket.Put(boltUint64(seq), data)
+	})
+}
+
+// 通知以序号为键，查询时需要遍历整个 bucket
+func (s *boltStore) QueryNotifications(ctx context.Context, since, until time.Time) ([]NotificationRecord, error) {
+	var records []NotificationRecord
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltNotifications).ForEach(func(_, v []byte) error {
+			var record NotificationRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			if !record.Time.Before(since) && record.Time.Before(until) {
+				records = append(records, record)
+			}
+			return nil
+		})
+	})
+	return records, err
+}
+
+func (s *boltStore) Prune(ctx context.Context, swapsBefore, notificationsBefore time.Time) (int64, error) {
+	var deleted int64
+	cutoff := boltUint64(uint64(swapsBefore.UnixMilli()))
+	err := s.update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltSwaps).Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k[:8], cutoff) < 0; k, _ = c.Next() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			deleted++
+		}
+
+		// 通知按序号追加，删除开头过期的记录
+		c = tx.Bucket(boltNotifications).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var record NotificationRecord
+			if err := json.Unmarshal(v, &record); err != nil || !record.Time.Before(notificationsBefore) {
+				break
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// BoltDB 文件不会自动缩小，压缩时复制到新文件后替换
+func (s *boltStore) Compact(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmpPath := s.path + ".compact"
+	os.Remove(tmpPath)
+	dst, err := bolt.Open(tmpPath, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return err
+	}
+	if err := bolt.Compact(dst, s.db, 0); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return err
+	}
+	s.db, err = bolt.Open(s.path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	return err
+}
+
+// 设置以 类型+"/"+键 为键，按类型前缀读取
+func (s *boltStore) LoadSettings(ctx context.Context, kind string) (map[string][]byte, error) {
+	prefix := []byte(kind + "/")
+	settings := make(map[string][]byte)
+	err := s.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltSettings).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			settings[string(k[len(prefix):])] = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	return settings, err
+}
+
+func (s *boltStore) SaveSetting(ctx context.Context, kind, key string, value []byte) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSettings).Put([]byte(kind+"/"+key), value)
+	})
+}
+
+func (s *boltStore) DeleteSetting(ctx context.Context, kind, key string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSettings).Delete([]byte(kind + "/" + key))
+	})
+}
+
+func (s *boltStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.Close()
+}
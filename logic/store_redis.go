@@ -0,0 +1,281 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 存储配置
+type RedisStorageConfig struct {
+	Addr       string `json:"addr"`
+	Password   string `json:"password"`
+	DB         int    `json:"db"`
+	KeyPrefix  string `json:"keyPrefix"`  // 键名前缀，多个实例共享时用于区分不同的池子
+	OutboxSize int    `json:"outboxSize"` // 通知记录保留的条数
+}
+
+// Redis 存储：处理进度与设置保存在哈希表中，已处理交易与 Swap 历史为按时间排序的有序集合，通知记录为列表
+type redisStore struct {
+	client *redis.Client
+	cfg    RedisStorageConfig
+}
+
+func openRedisStore(cfg RedisStorageConfig) (*redisStore, error) {
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = "message-push:"
+	}
+	if cfg.OutboxSize <= 0 {
+		cfg.OutboxSize = 1000
+	}
+	client := redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &redisStore{client: client, cfg: cfg}, nil
+}
+
+func (s *redisStore) key(name string) string {
+	return s.cfg.KeyPrefix + name
+}
+
+func (s *redisStore) LoadCursor(ctx context.Context, name string) (Cursor, bool, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	var cursor Cursor
+	data, err := s.client.HGet(ctx, s.key("cursors"), name).Result()
+	if err == redis.Nil {
+		return cursor, false, nil
+	}
+	if err != nil {
+		return cursor, false, err
+	}
+	return cursor, true, json.Unmarshal([]byte(data), &cursor)
+}
+
+func (s *redisStore) SaveCursor(ctx context.Context, name string, cursor Cursor) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	cursor.UpdatedAt = time.Now()
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, s.key("cursors"), name, data).Err()
+}
+
+func (s *redisStore) ListCursors(ctx context.Context) (map[string]Cursor, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	values, err := s.client.HGetAll(ctx, s.key("cursors")).Result()
+	if err != nil {
+		return nil, err
+	}
+	cursors := make(map[string]Cursor, len(values))
+	for name, data := range values {
+		var cursor Cursor
+		if err := json.Unmarshal([]byte(data), &cursor); err != nil {
+			return nil, err
+		}
+		cursors[name] = cursor
+	}
+	return cursors, nil
+}
+
+func (s *redisStore) SeenTx(ctx context.Context, txHashes []string) (map[string]bool, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	seen := make(map[string]bool)
+	if len(txHashes) == 0 {
+		return seen, nil
+	}
+	cmds := make([]*redis.FloatCmd, len(txHashes))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, hash := range txHashes {
+			cmds[i] = pipe.ZScore(ctx, s.key("seen"), hash)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	for i, cmd := range cmds {
+		if cmd.Err() == nil {
+			seen[txHashes[i]] = true
+		}
+	}
+	return seen, nil
+}
+
+// 已处理交易保存在以首次处理时间为分数的有序集合中，重复标记不会刷新时间
+func (s *redisStore) MarkSeen(ctx context.Context, txHashes []string, at time.Time) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	if len(txHashes) == 0 {
+		return nil
+	}
+	members := make([]redis.Z, len(txHashes))
+	for i, hash := range txHashes {
+		members[i] = redis.Z{Score: float64(at.UnixMilli()), Member: hash}
+	}
+	return s.client.ZAddNX(ctx, s.key("seen"), members...).Err()
+}
+
+func (s *redisStore) PruneSeen(ctx context.Context, before time.Time) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	return s.client.ZRemRangeByScore(ctx, s.key("seen"), "-inf", "("+strconv.FormatInt(before.UnixMilli(), 10)).Err()
+}
+
+func (s *redisStore) ListSeen(ctx context.Context) (map[string]time.Time, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	members, err := s.client.ZRangeWithScores(ctx, s.key("seen"), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]time.Time, len(members))
+	for _, member := range members {
+		seen[member.Member.(string)] = time.UnixMilli(int64(member.Score))
+	}
+	return seen, nil
+}
+
+func (s *redisStore) AppendSwap(ctx context.Context, record swapRecord) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.client.ZAdd(ctx, s.key("swaps"), redis.Z{Score: float64(record.Time.UnixMilli()), Member: data}).Err()
+}
+
+func (s *redisStore) QuerySwaps(ctx context.Context, since, until time.Time) ([]swapRecord, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	members, err := s.client.ZRangeByScore(ctx, s.key("swaps"), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since.UnixMilli(), 10),
+		Max: "(" + strconv.FormatInt(until.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]swapRecord, 0, len(members))
+	for _, member := range members {
+		var record swapRecord
+		if err := json.Unmarshal([]byte(member), &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// 通知记录写入 outbox 列表，只保留最近的 OutboxSize 条
+func (s *redisStore) LogNotification(ctx context.Context, record NotificationRecord) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, s.key("outbox"), data)
+		pipe.LTrim(ctx, s.key("outbox"), int64(-s.cfg.OutboxSize), -1)
+		return nil
+	})
+	return err
+}
+
+func (s *redisStore) QueryNotifications(ctx context.Context, since, until time.Time) ([]NotificationRecord, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	values, err := s.client.LRange(ctx, s.key("outbox"), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	var records []NotificationRecord
+	for _, value := range values {
+		var record NotificationRecord
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			return nil, err
+		}
+		if !record.Time.Before(since) && record.Time.Before(until) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (s *redisStore) Prune(ctx context.Context, swapsBefore, notificationsBefore time.Time) (int64, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	deleted, err := s.client.ZRemRangeByScore(ctx, s.key("swaps"), "-inf", "("+strconv.FormatInt(swapsBefore.UnixMilli(), 10)).Result()
+	if err != nil {
+		return deleted, err
+	}
+
+	// outbox 按时间顺序追加，删除开头过期的记录
+	values, err := s.client.LRange(ctx, s.key("outbox"), 0, -1).Result()
+	if err != nil {
+		return deleted, err
+	}
+	expired := 0
+	for _, value := range values {
+		var record NotificationRecord
+		if err := json.Unmarshal([]byte(value), &record); err != nil || !record.Time.Before(notificationsBefore) {
+			break
+		}
+		expired++
+	}
+	if expired == 0 {
+		return deleted, nil
+	}
+	if err := s.client.LTrim(ctx, s.key("outbox"), int64(expired), -1).Err(); err != nil {
+		return deleted, err
+	}
+	return deleted + int64(expired), nil
+}
+
+// 内存由 Redis 自行管理
+func (s *redisStore) Compact(ctx context.Context) error {
+	return nil
+}
+
+func (s *redisStore) LoadSettings(ctx context.Context, kind string) (map[string][]byte, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	values, err := s.client.HGetAll(ctx, s.key("settings:"+kind)).Result()
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[string][]byte, len(values))
+	for key, value := range values {
+		settings[key] = []byte(value)
+	}
+	return settings, nil
+}
+
+func (s *redisStore) SaveSetting(ctx context.Context, kind, key string, value []byte) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	return s.client.HSet(ctx, s.key("settings:"+kind), key, value).Err()
+}
+
+func (s *redisStore) DeleteSetting(ctx context.Context, kind, key string) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	return s.client.HDel(ctx, s.key("settings:"+kind), key).Err()
+}
+
+func (s *redisStore) Close() error {
+	return s.client.Close()
+}
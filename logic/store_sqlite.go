@@ -0,0 +1,328 @@
+package logic
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS cursors (
+	name       TEXT PRIMARY KEY,
+	block      TEXT NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS seen_hashes (
+	tx_hash TEXT PRIMARY KEY,
+	seen_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS seen_hashes_seen_at ON seen_hashes (seen_at);
+CREATE TABLE IF NOT EXISTS swaps (
+	tx_hash    TEXT NOT NULL,
+	block      INTEGER NOT NULL,
+	time       INTEGER NOT NULL,
+	sender     TEXT NOT NULL,
+	recipient  TEXT NOT NULL,
+	token_in   TEXT NOT NULL,
+	token_out  TEXT NOT NULL,
+	direction  TEXT NOT NULL,
+	amount_in  REAL NOT NULL,
+	amount_out REAL NOT NULL,
+	volume_usd REAL NOT NULL,
+	price      REAL NOT NULL
+);
+CREATE INDEX IF NOT EXISTS swaps_time ON swaps (time);
+CREATE TABLE IF NOT EXISTS notifications (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	time    INTEGER NOT NULL,
+	tx_hash TEXT NOT NULL,
+	targets TEXT NOT NULL,
+	message TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS notifications_time ON notifications (time);
+CREATE TABLE IF NOT EXISTS settings (
+	kind       TEXT NOT NULL,
+	key        TEXT NOT NULL,
+	value      TEXT NOT NULL,
+	updated_at INTEGER NOT NULL,
+	PRIMARY KEY (kind, key)
+);
+`
+
+// SQLite 存储，时间以 Unix 毫秒保存
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func openSQLiteStore(path string) (*sqliteStore, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite 只允许单个写入者
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) LoadCursor(ctx context.Context, name string) (Cursor, bool, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	var cursor Cursor
+	var updatedAt int64
+	err := s.db.QueryRowContext(ctx, `SELECT block, updated_at FROM cursors WHERE name = ?`, name).Scan(&cursor.LastBlockNumber, &updatedAt)
+	if err == sql.ErrNoRows {
+		return cursor, false, nil
+	}
+	cursor.UpdatedAt = time.UnixMilli(updatedAt)
+	return cursor, err == nil, err
+}
+
+func (s *sqliteStore) SaveCursor(ctx context.Context, name string, cursor Cursor) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, `INSERT INTO cursors (name, block, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET block = excluded.block, updated_at = excluded.updated_at`,
+		name, cursor.LastBlockNumber, time.Now().UnixMilli())
+	return err
+}
+
+func (s *sqliteStore) ListCursors(ctx context.Context) (map[string]Cursor, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, `SELECT name, block, updated_at FROM cursors`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cursors := make(map[string]Cursor)
+	for rows.Next() {
+		var name string
+		var cursor Cursor
+		var updatedAt int64
+		if err := rows.Scan(&name, &cursor.LastBlockNumber, &updatedAt); err != nil {
+			return nil, err
+		}
+		cursor.UpdatedAt = time.UnixMilli(updatedAt)
+		cursors[name] = cursor
+	}
+	return cursors, rows.Err()
+}
+
+func (s *sqliteStore) SeenTx(ctx context.Context, txHashes []string) (map[string]bool, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	seen := make(map[string]bool)
+	if len(txHashes) == 0 {
+		return seen, nil
+	}
+	args := make([]any, len(txHashes))
+	for i, hash := range txHashes {
+		args[i] = hash
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(txHashes)), ",")
+	rows, err := s.db.QueryContext(ctx, `SELECT tx_hash FROM seen_hashes WHERE tx_hash IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		seen[hash] = true
+	}
+	return seen, rows.Err()
+}
+
+func (s *sqliteStore) MarkSeen(ctx context.Context, txHashes []string, at time.Time) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, hash := range txHashes {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO seen_hashes (tx_hash, seen_at) VALUES (?, ?)`, hash, at.UnixMilli()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteStore) PruneSeen(ctx context.Context, before time.Time) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, `DELETE FROM seen_hashes WHERE seen_at < ?`, before.UnixMilli())
+	return err
+}
+
+func (s *sqliteStore) ListSeen(ctx context.Context) (map[string]time.Time, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, `SELECT tx_hash, seen_at FROM seen_hashes`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := make(map[string]time.Time)
+	for rows.Next() {
+		var hash string
+		var seenAt int64
+		if err := rows.Scan(&hash, &seenAt); err != nil {
+			return nil, err
+		}
+		seen[hash] = time.UnixMilli(seenAt)
+	}
+	return seen, rows.Err()
+}
+
+func (s *sqliteStore) AppendSwap(ctx context.Context, r swapRecord) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, `INSERT INTO swaps (tx_hash, block, time, sender, recipient, token_in, token_out,
+		direction, amount_in, amount_out, volume_usd, price) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.TxHash, r.Block, r.Time.UnixMilli(), r.Sender, r.Recipient, r.TokenIn, r.TokenOut,
+		r.Direction, r.AmountIn, r.AmountOut, r.VolumeUSD, r.Price)
+	return err
+}
+
+func (s *sqliteStore) QuerySwaps(ctx context.Context, since, until time.Time) ([]swapRecord, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, `SELECT tx_hash, block, time, sender, recipient, token_in, token_out,
+		direction, amount_in, amount_out, volume_usd, price FROM swaps WHERE time >= ? AND time < ? ORDER BY time`,
+		since.UnixMilli(), until.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []swapRecord
+	for rows.Next() {
+		var r swapRecord
+		var ts int64
+		if err := rows.Scan(&r.TxHash, &r.Block, &ts, &r.Sender, &r.Recipient, &r.TokenIn, &r.TokenOut,
+			&r.Direction, &r.AmountIn, &r.AmountOut, &r.VolumeUSD, &r.Price); err != nil {
+			return nil, err
+		}
+		r.Time = time.UnixMilli(ts)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+func (s *sqliteStore) LogNotification(ctx context.Context, record NotificationRecord) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, `INSERT INTO notifications (time, tx_hash, targets, message) VALUES (?, ?, ?, ?)`,
+		record.Time.UnixMilli(), record.TxHash, strings.Join(record.Targets, ","), record.Message)
+	return err
+}
+
+func (s *sqliteStore) QueryNotifications(ctx context.Context, since, until time.Time) ([]NotificationRecord, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, `SELECT time, tx_hash, targets, message FROM notifications
+		WHERE time >= ? AND time < ? ORDER BY time`, since.UnixMilli(), until.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []NotificationRecord
+	for rows.Next() {
+		var r NotificationRecord
+		var ts int64
+		var targets string
+		if err := rows.Scan(&ts, &r.TxHash, &targets, &r.Message); err != nil {
+			return nil, err
+		}
+		r.Time = time.UnixMilli(ts)
+		if targets != "" {
+			r.Targets = strings.Split(targets, ",")
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+func (s *sqliteStore) Prune(ctx context.Context, swapsBefore, notificationsBefore time.Time) (int64, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	var deleted int64
+	for _, q := range []struct {
+		query  string
+		before time.Time
+	}{
+		{`DELETE FROM swaps WHERE time < ?`, swapsBefore},
+		{`DELETE FROM notifications WHERE time < ?`, notificationsBefore},
+	} {
+		result, err := s.db.ExecContext(ctx, q.query, q.before.UnixMilli())
+		if err != nil {
+			return deleted, err
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// 回收已删除记录占用的空间
+func (s *sqliteStore) Compact(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `VACUUM`)
+	return err
+}
+
+func (s *sqliteStore) LoadSettings(ctx context.Context, kind string) (map[string][]byte, error) {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, `SELECT key, value FROM settings WHERE kind = ?`, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := make(map[string][]byte)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		settings[key] = []byte(value)
+	}
+	return settings, rows.Err()
+}
+
+func (s *sqliteStore) SaveSetting(ctx context.Context, kind, key string, value []byte) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, `INSERT INTO settings (kind, key, value, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (kind, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		kind, key, string(value), time.Now().UnixMilli())
+	return err
+}
+
+func (s *sqliteStore) DeleteSetting(ctx context.Context, kind, key string) error {
+	ctx, cancel := storeContext(ctx)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, `DELETE FROM settings WHERE kind = ? AND key = ?`, kind, key)
+	return err
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"context"
+	"time"
+)
+
+// 配置的存储无法打开时使用，所有读写返回打开时的错误
+type unavailableStore struct {
+	err error
+}
+
+func (s unavailableStore) LoadCursor(ctx context.Context, name string) (Cursor, bool, error) {
+	return Cursor{}, false, s.err
+}
+
+func (s unavailableStore) SaveCursor(ctx context.Context, name string, cursor Cursor) error {
+	return s.err
+}
+
+func (s unavailableStore) ListCursors(ctx context.Context) (map[string]Cursor, error) {
+	return nil, s.err
+}
+
+func (s unavailableStore) SeenTx(ctx context.Context, txHashes []string) (map[string]bool, error) {
+	return nil, s.err
+}
+
+func (s unavailableStore) MarkSeen(ctx context.Context, txHashes []string, at time.Time) error {
+	return s.err
+}
+
+func (s unavailableStore) PruneSeen(ctx context.Context, before time.Time) error {
+	return s.err
+}
+
+func (s unavailableStore) ListSeen(ctx context.Context) (map[string]time.Time, error) {
+	return nil, s.err
+}
+
+func (s unavailableStore) AppendSwap(ctx context.Context, record swapRecord) error {
+	return s.err
+}
+
+func (s unavailableStore) QuerySwaps(ctx context.Context, since, until time.Time) ([]swapRecord, error) {
+	return nil, s.err
+}
+
+func (s unavailableStore) LogNotification(ctx context.Context, record NotificationRecord) error {
+	return s.err
+}
+
+func (s unavailableStore) QueryNotifications(ctx context.Context, since, until time.Time) ([]NotificationRecord, error) {
+	return nil, s.err
+}
+
+func (s unavailableStore) Prune(ctx context.Context, swapsBefore, notificationsBefore time.Time) (int64, error) {
+	return 0, s.err
+}
+
+func (s unavailableStore) Compact(ctx context.Context) error {
+	return s.err
+}
+
+func (s unavailableStore) LoadSettings(ctx context.Context, kind string) (map[string][]byte, error) {
+	return nil, s.err
+}
+
+func (s unavailableStore) SaveSetting(ctx context.Context, kind, key string, value []byte) error {
+	return s.err
+}
+
+func (s unavailableStore) DeleteSetting(ctx context.Context, kind, key string) error {
+	return s.err
+}
+
+func (s unavailableStore) Close() error {
+	return nil
+}
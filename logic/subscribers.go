@@ -0,0 +1,468 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 订阅者，保存在存储中，通过 subscribers 命令或管理接口维护。
+// 没有任何订阅者时使用 barkAPIURLs 作为默认订阅者，保持旧配置可用
+type Subscriber struct {
+	Name         string    `json:"name"`
+	Channels     []string  `json:"channels"`               // Bark 地址（支持密钥引用）或 telegram:<会话 ID>
+	MinVolumeUSD float64   `json:"minVolumeUSD,omitempty"` // 成交额阈值，低于全局 limitPrice 时不生效
+	QuietHours   string    `json:"quietHours,omitempty"`   // 免打扰时段（订阅者时区），例如 23:00-07:00，期间只接收 critical 推送
+	Language     string    `json:"language,omitempty"`     // 通知语言，例如 zh-CN、en-US，为空时使用 i18n.language
+	Timezone     string    `json:"timezone,omitempty"`     // 时区，例如 America/New_York，为空时使用 i18n.timezone
+	TimeFormat   string    `json:"timeFormat,omitempty"`   // 时间格式：24h、12h、relative 或 Go 时间布局，为空时使用 i18n.timeFormat
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+const (
+	defaultSubscriberName   = "default"
+	telegramChannelPrefix   = "telegram:"
+	subscriberMaxNameLength = 64
+)
+
+// 加密值或密钥引用，解析后才是实际地址
+func isSecretRef(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix) || secretRefPattern.MatchString(value)
+}
+
+// 校验订阅者配置
+func (s Subscriber) validate() error {
+	if s.Name == "" || len(s.Name) > subscriberMaxNameLength || strings.ContainsAny(s.Name, "/ \t") {
+		return fmt.Errorf("invalid subscriber name %q", s.Name)
+	}
+	if len(s.Channels) == 0 {
+		return errors.New("subscriber needs at least one channel")
+	}
+	for _, channel := range s.Channels {
+		if chat, ok := strings.CutPrefix(channel, telegramChannelPrefix); ok {
+			if _, err := strconv.ParseInt(chat, 10, 64); err != nil {
+				return fmt.Errorf("invalid telegram chat %q", chat)
+			}
+		} else if !strings.HasPrefix(channel, "http://") && !strings.HasPrefix(channel, "https://") && !isSecretRef(channel) {
+			return fmt.Errorf("invalid channel %q, expected a bark url or telegram:<chat id>", channel)
+		}
+	}
+	if s.MinVolumeUSD < 0 {
+		return errors.New("minVolumeUSD must not be negative")
+	}
+	if s.QuietHours != "" {
+		if _, _, err := parseQuietHours(s.QuietHours); err != nil {
+			return err
+		}
+	}
+	if s.Language != "" && normalizeLanguage(s.Language) == "" {
+		return fmt.Errorf("unsupported language %q", s.Language)
+	}
+	return validateTimezone(s.Timezone)
+}
+
+// 订阅者的通知语言
+func (s Subscriber) language() string {
+	if lang := normalizeLanguage(s.Language); lang != "" {
+		return lang
+	}
+	return defaultLanguage()
+}
+
+// 订阅者的语言、时区和时间格式，未设置的项使用默认值
+func (s Subscriber) locale() localeData {
+	locale := defaultLocale()
+	locale.Lang = s.language()
+	if s.Timezone != "" {
+		locale.Timezone = s.Timezone
+	}
+	if s.TimeFormat != "" {
+		locale.TimeFormat = s.TimeFormat
+	}
+	return locale
+}
+
+// 解析免打扰时段，返回从 0 点起的分钟数
+func parseQuietHours(s string) (int, int, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid quiet hours %q, expected HH:MM-HH:MM", s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// 是否处于免打扰时段，支持跨过 0 点的时段
+func (s Subscriber) quiet(now time.Time) bool {
+	start, end, err := parseQuietHours(s.QuietHours)
+	if s.QuietHours == "" || err != nil || start == end {
+		return false
+	}
+	local := now.In(s.locale().location())
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// 是否接收这条推送，event 为空表示系统告警
+func (s Subscriber) wants(event *SwapEvent, level string, now time.Time) bool {
+	if level != barkLevelCritical && s.quiet(now) {
+		return false
+	}
+	return event == nil || event.VolumeUSD() >= s.MinVolumeUSD
+}
+
+// 拆分渠道，Bark 地址解析密钥引用
+func (s Subscriber) targets(ctx context.Context) (bark []string, chats []int64) {
+	for _, channel := range s.Channels {
+		if chat, ok := strings.CutPrefix(channel, telegramChannelPrefix); ok {
+			if id, err := strconv.ParseInt(chat, 10, 64); err == nil {
+				chats = append(chats, id)
+			}
+			continue
+		}
+		if target := secretValueContext(ctx, channel); target != "" {
+			bark = append(bark, target)
+		}
+	}
+	return bark, chats
+}
+
+// 按名称排序的订阅者列表，没有订阅者时返回由 barkAPIURLs 组成的默认订阅者
+func listSubscribers(ctx context.Context) ([]Subscriber, error) {
+	stored, err := loadSettings[Subscriber](ctx, settingsSubscribers)
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		configMutex.RLock()
+		channels := append([]string(nil), configData.BarkAPIURLs...)
+		configMutex.RUnlock()
+		return []Subscriber{{Name: defaultSubscriberName, Channels: channels}}, nil
+	}
+	subscribers := make([]Subscriber, 0, len(stored))
+	for _, s := range stored {
+		subscribers = append(subscribers, s)
+	}
+	sort.Slice(subscribers, func(i, j int) bool { return subscribers[i].Name < subscribers[j].Name })
+	return subscribers, nil
+}
+
+// 使用同一语言、时区和时间格式的推送目标
+type targetGroup struct {
+	localeData
+	Bark  []string
+	Chats []int64
+}
+
+// 按语言、时区和时间格式分组收集需要接收推送的 Bark 地址和 Telegram 会话，去重，
+// 同一目标只按第一个订阅者的设置推送。telegram.chatIDs 中的会话使用默认设置
+func subscriberGroups(ctx context.Context, event *SwapEvent, level string, now time.Time) []targetGroup {
+	subscribers, err := listSubscribers(ctx)
+	if err != nil {
+		slog.Error("Failed to load subscribers, using barkAPIURLs", "error", err)
+		subscribers = []Subscriber{{Name: defaultSubscriberName, Channels: getBarkAPIURLs()}}
+	}
+	var groups []targetGroup
+	index := map[localeData]int{}
+	group := func(locale localeData) *targetGroup {
+		i, ok := index[locale]
+		if !ok {
+			i = len(groups)
+			index[locale] = i
+			groups = append(groups, targetGroup{localeData: locale})
+		}
+		return &groups[i]
+	}
+	seenBark := map[string]bool{}
+	seenChat := map[int64]bool{}
+	for _, s := range subscribers {
+		if !s.wants(event, level, now) {
+			continue
+		}
+		b, c := s.targets(ctx)
+		for _, target := range b {
+			if !seenBark[target] {
+				seenBark[target] = true
+				g := group(s.locale())
+				g.Bark = append(g.Bark, target)
+			}
+		}
+		for _, chat := range c {
+			if !seenChat[chat] {
+				seenChat[chat] = true
+				g := group(s.locale())
+				g.Chats = append(g.Chats, chat)
+			}
+		}
+	}
+	for _, chat := range getTelegramConfig().ChatIDs {
+		if !seenChat[chat] {
+			seenChat[chat] = true
+			g := group(defaultLocale())
+			g.Chats = append(g.Chats, chat)
+		}
+	}
+	return groups
+}
+
+// 收集需要接收推送的 Bark 地址和 Telegram 会话，不区分语言
+func subscriberTargets(ctx context.Context, event *SwapEvent, level string, now time.Time) ([]string, []int64) {
+	var bark []string
+	var chats []int64
+	for _, g := range subscriberGroups(ctx, event, level, now) {
+		bark = append(bark, g.Bark...)
+		chats = append(chats, g.Chats...)
+	}
+	return bark, chats
+}
+
+// 只保留 targets 中的 Bark 地址，不属于任何分组的地址使用默认设置，用于脚本钩子修改目标后
+func restrictBarkTargets(groups []targetGroup, targets []string) []targetGroup {
+	allowed := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		allowed[target] = true
+	}
+	known := map[string]bool{}
+	restricted := make([]targetGroup, len(groups))
+	for i, g := range groups {
+		restricted[i] = targetGroup{localeData: g.localeData, Chats: g.Chats}
+		for _, target := range g.Bark {
+			known[target] = true
+			if allowed[target] {
+				restricted[i].Bark = append(restricted[i].Bark, target)
+			}
+		}
+	}
+	var extra []string
+	for _, target := range targets {
+		if !known[target] {
+			known[target] = true
+			extra = append(extra, target)
+		}
+	}
+	if len(extra) > 0 {
+		restricted = append(restricted, targetGroup{localeData: defaultLocale(), Bark: extra})
+	}
+	return restricted
+}
+
+// Telegram 会话的语言和时区，使用第一个（按名称排序）包含该会话的订阅者的设置，没有时使用默认值
+func chatLocale(ctx context.Context, chatID int64) localeData {
+	subscribers, err := listSubscribers(ctx)
+	if err != nil {
+		slog.Error("Failed to load subscribers", "error", err)
+		return defaultLocale()
+	}
+	for _, s := range subscribers {
+		if _, chats := s.targets(ctx); containsChat(chats, chatID) {
+			return s.locale()
+		}
+	}
+	return defaultLocale()
+}
+
+// 所有订阅者与 telegram.chatIDs 中的 Telegram 会话，不做过滤，用于自检
+func allTelegramChats(ctx context.Context) []int64 {
+	subscribers, err := listSubscribers(ctx)
+	if err != nil {
+		slog.Error("Failed to load subscribers", "error", err)
+	}
+	var chats []int64
+	seen := map[int64]bool{}
+	for _, s := range subscribers {
+		_, c := s.targets(ctx)
+		for _, chat := range c {
+			if !seen[chat] {
+				seen[chat] = true
+				chats = append(chats, chat)
+			}
+		}
+	}
+	for _, chat := range getTelegramConfig().ChatIDs {
+		if !seen[chat] {
+			seen[chat] = true
+			chats = append(chats, chat)
+		}
+	}
+	return chats
+}
+
+// 所有订阅者的 Bark 地址，不做过滤，用于自检和看门狗等运维推送
+func allBarkTargets(ctx context.Context) []string {
+	subscribers, err := listSubscribers(ctx)
+	if err != nil {
+		slog.Error("Failed to load subscribers, using barkAPIURLs", "error", err)
+		return getBarkAPIURLs()
+	}
+	var targets []string
+	seen := map[string]bool{}
+	for _, s := range subscribers {
+		bark, _ := s.targets(ctx)
+		for _, target := range bark {
+			if !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets
+}
+
+func saveSubscriber(ctx context.Context, s Subscriber) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now().UTC()
+	}
+	return saveSetting(ctx, settingsSubscribers, s.Name, s)
+}
+
+func removeSubscriber(ctx context.Context, name string) error {
+	stored, err := loadSettings[Subscriber](ctx, settingsSubscribers)
+	if err != nil {
+		return err
+	}
+	if _, ok := stored[name]; !ok {
+		return fmt.Errorf("subscriber %q not found", name)
+	}
+	return getStore().DeleteSetting(ctx, settingsSubscribers, name)
+}
+
+// 展示用的订阅者，隐藏 Bark 密钥
+func maskedSubscriber(s Subscriber) Subscriber {
+	channels := make([]string, len(s.Channels))
+	for i, channel := range s.Channels {
+		if strings.HasPrefix(channel, telegramChannelPrefix) || isSecretRef(channel) {
+			channels[i] = channel
+		} else {
+			channels[i] = maskTarget(channel)
+		}
+	}
+	s.Channels = channels
+	return s
+}
+
+// 字符串列表参数，可重复指定
+type stringsFlag []string
+
+func (f *stringsFlag) String() string     { return strings.Join(*f, ",") }
+func (f *stringsFlag) Set(v string) error { *f = append(*f, v); return nil }
+
+// RunSubscribers 维护订阅者
+//
+//	subscribers list
+//	subscribers add -name alice -channel https://api.day.app/KEY/ -channel telegram:123 [-min-volume 50000] [-quiet 23:00-07:00] [-lang en] [-tz America/New_York] [-time-format relative]
+//	subscribers remove <name>
+func RunSubscribers(args []string) error {
+	defer CloseStore()
+	ctx, stop := commandContext()
+	defer stop()
+	if len(args) == 0 {
+		return fmt.Errorf("usage: subscribers list | add -name <name> -channel <channel>... | remove <name>")
+	}
+
+	switch args[0] {
+	case "list":
+		subscribers, err := listSubscribers(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tCHANNELS\tMIN VOLUME\tQUIET\tLANG\tTIMEZONE\tTIME FORMAT")
+		for _, s := range subscribers {
+			s = maskedSubscriber(s)
+			fmt.Fprintf(w, "%s\t%s\t%.0f\t%s\t%s\t%s\t%s\n", s.Name, strings.Join(s.Channels, ","), s.MinVolumeUSD,
+				orDash(s.QuietHours), orDash(s.Language), orDash(s.Timezone), orDash(s.TimeFormat))
+		}
+		return w.Flush()
+	case "add":
+		fs := flag.NewFlagSet("subscribers add", flag.ContinueOnError)
+		var s Subscriber
+		var channels stringsFlag
+		fs.StringVar(&s.Name, "name", "", "订阅者名称，已存在时覆盖")
+		fs.Var(&channels, "channel", "Bark 地址或 telegram:<会话 ID>，可重复指定")
+		fs.Float64Var(&s.MinVolumeUSD, "min-volume", 0, "成交额阈值（美元）")
+		fs.StringVar(&s.QuietHours, "quiet", "", "免打扰时段，例如 23:00-07:00")
+		fs.StringVar(&s.Language, "lang", "", "通知语言，例如 zh-CN、en-US")
+		fs.StringVar(&s.Timezone, "tz", "", "时区，例如 America/New_York")
+		fs.StringVar(&s.TimeFormat, "time-format", "", "时间格式：24h、12h、relative 或 Go 时间布局")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		s.Channels = channels
+		if err := saveSubscriber(ctx, s); err != nil {
+			return err
+		}
+		fmt.Printf("subscriber %s saved\n", s.Name)
+		return nil
+	case "remove":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: subscribers remove <name>")
+		}
+		if err := removeSubscriber(ctx, args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("subscriber %s removed\n", args[1])
+		return nil
+	default:
+		return fmt.Errorf("unknown subscribers command %q", args[0])
+	}
+}
+
+// 订阅者管理接口
+//
+//	GET    /subscribers         列出订阅者，Bark 密钥已隐藏
+//	PUT    /subscribers/{name}  新增或替换订阅者，请求体为 Subscriber
+//	DELETE /subscribers/{name}  删除订阅者
+func registerSubscriberHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /subscribers", func(w http.ResponseWriter, r *http.Request) {
+		subscribers, err := listSubscribers(r.Context())
+		for i := range subscribers {
+			subscribers[i] = maskedSubscriber(subscribers[i])
+		}
+		writeAdminJSON(w, subscribers, err)
+	})
+	mux.HandleFunc("PUT /subscribers/{name}", func(w http.ResponseWriter, r *http.Request) {
+		var s Subscriber
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&s); err != nil {
+			writeAdminJSON(w, nil, err)
+			return
+		}
+		s.Name = r.PathValue("name")
+		err := saveSubscriber(r.Context(), s)
+		writeAdminJSON(w, maskedSubscriber(s), err)
+	})
+	mux.HandleFunc("DELETE /subscribers/{name}", func(w http.ResponseWriter, r *http.Request) {
+		err := removeSubscriber(r.Context(), r.PathValue("name"))
+		writeAdminJSON(w, map[string]string{"removed": r.PathValue("name")}, err)
+	})
+}
@@ -0,0 +1,126 @@
+package logic
+
+import (
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// 交易方向，以 UNIBTC 为标的
+const (
+	directionBuy  = "buy"  // WBTC -> UNIBTC
+	directionSell = "sell" // UNIBTC -> WBTC
+)
+
+// SwapEvent 归一化后的 Swap，供过滤、格式化等环节共用。数量与价格使用十进制数，
+// 代币数量按精度移位得到，不经过二进制浮点数
+type SwapEvent struct {
+	Swap      *Swap
+	TokenIn   string
+	TokenOut  string
+	AmountIn  decimal.Decimal // 输入数量（代币单位）
+	AmountOut decimal.Decimal // 输出数量（代币单位）
+	Volume    decimal.Decimal // 成交额（美元），输入数量 * BTC 价格
+	Direction string
+	Time      time.Time
+
+	PoolPrice      *decimal.Decimal // 交易后池子价格（token1 / token0），无法计算时为 nil
+	ExecutionPrice *decimal.Decimal // 成交均价（token1 / token0），数量为 0 时为 nil
+	PriceImpact    *decimal.Decimal // 价格影响，比例值
+}
+
+// 价格的小数位
+const priceScale = 18
+
+// 没有 btcPrice 时使用的 BTC 价格
+var defaultBTCPrice = decimal.NewFromInt(100000)
+
+// VolumeUSD 以美元计的成交额
+func (e *SwapEvent) VolumeUSD() float64 {
+	return decimalFloat(e.Volume)
+}
+
+// 转换为最接近的 float64。经由十进制字符串解析，结果与 Decimal.Float64 相同，但不需要构造 big.Rat
+func decimalFloat(d decimal.Decimal) float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// 将 Swap 转换为 SwapEvent
+func normalizeSwap(swap *Swap) (*SwapEvent, error) {
+	amount0Raw, err := decimal.NewFromString(swap.Amount0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount0 %q: %w", swap.Amount0, err)
+	}
+	amount1Raw, err := decimal.NewFromString(swap.Amount1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount1 %q: %w", swap.Amount1, err)
+	}
+	wbtcPrice := defaultBTCPrice
+	if swap.BtcPrice != "" {
+		if wbtcPrice, err = decimal.NewFromString(swap.BtcPrice); err != nil {
+			return nil, fmt.Errorf("invalid btcPrice %q: %w", swap.BtcPrice, err)
+		}
+	}
+
+	token0, token1 := getPoolTokenMeta()
+	amount0 := amount0Raw.Shift(-int32(token0.Decimals))
+	amount1 := amount1Raw.Shift(-int32(token1.Decimals))
+
+	event := &SwapEvent{Swap: swap}
+	if amount0.Sign() < 0 {
+		event.AmountIn = amount1
+		event.AmountOut = amount0.Neg()
+		event.TokenIn = token1.Symbol
+		event.TokenOut = token0.Symbol
+		event.Direction = directionBuy
+	} else {
+		event.AmountIn = amount0
+		event.AmountOut = amount1.Neg()
+		event.TokenIn = token0.Symbol
+		event.TokenOut = token1.Symbol
+		event.Direction = directionSell
+	}
+	event.Volume = event.AmountIn.Mul(wbtcPrice)
+
+	if !amount0.IsZero() {
+		price := amount1.DivRound(amount0, priceScale).Abs()
+		event.ExecutionPrice = &price
+	}
+	poolPrice, impact, err := computePoolPrice(swap)
+	if err != nil {
+		slog.Debug("Failed to compute pool price", "transactionHash", swap.TransactionHash, "error", err)
+	}
+	if poolPrice != nil {
+		// 原始价格按两种代币的精度差换算为代币单位的价格
+		if price, err := decimalFromBigFloat(poolPrice); err == nil {
+			price = price.Shift(int32(token0.Decimals - token1.Decimals)).Round(priceScale)
+			event.PoolPrice = &price
+		}
+	}
+	if impact != nil {
+		if v, err := decimalFromBigFloat(impact); err == nil {
+			v = v.Round(priceScale)
+			event.PriceImpact = &v
+		}
+	}
+
+	timestamp, err := strconv.ParseInt(swap.BlockTimestamp, 10, 64)
+	if err != nil {
+		return event, fmt.Errorf("invalid blockTimestamp %q: %w", swap.BlockTimestamp, err)
+	}
+	event.Time = time.Unix(timestamp, 0)
+	return event, nil
+}
+
+// 将高精度浮点数转换为十进制数，保留 40 位有效数字
+func decimalFromBigFloat(f *big.Float) (decimal.Decimal, error) {
+	if f.IsInf() {
+		return decimal.Decimal{}, fmt.Errorf("infinite value")
+	}
+	return decimal.NewFromString(f.Text('e', 40))
+}
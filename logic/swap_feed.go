@@ -0,0 +1,153 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 对外发布的 Swap 事件，与通知使用同一份归一化数据
+type swapFeedEvent struct {
+	swapRecord
+	Tick      int32   `json:"tick"`
+	ExecPrice float64 `json:"execPrice,omitempty"` // 成交均价（token1 / token0）
+	ImpactBps float64 `json:"impactBps,omitempty"`
+	Chain     string  `json:"chain,omitempty"`
+	Pool      string  `json:"pool,omitempty"`
+}
+
+func newSwapFeedEvent(event *SwapEvent) swapFeedEvent {
+	feed := swapFeedEvent{
+		swapRecord: newSwapRecord(event),
+		Tick:       event.Swap.Tick,
+		Chain:      getExplorerConfig().Chain,
+		Pool:       strings.ToLower(getPoolConfig().Address),
+	}
+	if event.ExecutionPrice != nil {
+		feed.ExecPrice = decimalFloat(*event.ExecutionPrice)
+	}
+	if event.PriceImpact != nil {
+		impact := decimalFloat(*event.PriceImpact)
+		feed.ImpactBps = impact * 10000
+	}
+	return feed
+}
+
+// 订阅者处理不及时时丢弃的事件数量上限
+const swapFeedBuffer = 256
+
+// Swap 事件的订阅者
+type swapFeedHub struct {
+	mu          sync.Mutex
+	subscribers map[chan swapFeedEvent]struct{}
+	closed      bool
+}
+
+var swapFeed = &swapFeedHub{subscribers: make(map[chan swapFeedEvent]struct{})}
+
+// 订阅 Swap 事件，返回的函数用于取消订阅。停止时 channel 会被关闭
+func (h *swapFeedHub) subscribe() (<-chan swapFeedEvent, func()) {
+	ch := make(chan swapFeedEvent, swapFeedBuffer)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	h.subscribers[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[ch]; ok {
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// 发送给所有订阅者，订阅者缓冲已满时丢弃该事件，不阻塞处理流程
+func (h *swapFeedHub) publish(event swapFeedEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+			slog.Warn("Swap feed subscriber is too slow, dropping event", "transactionHash", event.TxHash)
+		}
+	}
+}
+
+// 关闭所有订阅，停止时调用，使长连接的请求结束
+func (h *swapFeedHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for ch := range h.subscribers {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+// 观察每笔 Swap 并发布给订阅者
+func publishSwapFeed(ctx context.Context, event *SwapEvent) {
+	swapFeed.publish(newSwapFeedEvent(event))
+}
+
+// 以 Server-Sent Events 推送 Swap 事件，每个事件的 data 为一行 JSON，id 为交易哈希
+//
+//	GET /stream?direction=buy&minVolume=10000
+func serveSwapStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAdminError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+	direction := r.URL.Query().Get("direction")
+	var minVolume float64
+	if value := r.URL.Query().Get("minVolume"); value != "" {
+		if _, err := fmt.Sscan(value, &minVolume); err != nil {
+			writeAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid minVolume %q", value))
+			return
+		}
+	}
+
+	events, unsubscribe := swapFeed.subscribe()
+	defer unsubscribe()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepalive := time.NewTicker(15 * time.Second)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if (direction != "" && !strings.EqualFold(direction, event.Direction)) || event.VolumeUSD < minVolume {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				slog.Error("Failed to encode swap event", "error", err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: swap\ndata: %s\n\n", event.TxHash, data)
+		}
+		flusher.Flush()
+	}
+}
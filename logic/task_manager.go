@@ -0,0 +1,308 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"messag-push/utils"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bamzi/jobrunner"
+	"github.com/robfig/cron/v3"
+)
+
+// 任务超时与失败处理配置，失败相关的次数为 0 时使用默认值，小于 0 表示关闭
+type TaskConfig struct {
+	DefaultTimeoutSeconds int               `json:"defaultTimeoutSeconds"` // 单次执行的默认超时时间
+	TimeoutSeconds        map[string]int    `json:"timeoutSeconds"`        // 按任务名称单独设置超时时间
+	BackoffAfterFailures  int               `json:"backoffAfterFailures"`  // 连续失败多少次后开始退避，默认 3
+	MaxBackoffSeconds     int               `json:"maxBackoffSeconds"`     // 退避时间上限，默认 300
+	AlertAfterFailures    int               `json:"alertAfterFailures"`    // 连续失败多少次后推送告警，默认 5
+	DrainTimeoutSeconds   int               `json:"drainTimeoutSeconds"`   // 停止时等待正在执行的任务结束的时间，默认 30
+	Overlap               map[string]string `json:"overlap"`               // 上一轮未结束时的处理方式 skip / queue，默认 skip
+}
+
+func getTaskConfig() TaskConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Tasks
+}
+
+func getTaskTimeout(name string) time.Duration {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	cfg := configData.Tasks
+	if seconds, ok := cfg.TimeoutSeconds[name]; ok && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return secondsOrDefault(cfg.DefaultTimeoutSeconds, 60)
+}
+
+func getTaskOverlap(name string) string {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	if configData.Tasks.Overlap[name] == utils.OverlapQueue {
+		return utils.OverlapQueue
+	}
+	return utils.OverlapSkip
+}
+
+func getTaskFailurePolicy() utils.FailurePolicy {
+	configMutex.RLock()
+	cfg := configData.Tasks
+	configMutex.RUnlock()
+	return utils.FailurePolicy{
+		BackoffAfter:   countOrDefault(cfg.BackoffAfterFailures, 3),
+		MaxBackoff:     secondsOrDefault(cfg.MaxBackoffSeconds, 300),
+		AlertThreshold: countOrDefault(cfg.AlertAfterFailures, 5),
+	}
+}
+
+func countOrDefault(count, def int) int {
+	if count < 0 {
+		return 0
+	}
+	if count == 0 {
+		return def
+	}
+	return count
+}
+
+// 任务连续失败或恢复时推送告警。任务的 context 可能已经超时，使用独立的 context
+func alertTaskFailure(name string, failures int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if failures == 0 {
+		sendAlert(ctx, i18nText("alert.taskRecovered", name), barkLevelActive)
+		return
+	}
+	// 错误信息中常包含地址等字符，只写入日志和错误上报
+	slog.Error("Task failing repeatedly", "name", name, "failures", failures, "error", err)
+	reportError(errorReport{
+		Kind:    errorKindTaskFailure,
+		Message: err.Error(),
+		Job:     name,
+		Context: map[string]string{"consecutive_failures": strconv.Itoa(failures)},
+	})
+	sendAlert(ctx, i18nText("alert.taskFailing", name, failures), barkLevelTimeSensitive)
+}
+
+// 已注册的定时任务，支持运行时暂停、恢复、立即执行和修改间隔
+type managedTask struct {
+	job      *utils.JobWrapper
+	interval time.Duration // 固定间隔任务的执行间隔
+	spec     string        // cron 任务的表达式
+	entryID  cron.EntryID
+	paused   bool
+}
+
+// 任务的运行状态，供管理接口和 tasks 命令展示
+type TaskInfo struct {
+	Name         string    `json:"name"`
+	Schedule     string    `json:"schedule"`
+	Paused       bool      `json:"paused"`
+	Next         time.Time `json:"next,omitempty"`
+	LastRun      time.Time `json:"lastRun,omitempty"`
+	Duration     string    `json:"duration,omitempty"`
+	LastError    string    `json:"lastError,omitempty"`
+	Failures     int       `json:"failures"`
+	Panics       int       `json:"panics"`
+	Running      bool      `json:"running"`
+	Skipped      int64     `json:"skipped"`
+	BackoffUntil time.Time `json:"backoffUntil,omitempty"`
+}
+
+var (
+	tasks      = make(map[string]*managedTask)
+	tasksMutex sync.Mutex
+)
+
+// 按固定间隔执行任务
+func scheduleEvery(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+	task := &managedTask{job: newTaskJob(name, fn), interval: interval}
+	task.schedule()
+	tasks[name] = task
+}
+
+// 按 cron 表达式执行任务
+func scheduleCron(name, spec string, fn func(ctx context.Context) error) error {
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+	task := &managedTask{job: newTaskJob(name, fn), spec: spec}
+	if err := task.schedule(); err != nil {
+		return err
+	}
+	tasks[name] = task
+	return nil
+}
+
+func newTaskJob(name string, fn func(ctx context.Context) error) *utils.JobWrapper {
+	// 多实例部署时只有主实例执行任务
+	run := func(ctx context.Context) error {
+		if !leading() {
+			return nil
+		}
+		return fn(ctx)
+	}
+	return utils.WrapJob(name, run).
+		WithTimeout(func() time.Duration { return getTaskTimeout(name) }).
+		WithFailurePolicy(getTaskFailurePolicy, alertTaskFailure).
+		WithOverlap(func() string { return getTaskOverlap(name) }).
+		WithPanicHandler(reportJobPanic)
+}
+
+// 加入调度，cron 表达式无效时返回错误，不加入调度。
+// 直接调度 JobWrapper 而不经过 jobrunner.New：后者对同一任务加锁，重叠的调度会排队等待，
+// 到不了 JobWrapper 的 skip/queue 处理
+func (t *managedTask) schedule() error {
+	var schedule cron.Schedule = cron.Every(t.interval)
+	if t.spec != "" {
+		parsed, err := cron.ParseStandard(t.spec)
+		if err != nil {
+			return fmt.Errorf("invalid cron spec %q for task %s: %w", t.spec, t.job.Name(), err)
+		}
+		schedule = parsed
+	}
+	t.entryID = jobrunner.MainCron.Schedule(schedule, t.job)
+	return nil
+}
+
+func (t *managedTask) info() TaskInfo {
+	status := t.job.Status()
+	info := TaskInfo{Name: t.job.Name(), Schedule: t.spec, Paused: t.paused, LastRun: status.LastRun}
+	if t.spec == "" {
+		info.Schedule = "every " + t.interval.String()
+	}
+	if !t.paused {
+		info.Next = jobrunner.MainCron.Entry(t.entryID).Next
+	}
+	if !status.LastRun.IsZero() {
+		info.Duration = status.Duration.String()
+	}
+	if status.LastErr != nil {
+		info.LastError = status.LastErr.Error()
+	}
+	info.Failures, info.Panics = status.Failures, status.Panics
+	info.Running, info.Skipped = status.Running, status.Skipped
+	if status.NextAttempt.After(time.Now()) {
+		info.BackoffUntil = status.NextAttempt
+	}
+	return info
+}
+
+func lookupTask(name string) (*managedTask, error) {
+	task, ok := tasks[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown task %q", name)
+	}
+	return task, nil
+}
+
+// 按名称排序返回所有任务
+func listTasks() []TaskInfo {
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+	infos := make([]TaskInfo, 0, len(tasks))
+	for _, task := range tasks {
+		infos = append(infos, task.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// 暂停任务，正在执行的一轮会继续执行完
+func pauseTask(name string) (TaskInfo, error) {
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+	task, err := lookupTask(name)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	if !task.paused {
+		jobrunner.Remove(task.entryID)
+		task.paused = true
+		slog.Info("Task paused", "name", name)
+	}
+	return task.info(), nil
+}
+
+func resumeTask(name string) (TaskInfo, error) {
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+	task, err := lookupTask(name)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	if task.paused {
+		if err := task.schedule(); err != nil {
+			return TaskInfo{}, err
+		}
+		task.paused = false
+		slog.Info("Task resumed", "name", name)
+	}
+	return task.info(), nil
+}
+
+// 立即在后台执行一次，不影响原有的调度
+func triggerTask(name string) (TaskInfo, error) {
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+	task, err := lookupTask(name)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	task.job.ResetBackoff()
+	go task.job.Run()
+	slog.Info("Task triggered", "name", name)
+	return task.info(), nil
+}
+
+// 修改固定间隔任务的执行间隔，只在本次运行期间有效，重启后恢复配置文件中的值
+func setTaskInterval(name string, interval time.Duration) (TaskInfo, error) {
+	if interval < time.Second {
+		return TaskInfo{}, fmt.Errorf("interval must be at least 1s")
+	}
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+	task, err := lookupTask(name)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	if task.spec != "" {
+		return TaskInfo{}, fmt.Errorf("task %q is scheduled by cron spec %q", name, task.spec)
+	}
+	task.interval = interval
+	if !task.paused {
+		jobrunner.Remove(task.entryID)
+		task.schedule()
+	}
+	slog.Info("Task interval changed", "name", name, "interval", interval)
+	return task.info(), nil
+}
+
+// 修改 cron 任务的表达式，表达式无效时保留原有调度并返回错误。任务不存在时忽略
+func setTaskSpec(name, spec string) error {
+	if _, err := cron.ParseStandard(spec); err != nil {
+		return fmt.Errorf("invalid cron spec %q for task %s: %w", spec, name, err)
+	}
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+	task, ok := tasks[name]
+	if !ok || task.spec == spec {
+		return nil
+	}
+	task.spec = spec
+	if !task.paused {
+		jobrunner.Remove(task.entryID)
+		if err := task.schedule(); err != nil {
+			return err
+		}
+	}
+	slog.Info("Task schedule changed", "name", name, "spec", spec)
+	return nil
+}
@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bamzi/jobrunner"
+	"messag-push/utils"
+)
+
+// 上一轮未结束时再次调度，应按 tasks.overlap 跳过或排队，而不是阻塞等待上一轮结束
+func TestScheduledTaskOverlap(t *testing.T) {
+	if jobrunner.MainCron == nil {
+		jobrunner.Start()
+	}
+	quietLogs(t)
+	previous := getConfigSnapshot()
+	t.Cleanup(func() { applyConfig(previous) })
+
+	tests := []struct {
+		overlap     string
+		wantRuns    int32
+		wantSkipped int64
+	}{
+		{utils.OverlapSkip, 1, 1},
+		{utils.OverlapQueue, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.overlap, func(t *testing.T) {
+			name := "overlap_" + tt.overlap
+			cfg := Config{}
+			cfg.Tasks.Overlap = map[string]string{name: tt.overlap}
+			applyConfig(cfg)
+
+			started := make(chan struct{}, 2)
+			release := make(chan struct{})
+			var runs atomic.Int32
+			scheduleEvery(name, time.Hour, func(ctx context.Context) error {
+				runs.Add(1)
+				started <- struct{}{}
+				<-release
+				return nil
+			})
+			task := tasks[name]
+			t.Cleanup(func() {
+				jobrunner.Remove(task.entryID)
+				tasksMutex.Lock()
+				delete(tasks, name)
+				tasksMutex.Unlock()
+			})
+
+			// 按调度器实际调用的方式触发两次
+			job := jobrunner.MainCron.Entry(task.entryID).Job
+			go job.Run()
+			<-started
+			second := make(chan struct{})
+			go func() {
+				job.Run()
+				close(second)
+			}()
+			select {
+			case <-second:
+			case <-time.After(time.Second):
+				t.Fatal("overlapping run blocked until the previous run finished")
+			}
+			close(release)
+
+			deadline := time.Now().Add(5 * time.Second)
+			for task.job.Status().Running && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			status := task.job.Status()
+			if status.Running {
+				t.Fatal("task still running")
+			}
+			if runs.Load() != tt.wantRuns || status.Skipped != tt.wantSkipped {
+				t.Fatalf("got %d runs and %d skipped, want %d and %d", runs.Load(), status.Skipped, tt.wantRuns, tt.wantSkipped)
+			}
+		})
+	}
+}
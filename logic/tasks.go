@@ -1,12 +1,131 @@
 package logic
 
 import (
+	"context"
 	"github.com/bamzi/jobrunner"
+	"log/slog"
 	"messag-push/utils"
+	"strconv"
 	"time"
 )
 
+// StopTasks 停止调度，等待正在执行的任务（包括发送中的通知）结束，超过 drainTimeoutSeconds
+// 后取消剩余任务，最后释放主备锁并写入、关闭存储
+func StopTasks() {
+	timeout := secondsOrDefault(getTaskConfig().DrainTimeoutSeconds, 30)
+	slog.Info("Stopping tasks", "drainTimeout", timeout)
+	drained := jobrunner.MainCron.Stop()
+	select {
+	case <-drained.Done():
+		slog.Info("All running tasks finished")
+	case <-time.After(timeout):
+		slog.Warn("Drain timeout reached, cancelling running tasks")
+		utils.CancelJobs()
+		select {
+		case <-drained.Done():
+		case <-time.After(5 * time.Second):
+			slog.Error("Tasks did not stop after cancellation")
+		}
+	}
+	utils.CancelJobs()
+	drainDeliveryQueues(timeout)
+	waitExecHooks(timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	swapFeed.close()
+	stopHTTPServers(ctx)
+	stopGRPCServer(ctx)
+	stopKafkaOutput()
+	stopNATSOutput()
+	stopRedisOutput()
+	stopAMQPOutput()
+	stopLeaderElection()
+	shutdownTracing(ctx)
+	flushErrorReporting(2 * time.Second)
+	flushStore()
+	if err := CloseStore(); err != nil {
+		slog.Error("Failed to close store", "error", err)
+	}
+	slog.Info("Stopped")
+}
+
 func StartTasks() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	loadTokenMetadata(ctx)
+	cancel()
+	initTracing(context.Background())
+	initErrorReporting()
+	startLeaderElection()
 	jobrunner.Start()
-	jobrunner.Every(1*time.Second, utils.WrapJob("graph_task", GraphTask))
+	scheduleEvery("graph_task", 1*time.Second, pollAdaptively(GraphTask))
+	scheduleEvery("watchdog", 1*time.Minute, WatchdogTask)
+	retention := getRetentionConfig()
+	scheduleEvery("storage_prune", time.Duration(retention.PruneIntervalMinutes)*time.Minute, StoragePruneTask)
+	scheduleEvery("storage_compact", time.Duration(retention.CompactIntervalHours)*time.Hour, StorageCompactTask)
+	if cfg := getTVLConfig(); cfg.Enabled {
+		scheduleEvery("pool_tvl", secondsOrDefault(cfg.IntervalSeconds, 60), PoolTVLTask)
+	}
+	if cfg := getPriceHistoryConfig(); cfg.PollSeconds > 0 {
+		scheduleEvery("price_poll", secondsOrDefault(cfg.PollSeconds, 60), PricePollTask)
+	}
+	if cfg := getTelegramConfig(); cfg.Commands && cfg.BotToken != "" {
+		scheduleEvery("telegram_bot", 1*time.Second, TelegramBotTask)
+	}
+	if cfg := getLPConfig(); cfg.Enabled {
+		interval := cfg.IntervalMinutes
+		if interval <= 0 {
+			interval = 360
+		}
+		scheduleEvery("lp_range", 1*time.Minute, LPRangeTask)
+		scheduleEvery("lp_report", time.Duration(interval)*time.Minute, LPReportTask)
+	}
+
+	if cfg := getDigestConfig(); cfg.Enabled {
+		spec, err := dailyCronSpec(cfg.Time, cfg.Timezone, "*")
+		if err == nil {
+			err = scheduleCron("daily_digest", spec, DailyDigestTask)
+		}
+		if err != nil {
+			slog.Error("Failed to schedule daily digest", "error", err)
+		}
+	}
+	if cfg := getDigestConfig(); cfg.WeeklyEnabled {
+		spec, err := dailyCronSpec(cfg.Time, cfg.Timezone, strconv.Itoa(cfg.WeeklyDay))
+		if err == nil {
+			err = scheduleCron("weekly_report", spec, WeeklyReportTask)
+		}
+		if err != nil {
+			slog.Error("Failed to schedule weekly report", "error", err)
+		}
+	}
+	startAdminServer()
+	startHealthServer()
+	startDebugServer()
+	startGRPCServer()
+	startKafkaOutput()
+	startNATSOutput()
+	startRedisOutput()
+}
+
+// 配置重新加载后按新的时间调度日报和周报，表达式无效时记录错误并保留原有调度。
+// 启用或关闭日报、周报需要重启
+func rescheduleDigestTasks() {
+	cfg := getDigestConfig()
+	days := make(map[string]string)
+	if cfg.Enabled {
+		days["daily_digest"] = "*"
+	}
+	if cfg.WeeklyEnabled {
+		days["weekly_report"] = strconv.Itoa(cfg.WeeklyDay)
+	}
+	for name, day := range days {
+		spec, err := dailyCronSpec(cfg.Time, cfg.Timezone, day)
+		if err == nil {
+			err = setTaskSpec(name, spec)
+		}
+		if err != nil {
+			slog.Error("Invalid schedule in reloaded config, keeping previous schedule", "task", name, "error", err)
+		}
+	}
 }
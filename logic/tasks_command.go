@@ -0,0 +1,138 @@
+package logic
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// RunTasksCommand 通过管理接口管理正在运行的进程中的任务
+//
+//	tasks list                   列出任务
+//	tasks pause <name>           暂停任务
+//	tasks resume <name>          恢复任务
+//	tasks run <name>             立即执行一次
+//	tasks interval <name> <dur>  修改执行间隔，例如 5s、10m
+func RunTasksCommand(args []string) error {
+	cfg := getAdminConfig()
+	if cfg.Listen == "" {
+		return fmt.Errorf("admin server is disabled, set admin.listen in %s", configFile)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("usage: tasks list | tasks pause|resume|run <name> | tasks interval <name> <duration>")
+	}
+
+	var tasks []TaskInfo
+	switch args[0] {
+	case "list":
+		if err := adminRequest(cfg, http.MethodGet, "/tasks", &tasks); err != nil {
+			return err
+		}
+	case "pause", "resume", "run":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: tasks %s <name>", args[0])
+		}
+		var task TaskInfo
+		if err := adminRequest(cfg, http.MethodPost, "/tasks/"+url.PathEscape(args[1])+"/"+args[0], &task); err != nil {
+			return err
+		}
+		tasks = append(tasks, task)
+	case "interval":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: tasks interval <name> <duration>")
+		}
+		var task TaskInfo
+		path := "/tasks/" + url.PathEscape(args[1]) + "/interval?every=" + url.QueryEscape(args[2])
+		if err := adminRequest(cfg, http.MethodPost, path, &task); err != nil {
+			return err
+		}
+		tasks = append(tasks, task)
+	default:
+		return fmt.Errorf("unknown tasks command %q", args[0])
+	}
+	return printTasks(tasks)
+}
+
+// 命令行使用的 admin 范围令牌
+func adminCredential(cfg AdminConfig) string {
+	if cfg.Token != "" {
+		return cfg.Token
+	}
+	for _, t := range cfg.Tokens {
+		if t.Scope == scopeAdmin {
+			return secretValue(t.Token)
+		}
+	}
+	return ""
+}
+
+func adminRequest(cfg AdminConfig, method, path string, result interface{}) error {
+	scheme := "http://"
+	client := &http.Client{Timeout: 10 * time.Second}
+	if cfg.TLS.enabled() {
+		// 通过监听地址访问本机进程，证书中的域名通常不匹配：ACME 证书按第一个域名校验，证书文件不校验
+		scheme = "https://"
+		tlsConfig := &tls.Config{InsecureSkipVerify: true}
+		if len(cfg.TLS.ACMEDomains) > 0 {
+			tlsConfig = &tls.Config{ServerName: cfg.TLS.ACMEDomains[0]}
+		}
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+	req, err := http.NewRequest(method, scheme+cfg.Listen+path, nil)
+	if err != nil {
+		return err
+	}
+	if token := adminCredential(cfg); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("%s: %s", resp.Status, body.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func printTasks(tasks []TaskInfo) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSCHEDULE\tSTATE\tNEXT\tLAST RUN\tDURATION\tFAILURES\tSKIPPED\tLAST ERROR")
+	for _, task := range tasks {
+		state, next, lastRun := "active", "-", "-"
+		if task.Running {
+			state = "running"
+		}
+		if task.Paused {
+			state = "paused"
+		} else if !task.BackoffUntil.IsZero() {
+			state = "backoff"
+			next = task.BackoffUntil.Format(time.RFC3339)
+		} else if !task.Next.IsZero() {
+			next = task.Next.Format(time.RFC3339)
+		}
+		if !task.LastRun.IsZero() {
+			lastRun = task.LastRun.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\n", task.Name, task.Schedule, state, next, lastRun,
+			orDash(task.Duration), task.Failures, task.Skipped, orDash(task.LastError))
+	}
+	return w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,377 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Telegram 配置，通知发送到 chatIDs 中的会话，开启 commands 后这些会话可以通过命令查询状态和修改会话设置
+type TelegramConfig struct {
+	BotToken string  `json:"botToken"` // 机器人令牌，支持密钥引用
+	ChatIDs  []int64 `json:"chatIDs"`  // 接收通知的会话，也只响应这些会话中的命令
+	Commands bool    `json:"commands"` // 是否接收命令
+	APIURL   string  `json:"apiURL"`   // 默认 https://api.telegram.org
+	Format   string  `json:"format"`   // 通知格式：plain / markdown / html，默认 markdown
+}
+
+func getTelegramConfig() TelegramConfig {
+	configMutex.RLock()
+	cfg := configData.Telegram
+	configMutex.RUnlock()
+	cfg.BotToken = secretValue(cfg.BotToken)
+	if cfg.APIURL == "" {
+		cfg.APIURL = "https://api.telegram.org"
+	}
+	switch cfg.Format {
+	case formatPlain, formatMarkdown, formatHTML:
+	default:
+		cfg.Format = formatMarkdown
+	}
+	return cfg
+}
+
+// 会话设置，通过命令修改并保存在存储中
+type telegramChatSettings struct {
+	MinVolumeUSD float64   `json:"minVolumeUSD,omitempty"` // 高于全局 limitPrice 时生效
+	MutedUntil   time.Time `json:"mutedUntil,omitempty"`
+}
+
+func loadChatSettings(ctx context.Context, chatID int64) telegramChatSettings {
+	settings, err := loadSettings[telegramChatSettings](ctx, settingsTelegramChats)
+	if err != nil {
+		slog.Error("Failed to load telegram chat settings", "error", err)
+	}
+	return settings[strconv.FormatInt(chatID, 10)]
+}
+
+func saveChatSettings(ctx context.Context, chatID int64, settings telegramChatSettings) error {
+	return saveSetting(ctx, settingsTelegramChats, strconv.FormatInt(chatID, 10), settings)
+}
+
+// getUpdates 长轮询的等待时间
+const telegramPollTimeout = 20 * time.Second
+
+// 调用 Telegram Bot API，错误中不包含带令牌的地址
+func telegramCall(ctx context.Context, cfg TelegramConfig, method string, params, result interface{}) error {
+	return telegramCallWith(ctx, getHTTPClient(), cfg, method, params, result)
+}
+
+// 长轮询使用的客户端：与共享客户端使用同一连接池，但不设置 readTimeout 总超时，由请求的 context 控制截止时间
+func telegramPollClient() *http.Client {
+	return &http.Client{Transport: getHTTPClient().Transport}
+}
+
+func telegramCallWith(ctx context.Context, client *http.Client, cfg TelegramConfig, method string, params, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimRight(cfg.APIURL, "/") + "/bot" + cfg.BotToken + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("invalid telegram api url")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("telegram %s: %w", method, urlErr.Err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	var reply struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		Description string          `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("telegram %s: %s", method, resp.Status)
+	}
+	if !reply.OK {
+		return fmt.Errorf("telegram %s: %s", method, reply.Description)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(reply.Result, result)
+}
+
+// Telegram 的 parse_mode，纯文本为空
+var telegramParseModes = map[string]string{
+	formatMarkdown: "MarkdownV2",
+	formatHTML:     "HTML",
+}
+
+// 发送消息，format 为消息的格式。模板产生的格式无法解析时去掉格式重发，避免丢失通知
+func sendTelegramMessage(ctx context.Context, cfg TelegramConfig, chatID int64, text, format string) (err error) {
+	defer func() { recordChannelResult("telegram", "chat "+strconv.FormatInt(chatID, 10), time.Now(), err) }()
+	ctx = withChaosTarget(ctx, channelTelegram)
+	text = truncateMessage(text, format, channelLimit(channelTelegram), telegramLength)
+	params := map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}
+	mode := telegramParseModes[format]
+	if mode == "" {
+		return telegramCall(ctx, cfg, "sendMessage", params, nil)
+	}
+	params["parse_mode"] = mode
+	err = telegramCall(ctx, cfg, "sendMessage", params, nil)
+	if err != nil && strings.Contains(err.Error(), "can't parse entities") {
+		slog.Warn("Telegram rejected formatted message, sending as plain text", "chat", chatID, "error", err)
+		delete(params, "parse_mode")
+		err = telegramCall(ctx, cfg, "sendMessage", params, nil)
+	}
+	return err
+}
+
+// 推送到指定会话，跳过已静音的会话。event 不为空时按会话的成交额阈值过滤。
+// message 需已按 telegram.format 格式化，见 renderMessage 和 escapeText
+func pushTelegram(ctx context.Context, event *SwapEvent, message string, chats []int64) {
+	cfg := getTelegramConfig()
+	if cfg.BotToken == "" {
+		return
+	}
+	now := time.Now()
+	for _, chatID := range chats {
+		settings := loadChatSettings(ctx, chatID)
+		if now.Before(settings.MutedUntil) {
+			continue
+		}
+		if event != nil && event.VolumeUSD() < settings.MinVolumeUSD {
+			continue
+		}
+		if err := sendTelegramMessage(ctx, cfg, chatID, message, cfg.Format); err != nil {
+			slog.Error("Telegram notification failed", "chat", chatID, "error", err)
+		}
+	}
+}
+
+// 下一次拉取的更新序号，Telegram 会保留未确认的更新，重启后继续处理
+var telegramOffset int64
+
+// 长轮询比调度间隔长，上一次拉取未结束时直接跳过
+var telegramPolling atomic.Bool
+
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+// TelegramBotTask 拉取并处理会话中的命令
+func TelegramBotTask(ctx context.Context) error {
+	cfg := getTelegramConfig()
+	if cfg.BotToken == "" || !cfg.Commands {
+		return nil
+	}
+	if !telegramPolling.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer telegramPolling.Store(false)
+
+	// 截止时间比等待时间多留 10 秒，没有新命令时服务端在等待结束后正常返回空列表
+	pollCtx, cancel := context.WithTimeout(ctx, telegramPollTimeout+10*time.Second)
+	defer cancel()
+	var updates []telegramUpdate
+	err := telegramCallWith(pollCtx, telegramPollClient(), cfg, "getUpdates", map[string]interface{}{
+		"offset":          telegramOffset,
+		"timeout":         int(telegramPollTimeout / time.Second),
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	if err != nil {
+		return err
+	}
+	for _, update := range updates {
+		telegramOffset = update.UpdateID + 1
+		if update.Message == nil || !strings.HasPrefix(update.Message.Text, "/") {
+			continue
+		}
+		chatID := update.Message.Chat.ID
+		if !containsChat(cfg.ChatIDs, chatID) {
+			slog.Warn("Ignoring telegram command from unknown chat", "chat", chatID)
+			continue
+		}
+		reply := handleTelegramCommand(ctx, chatID, update.Message.Text, time.Now())
+		if err := sendTelegramMessage(ctx, cfg, chatID, reply, formatPlain); err != nil {
+			slog.Error("Failed to reply telegram command", "chat", chatID, "error", err)
+		}
+	}
+	return nil
+}
+
+func containsChat(chats []int64, chatID int64) bool {
+	for _, id := range chats {
+		if id == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+// 处理一条命令，返回回复内容。回复使用包含该会话的订阅者的语言和时区
+func handleTelegramCommand(ctx context.Context, chatID int64, text string, now time.Time) string {
+	fields := strings.Fields(text)
+	command, _, _ := strings.Cut(strings.ToLower(fields[0]), "@")
+	args := fields[1:]
+	locale := chatLocale(ctx, chatID)
+	lang := locale.Lang
+
+	switch command {
+	case "/start", "/help":
+		return translate(lang, "telegram.help")
+	case "/status":
+		return telegramStatus(ctx, locale, chatID, now)
+	case "/last":
+		n := 5
+		if len(args) > 0 {
+			parsed, err := strconv.Atoi(args[0])
+			if err != nil || parsed <= 0 {
+				return translate(lang, "telegram.usage", "/last [n]")
+			}
+			n = min(parsed, 20)
+		}
+		return telegramLastSwaps(ctx, locale, n, now)
+	case "/price":
+		return telegramPrice(locale, now)
+	case "/threshold":
+		return telegramThreshold(ctx, lang, chatID, args)
+	case "/mute":
+		duration := time.Hour
+		if len(args) > 0 {
+			if args[0] == "off" {
+				return telegramUnmute(ctx, lang, chatID)
+			}
+			parsed, err := parseDays(args[0])
+			if err != nil || parsed <= 0 {
+				return translate(lang, "telegram.usage", "/mute [2h|1d|off]")
+			}
+			duration = parsed
+		}
+		settings := loadChatSettings(ctx, chatID)
+		settings.MutedUntil = now.Add(duration)
+		if err := saveChatSettings(ctx, chatID, settings); err != nil {
+			return translate(lang, "telegram.saveFailed", err.Error())
+		}
+		return translate(lang, "telegram.muted", formatChatTime(settings.MutedUntil, locale))
+	case "/unmute":
+		return telegramUnmute(ctx, lang, chatID)
+	default:
+		return translate(lang, "telegram.unknownCommand") + "\n" + translate(lang, "telegram.help")
+	}
+}
+
+func telegramStatus(ctx context.Context, locale localeData, chatID int64, now time.Time) string {
+	lang := locale.Lang
+	status := serviceStatus(ctx, now)
+	role := translate(lang, "telegram.leader")
+	if !status.Leader {
+		role = translate(lang, "telegram.standby")
+	}
+	lines := []string{
+		translate(lang, "telegram.uptime", role, status.Uptime),
+		translate(lang, "telegram.cursor", status.LastBlock),
+	}
+	if status.LastQuery != nil {
+		lines = append(lines, translate(lang, "telegram.lastQuery", now.Sub(*status.LastQuery).Round(time.Second)))
+	}
+	if status.NotifyPaused {
+		lines = append(lines, translate(lang, "telegram.paused", formatChatTime(*status.PausedUntil, locale)))
+	}
+	if len(status.TasksFailing) > 0 {
+		lines = append(lines, translate(lang, "telegram.tasksFailing", strings.Join(status.TasksFailing, ", ")))
+	}
+	settings := loadChatSettings(ctx, chatID)
+	if settings.MinVolumeUSD > 0 {
+		lines = append(lines, translate(lang, "telegram.chatThreshold", settings.MinVolumeUSD))
+	}
+	if now.Before(settings.MutedUntil) {
+		lines = append(lines, translate(lang, "telegram.chatMuted", formatChatTime(settings.MutedUntil, locale)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func telegramLastSwaps(ctx context.Context, locale localeData, n int, now time.Time) string {
+	records := newestFirst(querySwapHistory(ctx, now.AddDate(0, 0, -7), now.Add(time.Second)), n,
+		func(r swapRecord) time.Time { return r.Time })
+	if len(records) == 0 {
+		return translate(locale.Lang, "telegram.noSwaps")
+	}
+	lines := make([]string, len(records))
+	for i, r := range records {
+		lines[i] = fmt.Sprintf("%s %s %s %s -> %s %s %s", formatChatTime(r.Time, locale), r.Direction,
+			formatAmount(r.AmountIn), r.TokenIn, formatAmount(r.AmountOut), r.TokenOut, formatUSD(r.VolumeUSD))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func telegramPrice(locale localeData, now time.Time) string {
+	points := priceHistory.query(now.Add(-24*time.Hour), now.Add(time.Second))
+	if len(points) == 0 {
+		return translate(locale.Lang, "telegram.noPrices")
+	}
+	first, last := points[0], points[len(points)-1]
+	text := translate(locale.Lang, "telegram.price", formatPrice(last.Price), formatChatTime(last.Time, locale))
+	if first.Price > 0 && len(points) > 1 {
+		text += "\n" + translate(locale.Lang, "telegram.priceChange", (last.Price-first.Price)/first.Price*100)
+	}
+	return text
+}
+
+func telegramThreshold(ctx context.Context, lang string, chatID int64, args []string) string {
+	settings := loadChatSettings(ctx, chatID)
+	if len(args) == 0 {
+		if settings.MinVolumeUSD <= 0 {
+			return translate(lang, "telegram.thresholdDefault", getLimitPrice())
+		}
+		return translate(lang, "telegram.threshold", settings.MinVolumeUSD)
+	}
+	if args[0] == "off" {
+		settings.MinVolumeUSD = 0
+	} else {
+		value, err := strconv.ParseFloat(strings.TrimPrefix(args[0], "$"), 64)
+		if err != nil || value < 0 {
+			return translate(lang, "telegram.usage", "/threshold [usd|off]")
+		}
+		settings.MinVolumeUSD = value
+	}
+	if err := saveChatSettings(ctx, chatID, settings); err != nil {
+		return translate(lang, "telegram.saveFailed", err.Error())
+	}
+	if settings.MinVolumeUSD == 0 {
+		return translate(lang, "telegram.thresholdCleared")
+	}
+	return translate(lang, "telegram.thresholdSet", settings.MinVolumeUSD)
+}
+
+func telegramUnmute(ctx context.Context, lang string, chatID int64) string {
+	settings := loadChatSettings(ctx, chatID)
+	settings.MutedUntil = time.Time{}
+	if err := saveChatSettings(ctx, chatID, settings); err != nil {
+		return translate(lang, "telegram.saveFailed", err.Error())
+	}
+	return translate(lang, "telegram.unmuted")
+}
+
+// 按会话的时区输出时间
+func formatChatTime(t time.Time, locale localeData) string {
+	return t.In(locale.location()).Format("01-02 15:04")
+}
@@ -0,0 +1,334 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// 消息模板配置，使用 Go text/template 语法。模板名为 <类型>、<类型>.<格式> 或 <类型>.<渠道>，
+// 例如 swap、swap.markdown、alert.telegram，按渠道模板、渠道格式的模板、纯文本模板的顺序查找，
+// 使用纯文本模板时整条消息按渠道格式转义。格式与转义规则见 message_format.go
+//
+// 以上模板渲染通知正文，标题和副标题使用 <类型>_title、<类型>_subtitle，例如 swap_title、
+// alert_title.telegram，没有对应模板时不设置，Bark 使用地址中的标题
+//
+// 类型：swap（单笔 Swap，数据见 swapTemplateData）、alert（系统告警）、digest（汇总），
+// 后两者的数据为 {Message, Level, Time}。渠道：bark（plain）、telegram（由 telegram.format 决定）。
+// 模板按接收者的语言渲染，{{.T "swap.volume"}} 输出语言包中的文本，.Lang 为当前语言
+//
+// verbosity 按渠道选择详细程度：compact 使用 <类型>_compact 模板（单行摘要），verbose 使用
+// <类型>_verbose 模板（附地址、tick、流动性、区块和 Gas 等明细），没有对应模板时使用正文模板
+type TemplatesConfig struct {
+	Dir       string            `json:"dir"`       // 模板目录，文件名为模板名加 .tmpl，例如 swap.telegram.tmpl
+	Inline    map[string]string `json:"inline"`    // 直接写在配置中的模板，优先于目录中的同名文件
+	Verbosity map[string]string `json:"verbosity"` // 渠道的详细程度：compact、standard 或 verbose，默认 standard
+	Limits    map[string]int    `json:"limits"`    // 渠道的长度上限，超出时截断，默认 bark 3000（地址字节数）、telegram 4096，负数表示不限制
+}
+
+func getTemplatesConfig() TemplatesConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.Templates
+}
+
+// 消息类型
+const (
+	templateSwap   = "swap"
+	templateAlert  = "alert"
+	templateDigest = "digest"
+)
+
+// 推送渠道
+const (
+	channelBark     = "bark"
+	channelTelegram = "telegram"
+)
+
+// 逐笔发布的输出，使用独立的推送队列
+const (
+	channelAMQP          = "amqp"
+	channelHomeAssistant = "homeassistant"
+)
+
+// 消息的详细程度
+const (
+	verbosityCompact  = "compact"
+	verbosityStandard = "standard"
+	verbosityVerbose  = "verbose"
+)
+
+// 渠道的详细程度，未配置或无效时为 standard
+func channelVerbosity(channel string) string {
+	switch mode := getTemplatesConfig().Verbosity[channel]; mode {
+	case verbosityCompact, verbosityVerbose:
+		return mode
+	default:
+		return verbosityStandard
+	}
+}
+
+// 内置模板，swap 与原有的固定格式一致，标签文本来自语言包，数字格式见 numbers 配置
+const defaultSwapTemplate = `{{.TimeText}}  {{amount .AmountIn}} {{.TokenIn}} -> {{amount .AmountOut}} {{.TokenOut}} {{.T "swap.volume"}}: {{usd .VolumeUSD}}` +
+	`{{with .Price}} {{$.T "swap.price"}}: {{price .}}{{end}}{{with .ImpactBps}} {{$.T "swap.impact"}}: {{fixed 1 .}}bps{{end}}{{with .SenderLabel}} {{$.T "swap.by"}}: {{.}}{{end}}` +
+	`{{.Receipt}}{{.TVL}}{{.Flow}}{{.Actor}}{{with .MEV}} {{$.T "swap.mev"}}: {{.}}{{end}}`
+
+// 单行摘要与完整明细的内置 swap 模板
+const (
+	defaultSwapCompactTemplate = `{{with .Emoji}}{{.}} {{end}}{{amount .AmountIn}} {{.TokenIn}} -> {{amount .AmountOut}} {{.TokenOut}} {{usd .VolumeUSD}}{{with .Price}} @ {{price .}}{{end}}`
+	defaultSwapVerboseTemplate = defaultSwapTemplate + "\n" +
+		`{{.T "swap.sender"}}: {{.Sender}}{{with .SenderLabel}} ({{.}}){{end}}` + "\n" +
+		`{{.T "swap.recipient"}}: {{.Recipient}}` + "\n" +
+		`{{.T "swap.tick"}}: {{.Tick}}{{with .Liquidity}} {{$.T "swap.liquidity"}}: {{.}}{{end}}` + "\n" +
+		`{{.T "swap.block"}}: {{.Block}} {{.T "swap.tx"}}: {{.TxHash}}{{with .Links.Tx}}` + "\n" + `{{.}}{{end}}`
+)
+
+var defaultTemplates = map[string]string{
+	templateSwap:                          defaultSwapTemplate,
+	templateSwap + "_" + verbosityCompact: defaultSwapCompactTemplate,
+	templateSwap + "_" + verbosityVerbose: defaultSwapVerboseTemplate,
+	templateSwap + "." + formatMarkdown:   defaultSwapMarkdownTemplate,
+	templateSwap + "." + formatHTML:       defaultSwapHTMLTemplate,
+	templateSwap + titleSuffix:            defaultSwapTitleTemplate,
+	templateSwap + subtitleSuffix:         defaultSwapSubtitleTemplate,
+	templateAlert:                         "{{.Message}}",
+	templateDigest:                        "{{.Message}}",
+}
+
+// Telegram 等支持富文本的渠道使用的内置模板，时间加粗，末尾附交易链接
+const (
+	defaultSwapMarkdownTemplate = `*{{.TimeText}}*` + "\n" + `{{amount .AmountIn}} {{.TokenIn}} \-\> {{amount .AmountOut}} {{.TokenOut}} {{.T "swap.volume"}}: {{usd .VolumeUSD}}` +
+		`{{with .Price}} {{$.T "swap.price"}}: {{price .}}{{end}}{{with .ImpactBps}} {{$.T "swap.impact"}}: {{fixed 1 .}}bps{{end}}{{with .SenderLabel}} {{$.T "swap.by"}}: *{{.}}*{{end}}` +
+		`{{.Receipt}}{{.TVL}}{{.Flow}}{{.Actor}}{{with .MEV}} {{$.T "swap.mev"}}: {{.}}{{end}}{{with .Links.Tx}}` + "\n" + `[{{$.T "swap.viewTx"}}]({{url .}}){{end}}`
+	defaultSwapHTMLTemplate = `<b>{{.TimeText}}</b>` + "\n" + `{{amount .AmountIn}} {{.TokenIn}} -&gt; {{amount .AmountOut}} {{.TokenOut}} {{.T "swap.volume"}}: {{usd .VolumeUSD}}` +
+		`{{with .Price}} {{$.T "swap.price"}}: {{price .}}{{end}}{{with .ImpactBps}} {{$.T "swap.impact"}}: {{fixed 1 .}}bps{{end}}{{with .SenderLabel}} {{$.T "swap.by"}}: <b>{{.}}</b>{{end}}` +
+		`{{.Receipt}}{{.TVL}}{{.Flow}}{{.Actor}}{{with .MEV}} {{$.T "swap.mev"}}: {{.}}{{end}}{{with .Links.Tx}}` + "\n" + `<a href="{{url .}}">{{$.T "swap.viewTx"}}</a>{{end}}`
+)
+
+// 模板中可用的函数
+var templateFuncs = template.FuncMap{
+	"fixed":   func(decimals int, v float64) string { return strconv.FormatFloat(v, 'f', decimals, 64) },
+	"amount":  formatAmount,
+	"usd":     formatUSD,
+	"price":   formatPrice,
+	"compact": compactUSD,
+	"short":   shortAddress,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"date": func(layout string, t time.Time) string {
+		return conditionTime(t).Format(layout)
+	},
+}
+
+// 模板中的语言、时区和时间格式，{{.T "key"}} 按该语言输出语言包中的文本
+type localeData struct {
+	Lang       string
+	Timezone   string // 为空时使用 i18n.timezone
+	TimeFormat string // 为空时使用 i18n.timeFormat
+}
+
+func (d localeData) T(key string) string { return translate(d.Lang, key) }
+
+// 单笔 Swap 的模板数据
+type swapTemplateData struct {
+	localeData
+	Time        time.Time
+	TimeText    string // 按接收者的时区和时间格式输出，默认 2006-01-02 15:04:05
+	TxHash      string
+	Block       string
+	Direction   string // buy / sell
+	TokenIn     string
+	TokenOut    string
+	AmountIn    float64
+	AmountOut   float64
+	VolumeUSD   float64
+	Price       *float64 // 成交均价（token1 / token0）
+	PoolPrice   *float64 // 交易后池子价格
+	ImpactBps   *float64
+	Tick        int32
+	Liquidity   string // 交易后池子的流动性
+	Sender      string
+	Recipient   string
+	SenderLabel string // 地址标签中的名称
+	Tier        string // 大额交易分级名称
+	Level       string // Bark 推送级别
+	Links       explorerLinks
+
+	// 按渠道填充的 emoji，见 EmojiConfig
+	Emoji          string // 分级与方向，例如 🐋🟢
+	DirectionEmoji string
+	TierEmoji      string
+	tierEmoji      string // 分级配置的 emoji
+
+	// 补充信息，按当前语言渲染，内置模板直接拼接在末尾
+	Receipt string // 交易回执摘要
+	TVL     string // 池子 TVL
+	Flow    string // 近期资金流向
+	Actor   string // 已知地址类型
+	MEV     string // MEV 标记
+}
+
+func newSwapTemplateData(ctx context.Context, event *SwapEvent) swapTemplateData {
+	amountIn := decimalFloat(event.AmountIn)
+	amountOut := decimalFloat(event.AmountOut)
+	data := swapTemplateData{
+		localeData:  defaultLocale(),
+		Time:        event.Time,
+		TxHash:      event.Swap.TransactionHash,
+		Block:       event.Swap.BlockNumber,
+		Direction:   event.Direction,
+		TokenIn:     event.TokenIn,
+		TokenOut:    event.TokenOut,
+		AmountIn:    amountIn,
+		AmountOut:   amountOut,
+		VolumeUSD:   event.VolumeUSD(),
+		Tick:        event.Swap.Tick,
+		Liquidity:   event.Swap.Liquidity,
+		Sender:      event.Swap.Sender,
+		Recipient:   event.Swap.Recipient,
+		SenderLabel: lookupAddressName(ctx, event.Swap.Sender),
+		Links:       swapExplorerLinks(event.Swap),
+	}
+	if event.ExecutionPrice != nil {
+		price := decimalFloat(*event.ExecutionPrice)
+		data.Price = &price
+	}
+	if event.PoolPrice != nil {
+		price := decimalFloat(*event.PoolPrice)
+		data.PoolPrice = &price
+	}
+	if event.PriceImpact != nil {
+		impact := decimalFloat(*event.PriceImpact)
+		impact *= 10000
+		data.ImpactBps = &impact
+	}
+	if tier := matchWhaleTier(event); tier != nil {
+		data.Tier, data.tierEmoji = tier.Name, tier.Emoji
+	}
+	data.TimeText = data.FormatTime(event.Time)
+	return data
+}
+
+// 系统告警与汇总的模板数据
+type messageTemplateData struct {
+	localeData
+	Message  string
+	Level    string
+	Time     time.Time
+	TimeText string
+}
+
+var (
+	compiledTemplates      map[string]*template.Template
+	compiledTemplatesMutex sync.Mutex
+)
+
+// 配置变更后丢弃已编译的模板
+func resetMessageTemplates() {
+	compiledTemplatesMutex.Lock()
+	defer compiledTemplatesMutex.Unlock()
+	compiledTemplates = nil
+}
+
+// 编译内置、目录与配置中的模板，无法编译的模板记录错误后使用内置模板
+func loadMessageTemplates() map[string]*template.Template {
+	compiledTemplatesMutex.Lock()
+	defer compiledTemplatesMutex.Unlock()
+	if compiledTemplates != nil {
+		return compiledTemplates
+	}
+	sources := messageTemplateSources()
+
+	compiled := make(map[string]*template.Template, len(sources))
+	for name, text := range sources {
+		tmpl, err := parseFormatTemplate(name, text)
+		if err != nil {
+			slog.Error("Failed to parse message template, using built-in", "template", name, "error", err)
+			if text, ok := defaultTemplates[name]; ok {
+				tmpl = template.Must(parseFormatTemplate(name, text))
+			} else {
+				continue
+			}
+		}
+		compiled[name] = tmpl
+	}
+	compiledTemplates = compiled
+	return compiled
+}
+
+// 内置、目录与配置中的模板源码，后者覆盖前者
+func messageTemplateSources() map[string]string {
+	sources := make(map[string]string, len(defaultTemplates))
+	for name, text := range defaultTemplates {
+		sources[name] = text
+	}
+	cfg := getTemplatesConfig()
+	if cfg.Dir != "" {
+		files, err := filepath.Glob(filepath.Join(cfg.Dir, "*.tmpl"))
+		if err != nil {
+			slog.Error("Failed to list message templates", "dir", cfg.Dir, "error", err)
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				slog.Error("Failed to read message template", "path", file, "error", err)
+				continue
+			}
+			sources[strings.TrimSuffix(filepath.Base(file), ".tmpl")] = strings.TrimRight(string(data), "\n")
+		}
+	}
+	for name, text := range cfg.Inline {
+		sources[name] = text
+	}
+	return sources
+}
+
+// 按渠道渲染消息，结果为渠道使用的格式，执行失败时依次尝试后面的模板。
+// 渠道配置了 compact 或 verbose 时优先使用对应的模板
+func renderMessage(kind, channel string, data interface{}) string {
+	if mode := channelVerbosity(channel); mode != verbosityStandard {
+		if text, ok := renderTemplate(kind+"_"+mode, channel, data); ok {
+			return text
+		}
+	}
+	if text, ok := renderTemplate(kind, channel, data); ok {
+		return text
+	}
+	return escapeText(channelFormat(channel), fmt.Sprint(data))
+}
+
+// 渲染模板时复用的缓冲
+var renderBufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// 按查找顺序执行模板，没有可用的模板时返回 false
+func renderTemplate(kind, channel string, data interface{}) (string, bool) {
+	format := channelFormat(channel)
+	templates := loadMessageTemplates()
+	names := [...]string{kind + "." + channel, kind + "." + format, kind}
+	buf := renderBufferPool.Get().(*bytes.Buffer)
+	defer renderBufferPool.Put(buf)
+	for _, name := range names {
+		tmpl := templates[name]
+		if tmpl == nil {
+			continue
+		}
+		buf.Reset()
+		if err := tmpl.Execute(buf, data); err != nil {
+			slog.Error("Failed to render message template", "template", name, "error", err)
+			continue
+		}
+		if name == kind {
+			return escapeText(format, buf.String()), true
+		}
+		return buf.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,116 @@
+package logic
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	_ "time/tzdata" // 内置时区数据，运行环境没有安装 tzdata 时也能加载配置的时区
+)
+
+// 时间格式，也可以直接使用 Go 的时间布局，例如 01-02 15:04
+const (
+	timeFormat24h      = "24h"      // 2006-01-02 15:04:05
+	timeFormat12h      = "12h"      // 2006-01-02 03:04:05 PM
+	timeFormatRelative = "relative" // 2分钟前，超过 7 天时使用 24h
+
+	defaultTimezone = "Asia/Shanghai"
+	layout24h       = "2006-01-02 15:04:05"
+	layout12h       = "2006-01-02 03:04:05 PM"
+)
+
+var locationCache sync.Map
+
+// 加载时区，结果缓存
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
+// 默认时区，配置无效时为 Asia/Shanghai
+func defaultLocation() *time.Location {
+	if name := getI18nConfig().Timezone; name != "" {
+		if loc, err := loadLocation(name); err == nil {
+			return loc
+		}
+	}
+	loc, err := loadLocation(defaultTimezone)
+	if err != nil {
+		return time.FixedZone("CST", 8*3600)
+	}
+	return loc
+}
+
+// 默认语言、时区和时间格式
+func defaultLocale() localeData {
+	cfg := getI18nConfig()
+	return localeData{Lang: defaultLanguage(), Timezone: cfg.Timezone, TimeFormat: cfg.TimeFormat}
+}
+
+// 接收者的时区，为空或无效时使用默认时区
+func (d localeData) location() *time.Location {
+	if d.Timezone != "" {
+		if loc, err := loadLocation(d.Timezone); err == nil {
+			return loc
+		}
+	}
+	return defaultLocation()
+}
+
+// 按接收者的时区和时间格式输出时间，{{.FormatTime .Time}}
+func (d localeData) FormatTime(t time.Time) string {
+	return formatTime(t, d, time.Now())
+}
+
+func formatTime(t time.Time, d localeData, now time.Time) string {
+	t = t.In(d.location())
+	format := d.TimeFormat
+	if format == "" {
+		format = getI18nConfig().TimeFormat
+	}
+	switch format {
+	case "", timeFormat24h:
+		return t.Format(layout24h)
+	case timeFormat12h:
+		return t.Format(layout12h)
+	case timeFormatRelative:
+		elapsed := now.Sub(t)
+		if elapsed < 0 || elapsed >= 7*24*time.Hour {
+			return t.Format(layout24h)
+		}
+		return relativeTime(d.Lang, elapsed)
+	default:
+		return t.Format(format)
+	}
+}
+
+// 相对时间，例如 刚刚、2分钟前、3小时前
+func relativeTime(lang string, elapsed time.Duration) string {
+	switch {
+	case elapsed < time.Minute:
+		return translate(lang, "time.justNow")
+	case elapsed < time.Hour:
+		return translate(lang, "time.minutesAgo", int(elapsed/time.Minute))
+	case elapsed < 24*time.Hour:
+		return translate(lang, "time.hoursAgo", int(elapsed/time.Hour))
+	default:
+		return translate(lang, "time.daysAgo", int(elapsed/(24*time.Hour)))
+	}
+}
+
+// 校验时区名称，空字符串表示使用默认时区
+func validateTimezone(timezone string) error {
+	if timezone == "" {
+		return nil
+	}
+	if _, err := loadLocation(timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	return nil
+}
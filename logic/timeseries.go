@@ -0,0 +1,136 @@
+package logic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 带时间戳的记录
+type timedRecord interface {
+	timestamp() time.Time
+}
+
+// 按时间正序保存的记录，可选持久化到 JSON Lines 文件
+type timeSeries[T timedRecord] struct {
+	mu      sync.Mutex
+	loaded  bool
+	records []T
+	config  func() (path string, retention time.Duration)
+}
+
+func newTimeSeries[T timedRecord](config func() (string, time.Duration)) *timeSeries[T] {
+	return &timeSeries[T]{config: config}
+}
+
+// 首次使用时从文件加载记录，调用方需持有写锁
+func (s *timeSeries[T]) load(path string, retention time.Duration) {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	if path == "" {
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Error("Failed to open history file", "path", path, "error", err)
+		}
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record T
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			slog.Error("Skip invalid history record", "path", path, "error", err)
+			continue
+		}
+		s.records = append(s.records, record)
+	}
+	s.sort()
+	s.prune(retention)
+}
+
+// 追加一条记录
+func (s *timeSeries[T]) append(record T) {
+	path, retention := s.config()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load(path, retention)
+
+	s.records = append(s.records, record)
+	if n := len(s.records); n > 1 && record.timestamp().Before(s.records[n-2].timestamp()) {
+		s.sort()
+	}
+	if s.prune(retention) {
+		s.rewrite(path)
+		return
+	}
+	if path == "" {
+		return
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		slog.Error("Failed to open history file", "path", path, "error", err)
+		return
+	}
+	defer file.Close()
+	if err := json.NewEncoder(file).Encode(record); err != nil {
+		slog.Error("Failed to write history record", "path", path, "error", err)
+	}
+}
+
+func (s *timeSeries[T]) sort() {
+	sort.SliceStable(s.records, func(i, j int) bool { return s.records[i].timestamp().Before(s.records[j].timestamp()) })
+}
+
+// 删除超出保留期的记录，返回是否有记录被删除
+func (s *timeSeries[T]) prune(retention time.Duration) bool {
+	cutoff := time.Now().Add(-retention)
+	idx := sort.Search(len(s.records), func(i int) bool { return !s.records[i].timestamp().Before(cutoff) })
+	if idx == 0 {
+		return false
+	}
+	s.records = append([]T(nil), s.records[idx:]...)
+	return true
+}
+
+// 用内存中的记录重写文件
+func (s *timeSeries[T]) rewrite(path string) {
+	if path == "" {
+		return
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range s.records {
+		if err := encoder.Encode(record); err != nil {
+			slog.Error("Failed to write history record", "path", path, "error", err)
+			return
+		}
+	}
+	if err := writeFileAtomic(path, buf.Bytes(), 0644); err != nil {
+		slog.Error("Failed to rewrite history file", "path", path, "error", err)
+	}
+}
+
+// 查询 [since, until) 区间内的记录
+func (s *timeSeries[T]) query(since, until time.Time) []T {
+	path, retention := s.config()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load(path, retention)
+
+	from := sort.Search(len(s.records), func(i int) bool { return !s.records[i].timestamp().Before(since) })
+	to := sort.Search(len(s.records), func(i int) bool { return !s.records[i].timestamp().Before(until) })
+	return append([]T(nil), s.records[from:to]...)
+}
@@ -0,0 +1,131 @@
+package logic
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	selectorSymbol   = "0x95d89b41" // symbol()
+	selectorDecimals = "0x313ce567" // decimals()
+)
+
+// 代币元数据
+type tokenMeta struct {
+	Address  string
+	Symbol   string
+	Decimals int
+}
+
+// 未配置池子或查询失败时使用的默认代币
+var (
+	defaultToken0 = tokenMeta{Symbol: "UNIBTC", Decimals: 8}
+	defaultToken1 = tokenMeta{Symbol: "WBTC", Decimals: 8}
+)
+
+var (
+	tokenMetaCache = newLookupCache[tokenMeta]("token_meta", true) // 代币地址 -> 元数据
+	poolToken0     = defaultToken0
+	poolToken1     = defaultToken1
+	tokenMetaMutex sync.RWMutex
+)
+
+// 代币元数据与池子代币的缓存时间
+func tokenMetadataTTL() time.Duration {
+	hours := getCacheConfig().TokenMetadataHours
+	if hours <= 0 {
+		hours = 168
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// 当前池子的 token0 和 token1
+func getPoolTokenMeta() (tokenMeta, tokenMeta) {
+	tokenMetaMutex.RLock()
+	defer tokenMetaMutex.RUnlock()
+	return poolToken0, poolToken1
+}
+
+// 启动时查询池子的代币元数据，失败时保留默认值
+func loadTokenMetadata(ctx context.Context) {
+	pool := getPoolConfig().Address
+	if pool == "" || getRPCURL(ctx) == "" {
+		return
+	}
+	tokens, err := getPoolTokens(ctx, pool)
+	if err != nil {
+		slog.Error("Failed to query pool tokens, using default token metadata", "pool", pool, "error", err)
+		return
+	}
+	token0, err := fetchTokenMeta(ctx, tokens.Token0)
+	if err != nil {
+		slog.Error("Failed to query token0 metadata", "token", tokens.Token0, "error", err)
+		return
+	}
+	token1, err := fetchTokenMeta(ctx, tokens.Token1)
+	if err != nil {
+		slog.Error("Failed to query token1 metadata", "token", tokens.Token1, "error", err)
+		return
+	}
+
+	tokenMetaMutex.Lock()
+	poolToken0, poolToken1 = token0, token1
+	tokenMetaMutex.Unlock()
+	slog.Info("Loaded token metadata", "token0", token0.Symbol, "decimals0", token0.Decimals,
+		"token1", token1.Symbol, "decimals1", token1.Decimals)
+}
+
+// 查询代币的 symbol 和 decimals，结果会被缓存
+func fetchTokenMeta(ctx context.Context, address string) (tokenMeta, error) {
+	address = strings.ToLower(address)
+	return tokenMetaCache.get(ctx, address, tokenMetadataTTL(), func() (tokenMeta, error) {
+		meta := tokenMeta{Address: address}
+		result, err := ethCall(ctx, address, selectorSymbol)
+		if err != nil {
+			return meta, err
+		}
+		if meta.Symbol, err = decodeSymbol(result); err != nil {
+			return meta, err
+		}
+		result, err = ethCall(ctx, address, selectorDecimals)
+		if err != nil {
+			return meta, err
+		}
+		decimals, err := decodeUint(result)
+		if err != nil {
+			return meta, err
+		}
+		meta.Decimals = int(decimals.Int64())
+		return meta, nil
+	})
+}
+
+// 部分早期代币的 symbol 返回 bytes32 而不是 string
+func decodeSymbol(result string) (string, error) {
+	raw := strings.TrimPrefix(result, "0x")
+	if len(raw) == 64 {
+		b, err := hex.DecodeString(raw)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\x00"), nil
+	}
+	return decodeString(result)
+}
+
+// 将原始精度的数量转换为代币单位
+func toTokenUnits(raw *big.Float, decimals int) *big.Float {
+	scale := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	return new(big.Float).Quo(raw, scale)
+}
+
+// 将原始精度的整数转换为代币单位的浮点数
+func tokenUnitsFloat(raw *big.Int, decimals int) float64 {
+	f, _ := toTokenUnits(new(big.Float).SetInt(raw), decimals).Float64()
+	return f
+}
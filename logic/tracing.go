@@ -0,0 +1,117 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 链路追踪配置，通过 OTLP/HTTP 导出到 Jaeger、Tempo 或 OpenTelemetry Collector
+type TracingConfig struct {
+	Enabled     bool              `json:"enabled"`
+	Endpoint    string            `json:"endpoint"`    // OTLP/HTTP 地址，例如 localhost:4318
+	Insecure    bool              `json:"insecure"`    // 使用 HTTP 而不是 HTTPS
+	Headers     map[string]string `json:"headers"`     // 附加请求头，例如鉴权令牌
+	ServiceName string            `json:"serviceName"` // 服务名称，默认为 message-push
+	SampleRatio float64           `json:"sampleRatio"` // 采样比例，默认 1 即全部采样
+}
+
+func getTracingConfig() TracingConfig {
+	configMutex.RLock()
+	cfg := configData.Tracing
+	headers := make(map[string]string, len(cfg.Headers))
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+	configMutex.RUnlock()
+	for k, v := range headers {
+		headers[k] = secretValue(v)
+	}
+	cfg.Headers = headers
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "message-push"
+	}
+	if cfg.SampleRatio <= 0 {
+		cfg.SampleRatio = 1
+	}
+	return cfg
+}
+
+// 未开启时使用 otel 默认的空实现，创建 span 没有开销
+var (
+	tracer         = otel.Tracer("messag-push/logic")
+	tracerProvider *sdktrace.TracerProvider
+	tracingMutex   sync.Mutex
+)
+
+// 按配置初始化链路追踪，修改配置后需要重启
+func initTracing(ctx context.Context) {
+	cfg := getTracingConfig()
+	if !cfg.Enabled {
+		return
+	}
+	options := []otlptracehttp.Option{otlptracehttp.WithHeaders(cfg.Headers)}
+	if cfg.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		slog.Error("Failed to create trace exporter", "error", err)
+		return
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		res = resource.Default()
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	tracingMutex.Lock()
+	tracerProvider = provider
+	tracingMutex.Unlock()
+	slog.Info("Tracing enabled", "endpoint", cfg.Endpoint, "service", cfg.ServiceName)
+}
+
+// 导出剩余的 span 并关闭
+func shutdownTracing(ctx context.Context) {
+	tracingMutex.Lock()
+	provider := tracerProvider
+	tracerProvider = nil
+	tracingMutex.Unlock()
+	if provider == nil {
+		return
+	}
+	if err := provider.Shutdown(ctx); err != nil {
+		slog.Error("Failed to shut down tracing", "error", err)
+	}
+}
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// 结束 span，出错时记录错误
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,91 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// 成交量异动告警配置
+type VolumeSpikeConfig struct {
+	Enabled         bool    `json:"enabled"`
+	BucketMinutes   int     `json:"bucketMinutes"`   // 统计周期（分钟），当前周期的成交额与历史周期比较
+	BaselineBuckets int     `json:"baselineBuckets"` // 作为基线的历史周期数
+	StdDevs         float64 `json:"stdDevs"`         // 超过均值多少个标准差时告警
+	MinVolumeUSD    float64 `json:"minVolumeUSD"`    // 当前周期成交额低于该值时不告警
+}
+
+// 成交量统计状态
+type volumeTracker struct {
+	mu          sync.Mutex
+	buckets     map[int64]float64 // 周期序号 -> 成交额（美元）
+	lastAlerted int64             // 上次告警的周期序号
+}
+
+var volumeSpike = &volumeTracker{buckets: make(map[int64]float64)}
+
+func getVolumeSpikeConfig() VolumeSpikeConfig {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.VolumeSpike
+}
+
+// 观察每笔 Swap 的成交额
+func observeVolumeSpike(ctx context.Context, event *SwapEvent) {
+	cfg := getVolumeSpikeConfig()
+	if !cfg.Enabled {
+		return
+	}
+	if message := volumeSpike.observe(cfg, event.Time, event.VolumeUSD()); !message.empty() {
+		slog.Warn("Volume spike alert", "message", message)
+		sendAlert(ctx, message, barkLevelTimeSensitive)
+	}
+}
+
+// 记录成交额，当前周期超过基线时返回告警内容
+func (t *volumeTracker) observe(cfg VolumeSpikeConfig, ts time.Time, volume float64) localizedText {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bucketMinutes := cfg.BucketMinutes
+	if bucketMinutes <= 0 {
+		bucketMinutes = 15
+	}
+	baselineBuckets := cfg.BaselineBuckets
+	if baselineBuckets <= 0 {
+		baselineBuckets = 96
+	}
+	stdDevs := cfg.StdDevs
+	if stdDevs <= 0 {
+		stdDevs = 3
+	}
+
+	current := ts.Unix() / int64(bucketMinutes*60)
+	t.buckets[current] += volume
+	for idx := range t.buckets {
+		if idx < current-int64(baselineBuckets) {
+			delete(t.buckets, idx)
+		}
+	}
+
+	// 基线包含没有成交的周期
+	var sum, sumSq float64
+	for idx := current - int64(baselineBuckets); idx < current; idx++ {
+		v := t.buckets[idx]
+		sum += v
+		sumSq += v * v
+	}
+	mean := sum / float64(baselineBuckets)
+	stddev := math.Sqrt(math.Max(sumSq/float64(baselineBuckets)-mean*mean, 0))
+
+	currentVolume := t.buckets[current]
+	threshold := mean + stdDevs*stddev
+	if currentVolume < cfg.MinVolumeUSD || currentVolume <= threshold || t.lastAlerted == current {
+		return localizedText{}
+	}
+	t.lastAlerted = current
+	return i18nText("alert.volumeSpike",
+		bucketMinutes, currentVolume, mean, threshold)
+}
@@ -0,0 +1,149 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 运行状态监控配置
+type WatchdogConfig struct {
+	Enabled              bool     `json:"enabled"`
+	QueryStaleMinutes    int      `json:"queryStaleMinutes"`    // 超过该时间没有成功的 Graph 查询时告警
+	SwapStaleMinutes     int      `json:"swapStaleMinutes"`     // 超过该时间没有新的 Swap 时告警，0 表示不检查，交易稀少的池子不宜开启
+	OwnerTargets         []string `json:"ownerTargets"`         // 接收告警的 Bark 地址，为空时使用 barkAPIURLs
+	HeartbeatURL         string   `json:"heartbeatURL"`         // 每轮处理成功后请求的地址，例如 healthchecks.io 的 ping 地址
+	HeartbeatMinInterval int      `json:"heartbeatMinInterval"` // 两次心跳请求的最小间隔（秒）
+}
+
+func getWatchdogConfig(ctx context.Context) WatchdogConfig {
+	configMutex.RLock()
+	cfg := configData.Watchdog
+	configMutex.RUnlock()
+	if cfg.QueryStaleMinutes <= 0 {
+		cfg.QueryStaleMinutes = 15
+	}
+	if cfg.HeartbeatMinInterval <= 0 {
+		cfg.HeartbeatMinInterval = 60
+	}
+	cfg.OwnerTargets = secretValues(cfg.OwnerTargets)
+	if len(cfg.OwnerTargets) == 0 {
+		cfg.OwnerTargets = allBarkTargets(ctx)
+	}
+	cfg.HeartbeatURL = secretValue(cfg.HeartbeatURL)
+	return cfg
+}
+
+// 监控状态，启动时间视为最近一次成功，避免启动后立即告警
+type watchdogState struct {
+	mu            sync.Mutex
+	lastQuery     time.Time
+	lastSwap      time.Time
+	lastHeartbeat time.Time
+	queried       bool            // 启动后是否有过成功的查询
+	degraded      map[string]bool // 已告警的检查项，恢复后发送恢复通知
+}
+
+var watchdog = &watchdogState{lastQuery: time.Now(), lastSwap: time.Now(), degraded: make(map[string]bool)}
+
+// 记录一次成功的查询，swaps 为本轮获取到的 Swap 数量，到达间隔时发送心跳
+func (w *watchdogState) recordQuery(ctx context.Context, now time.Time, swaps int) {
+	cfg := getWatchdogConfig(ctx)
+	w.mu.Lock()
+	w.lastQuery, w.queried = now, true
+	if swaps > 0 {
+		w.lastSwap = now
+	}
+	ping := cfg.Enabled && cfg.HeartbeatURL != "" && now.Sub(w.lastHeartbeat) >= time.Duration(cfg.HeartbeatMinInterval)*time.Second
+	if ping {
+		w.lastHeartbeat = now
+	}
+	w.mu.Unlock()
+
+	if ping {
+		if err := pingHeartbeat(ctx, cfg.HeartbeatURL); err != nil {
+			slog.Error("Failed to ping heartbeat", "error", err)
+		}
+	}
+}
+
+func pingHeartbeat(ctx context.Context, heartbeatURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, heartbeatURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("heartbeat returned %s", resp.Status)
+	}
+	return nil
+}
+
+// 最近一次成功查询的时间
+func (w *watchdogState) lastSuccess() (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastQuery, w.queried
+}
+
+// 重新开始计时，备实例成为主实例时使用
+func (w *watchdogState) reset(now time.Time) {
+	w.mu.Lock()
+	w.lastQuery, w.lastSwap = now, now
+	w.mu.Unlock()
+}
+
+// 返回状态发生变化的检查项及对应的告警消息
+func (w *watchdogState) check(cfg WatchdogConfig, now time.Time) []localizedText {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	type staleCheck struct {
+		key     string
+		last    time.Time
+		minutes int
+		what    localizedText
+	}
+	checks := []staleCheck{{"query", w.lastQuery, cfg.QueryStaleMinutes, i18nText("alert.noQuery")}}
+	if cfg.SwapStaleMinutes > 0 {
+		checks = append(checks, staleCheck{"swap", w.lastSwap, cfg.SwapStaleMinutes, i18nText("alert.noSwap")})
+	}
+
+	var messages []localizedText
+	for _, c := range checks {
+		stale := now.Sub(c.last) > time.Duration(c.minutes)*time.Minute
+		switch {
+		case stale && !w.degraded[c.key]:
+			w.degraded[c.key] = true
+			messages = append(messages, i18nText("alert.watchdogStale",
+				int(now.Sub(c.last).Minutes()), c.what, c.last.Format("01-02 15:04:05")))
+		case !stale && w.degraded[c.key]:
+			w.degraded[c.key] = false
+			messages = append(messages, i18nText("alert.watchdogOK", c.what))
+		}
+	}
+	return messages
+}
+
+// WatchdogTask 检查最近一次成功查询和最近一笔 Swap 的时间，超时时通知负责人
+func WatchdogTask(ctx context.Context) error {
+	cfg := getWatchdogConfig(ctx)
+	if !cfg.Enabled {
+		return nil
+	}
+	for _, message := range watchdog.check(cfg, time.Now()) {
+		// 负责人不一定是订阅者，使用默认语言
+		text := message.String()
+		slog.Warn("Watchdog alert", "message", text)
+		pushBark(ctx, cfg.OwnerTargets, text, barkOptions{Level: barkLevelTimeSensitive})
+		logNotification(ctx, "", cfg.OwnerTargets, text)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+// Source Swap 数据源，每个数据源有独立的处理进度
+type Source interface {
+	Name() string
+	CursorName() string                                          // 处理进度名称
+	LoadCursor(ctx context.Context, store Store) (Cursor, error) // 读取处理进度，没有进度时返回起始区块
+	FetchSwaps(ctx context.Context, from string) ([]Swap, error) // 获取 from 之后的交易，按区块从新到旧
+}
+
+// Notifier 通知一笔新交易，返回错误时该交易不记为已处理，处理进度不越过该交易所在的区块，下一轮重新通知
+type Notifier interface {
+	Notify(ctx context.Context, swap Swap, mevTag string) error
+}
+
+// NotifierFunc 把函数用作 Notifier
+type NotifierFunc func(ctx context.Context, swap Swap, mevTag string) error
+
+func (f NotifierFunc) Notify(ctx context.Context, swap Swap, mevTag string) error {
+	return f(ctx, swap, mevTag)
+}
+
+// Clock 当前时间，测试时可替换为固定时间
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// 始终返回同一时间的时钟
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// Watcher 获取各数据源的新交易，跳过已处理的交易后逐笔通知并推进处理进度。
+// 依赖全部通过字段注入，GraphTask 每轮按当前配置创建，配置修改后下一轮生效
+type Watcher struct {
+	Sources     []Source
+	Store       Store
+	Notifier    Notifier
+	Clock       Clock
+	Concurrency int // 同时获取的数据源数量上限，为 0 时不限制
+}
+
+// 按当前配置创建 Watcher：配置的 Graph 数据源、当前存储，以及带 panic 恢复的推送
+func newWatcher() *Watcher {
+	clock := systemClock{}
+	return &Watcher{
+		Sources:     GraphSources(),
+		Store:       getStore(),
+		Notifier:    clockNotifier(clock),
+		Clock:       clock,
+		Concurrency: getGraphConcurrency(),
+	}
+}
+
+// 一个数据源本轮获取的结果
+type sourceBatch struct {
+	source Source
+	from   string // 获取时的处理进度
+	swaps  []Swap
+	err    error
+}
+
+// Run 执行一轮：并发获取各数据源的新交易，再按数据源依次处理
+func (w *Watcher) Run(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "graph_task")
+	defer func() { endSpan(span, err) }()
+	flushSpamAggregates(ctx, w.Clock.Now())
+
+	batches := w.fetchAll(ctx)
+	var errs []error
+	fetched, succeeded := 0, false
+	for _, batch := range batches {
+		if batch.err != nil {
+			errs = append(errs, batch.err)
+			continue
+		}
+		succeeded = true
+		fetched += len(batch.swaps)
+	}
+	if succeeded {
+		watchdog.recordQuery(ctx, w.Clock.Now(), fetched)
+		graphPoller.record(w.Clock.Now(), fetched)
+	}
+	span.SetAttributes(attribute.Int("swaps.fetched", fetched))
+
+	for _, batch := range batches {
+		if batch.err != nil {
+			continue
+		}
+		if err := w.process(ctx, batch); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 && !succeeded {
+		select {
+		case <-time.After(3 * time.Second):
+		case <-ctx.Done():
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// 按并发上限同时获取所有数据源的新交易，单个数据源失败不影响其他数据源
+func (w *Watcher) fetchAll(ctx context.Context) []sourceBatch {
+	batches := make([]sourceBatch, len(w.Sources))
+	var g errgroup.Group
+	if w.Concurrency > 0 {
+		g.SetLimit(w.Concurrency)
+	}
+	for i, source := range w.Sources {
+		g.Go(func() error {
+			batch := sourceBatch{source: source}
+			cursor, err := state.getWith(ctx, w.Store, source.LoadCursor)
+			if err == nil {
+				batch.from = cursor.LastBlockNumber
+				batch.swaps, err = source.FetchSwaps(ctx, cursor.LastBlockNumber)
+			}
+			if err != nil {
+				slog.Error("Error fetching swaps", "source", source.Name(), "error", err)
+			}
+			batch.err = err
+			batches[i] = batch
+			return nil
+		})
+	}
+	g.Wait()
+	return batches
+}
+
+// 处理一个数据源本轮获取的交易并推进处理进度
+func (w *Watcher) process(ctx context.Context, batch sourceBatch) (err error) {
+	ctx, span := startSpan(ctx, "process_swaps", attribute.String("graph.source", batch.source.Name()))
+	defer func() { endSpan(span, err) }()
+	swaps := batch.swaps
+	err = state.updateWith(ctx, w.Store, batch.source.CursorName(), batch.source.LoadCursor, w.Clock.Now(), func(cursor *Cursor) error {
+		if cursor.LastBlockNumber != batch.from {
+			// 获取之后进度已被其他轮次推进，这一批留给下一轮重新获取
+			return nil
+		}
+		if len(swaps) == 0 {
+			slog.Info("No new swaps found", "source", batch.source.Name())
+			return nil
+		}
+
+		txHashes := make([]string, len(swaps))
+		for i, swap := range swaps {
+			txHashes[i] = swap.TransactionHash
+		}
+		seen, err := w.Store.SeenTx(ctx, txHashes)
+		if err != nil {
+			return err
+		}
+
+		_, observeSpan := startSpan(ctx, "observe")
+		observeSwaps(ctx, swaps, seen)
+		observeSpan.End()
+		mevTags := detectMEV(swaps)
+
+		var newTxHashes []string
+		failedBlock := int64(-1) // 通知失败的交易中最低的区块
+		for _, swap := range swaps {
+			if ctx.Err() != nil {
+				break
+			}
+			if seen[swap.TransactionHash] {
+				auditSuppressedSwap(&swap, nil, auditReasonDuplicate, "")
+				continue
+			}
+			err = w.Notifier.Notify(ctx, swap, mevTags[swap.TransactionHash])
+			if err != nil {
+				slog.Error("Error sending notification", "error", err)
+				auditSuppressedSwap(&swap, nil, auditReasonError, err.Error())
+				if block, _ := strconv.ParseInt(swap.BlockNumber, 10, 64); failedBlock < 0 || block < failedBlock {
+					failedBlock = block
+				}
+			} else {
+				newTxHashes = append(newTxHashes, swap.TransactionHash)
+			}
+		}
+		span.SetAttributes(attribute.Int("swaps.notified", len(newTxHashes)))
+		if err := w.Store.MarkSeen(ctx, newTxHashes, w.Clock.Now()); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			// 超时或停止时进度不前进，未发送的交易下一轮重新处理
+			return err
+		}
+
+		if failedBlock < 0 {
+			cursor.LastBlockNumber = swaps[0].BlockNumber
+			return nil
+		}
+		// 进度只推进到失败交易之前的区块，失败的交易下一轮重新获取，同一区块及之后已通知的交易按已处理跳过
+		for _, swap := range swaps {
+			if block, _ := strconv.ParseInt(swap.BlockNumber, 10, 64); block < failedBlock {
+				cursor.LastBlockNumber = swap.BlockNumber
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("Error updating cursor", "source", batch.source.Name(), "error", err)
+	}
+	return err
+}
+
+// 返回固定交易的数据源，只返回进度之后的部分
+type staticSource struct {
+	name  string
+	swaps []Swap // 按区块从新到旧
+}
+
+func (s staticSource) Name() string { return s.name }
+
+func (s staticSource) CursorName() string { return defaultCursorName + ":" + s.name }
+
+func (s staticSource) LoadCursor(ctx context.Context, store Store) (Cursor, error) {
+	cursor, _, err := store.LoadCursor(ctx, s.CursorName())
+	return cursor, err
+}
+
+func (s staticSource) FetchSwaps(ctx context.Context, from string) ([]Swap, error) {
+	start, _ := strconv.ParseInt(from, 10, 64)
+	var swaps []Swap
+	for _, swap := range s.swaps {
+		if block, _ := strconv.ParseInt(swap.BlockNumber, 10, 64); block > start {
+			swaps = append(swaps, swap)
+		}
+	}
+	return swaps, nil
+}
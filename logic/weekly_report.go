@@ -0,0 +1,132 @@
+package logic
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 周报中展示的地址数量
+const weeklyTopN = 3
+
+// 地址成交额排名
+type addressVolume struct {
+	Address   string
+	VolumeUSD float64
+}
+
+// WeeklyReportTask 发送过去 7 天的成交周报
+func WeeklyReportTask(ctx context.Context) error {
+	now := time.Now()
+	records := querySwapHistory(ctx, now.AddDate(0, 0, -7), now)
+	message := formatWeeklyReport(ctx, now, records)
+	slog.Info("Sending weekly report", "message", message)
+	sendDigest(ctx, message)
+	return nil
+}
+
+func formatWeeklyReport(ctx context.Context, now time.Time, records []swapRecord) localizedText {
+	loc, err := time.LoadLocation(getDigestConfig().Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	start := now.AddDate(0, 0, -7).In(loc).Format("01-02")
+	end := now.In(loc).Format("01-02")
+	if len(records) == 0 {
+		return i18nText("weekly.empty", start, end)
+	}
+
+	summary := summarizeSwaps(records)
+	parts := []localizedText{
+		i18nText("weekly.summary", start, end, summary.Count, formatDigestUSD(summary.VolumeUSD)),
+		i18nText("weekly.daily", dailyVolumes(records, loc)),
+		buySellRatio(summary),
+	}
+	if low, high, ok := priceRange(records); ok {
+		parts = append(parts, i18nText("weekly.priceRange", formatPrice(low), formatPrice(high)))
+	}
+	parts = append(parts,
+		i18nText("weekly.senders", formatTopAddresses(ctx, topAddresses(records, func(r swapRecord) string { return r.Sender }))),
+		i18nText("weekly.recipients", formatTopAddresses(ctx, topAddresses(records, func(r swapRecord) string { return r.Recipient }))),
+	)
+	return i18nText("weekly.report", localizedList{Separator: "report.separator", Items: parts})
+}
+
+// 按日统计成交额
+func dailyVolumes(records []swapRecord, loc *time.Location) string {
+	var days []string
+	volumes := make(map[string]float64)
+	for _, r := range records {
+		day := r.Time.In(loc).Format("01-02")
+		if _, ok := volumes[day]; !ok {
+			days = append(days, day)
+		}
+		volumes[day] += r.VolumeUSD
+	}
+	parts := make([]string, 0, len(days))
+	for _, day := range days {
+		parts = append(parts, day+" "+formatDigestUSD(volumes[day]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// 买入与卖出成交额之比
+func buySellRatio(summary swapSummary) localizedText {
+	sell := summary.VolumeUSD - summary.BuyVolumeUSD
+	if sell == 0 {
+		return i18nText("weekly.allBuy")
+	}
+	return i18nText("weekly.ratio", summary.BuyVolumeUSD/sell)
+}
+
+// 价格最低和最高值
+func priceRange(records []swapRecord) (low, high float64, ok bool) {
+	for _, r := range records {
+		if r.Price <= 0 {
+			continue
+		}
+		if !ok || r.Price < low {
+			low = r.Price
+		}
+		if !ok || r.Price > high {
+			high = r.Price
+		}
+		ok = true
+	}
+	return low, high, ok
+}
+
+// 按成交额排序的地址
+func topAddresses(records []swapRecord, key func(swapRecord) string) []addressVolume {
+	volumes := make(map[string]float64)
+	for _, r := range records {
+		volumes[strings.ToLower(key(r))] += r.VolumeUSD
+	}
+	ranked := make([]addressVolume, 0, len(volumes))
+	for address, volume := range volumes {
+		ranked = append(ranked, addressVolume{Address: address, VolumeUSD: volume})
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].VolumeUSD > ranked[j].VolumeUSD })
+	if len(ranked) > weeklyTopN {
+		ranked = ranked[:weeklyTopN]
+	}
+	return ranked
+}
+
+func formatTopAddresses(ctx context.Context, ranked []addressVolume) string {
+	parts := make([]string, 0, len(ranked))
+	for _, a := range ranked {
+		parts = append(parts, displayAddress(ctx, a.Address)+" "+formatDigestUSD(a.VolumeUSD))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// 缩短地址显示，例如 0x1234...abcd
+func shortAddress(address string) string {
+	if len(address) <= 12 {
+		return address
+	}
+	return address[:6] + "..." + address[len(address)-4:]
+}
@@ -0,0 +1,44 @@
+package logic
+
+// 大额交易分级，按 MinAmount 从高到低匹配第一个满足的级别
+type WhaleTier struct {
+	Name      string  `json:"name"`
+	MinAmount float64 `json:"minAmount"` // 输入数量下限（代币单位）
+	Title     string  `json:"title"`     // 推送标题，为空时使用 Bark 地址中的标题
+	Sound     string  `json:"sound"`     // Bark 提示音
+	Level     string  `json:"level"`     // Bark 推送级别：critical / timeSensitive / active / passive
+	Emoji     string  `json:"emoji"`     // 通知中的 emoji，为空时使用 emoji.whale
+}
+
+func getWhaleTiers() []WhaleTier {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+	return configData.WhaleTiers
+}
+
+// 找到交易所属的最高级别，没有匹配时返回 nil
+func matchWhaleTier(event *SwapEvent) *WhaleTier {
+	amount := decimalFloat(event.AmountIn)
+	var matched *WhaleTier
+	for _, tier := range getWhaleTiers() {
+		if amount >= tier.MinAmount && (matched == nil || tier.MinAmount > matched.MinAmount) {
+			t := tier
+			matched = &t
+		}
+	}
+	return matched
+}
+
+// 根据级别生成推送选项，未匹配任何级别时保持原有的紧急推送
+func whaleBarkOptions(event *SwapEvent) barkOptions {
+	opts := barkOptions{Level: barkLevelCritical}
+	tier := matchWhaleTier(event)
+	if tier == nil {
+		return opts
+	}
+	opts.Title, opts.Sound = tier.Title, tier.Sound
+	if tier.Level != "" {
+		opts.Level = tier.Level
+	}
+	return opts
+}
@@ -1,55 +1,96 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"gopkg.in/natefinch/lumberjack.v2"
-	"io"
 	"log"
 	"messag-push/logic"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 )
 
 //TIP To run your code, right-click the code and select <b>Run</b>. Alternatively, click
 // the <icon src="AllIcons.Actions.Execute"/> icon in the gutter and select the <b>Run</b> menu item from here.
 
+// 子命令，未指定子命令时以守护进程方式运行
+var commands = map[string]func(args []string) error{
+	"prices":      logic.RunPriceQuery,
+	"cursor":      logic.RunCursorCommand,
+	"migrate":     logic.RunMigrate,
+	"backup":      logic.RunBackup,
+	"restore":     logic.RunRestore,
+	"export":      logic.RunExport,
+	"encrypt":     logic.RunEncrypt,
+	"tasks":       logic.RunTasksCommand,
+	"selftest":    logic.RunSelfTest,
+	"run-once":    logic.RunOnce,
+	"audit":       logic.RunAudit,
+	"subscribers": logic.RunSubscribers,
+	"render":      logic.RunRender,
+	"replay":      logic.RunReplay,
+	"simulate":    logic.RunSimulate,
+}
+
 func main() {
+	// 加载配置并监控配置文件的修改
+	logic.Init()
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "--selftest" {
+		runCommand("selftest", os.Args[2:])
+		return
+	}
+
 	// 初始化日志配置
 	setupLogger()
+	if err := logic.StartupSelfTest(); err != nil {
+		log.Fatalf("Self test failed: %v", err)
+	}
 	logic.StartTasks()
-	select {}
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	log.Printf("Received %s, stopping tasks", sig)
+
+	// 再次收到信号时不再等待，立即退出
+	stopped := make(chan struct{})
+	go func() {
+		logic.StopTasks()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case sig = <-signals:
+		log.Printf("Received %s again, exiting immediately", sig)
+		os.Exit(1)
+	}
 }
 
-// setupLogger 配置日志系统，使用 lumberjack 处理日志轮转
-func setupLogger() {
-	logDir := "./logs" // 日志目录
-	err := os.MkdirAll(logDir, 0755)
-	if err != nil {
-		log.Fatalf("Failed to create log directory: %v", err)
+// 执行子命令
+func runCommand(name string, args []string) {
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+		os.Exit(2)
+	}
+	if err := command(args); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		var exitErr *logic.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
+		os.Exit(1)
 	}
+}
 
-	// 动态生成日志文件名
-	logFileName := fmt.Sprintf("%s/message_push_output.log", logDir)
-
-	// 配置日志切割
-	log.SetOutput(&lumberjack.Logger{
-		Filename:   logFileName, // 日志文件路径
-		MaxSize:    1,           // 单个日志文件的最大大小（MB）
-		MaxBackups: 20,          // 最多保留的旧日志文件数量
-		MaxAge:     2,           // 日志文件保留的天数
-		Compress:   true,        // 是否压缩旧日志
-	})
-
-	// 创建一个多写器，同时写入文件和控制台
-	mw := io.MultiWriter(os.Stdout, os.Stderr, &lumberjack.Logger{
-		Filename:   logFileName,
-		MaxSize:    50,
-		MaxBackups: 20,
-		MaxAge:     2,
-		Compress:   true,
-	})
-	// 设置日志输出到多写器
-	log.SetOutput(mw)
-	// 设置日志格式
-	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-	log.Printf("Logger initialized with file: %s", logFileName)
+// setupLogger 配置日志系统，业务代码与标准库 log 都输出到 slog
+func setupLogger() {
+	if err := logic.SetupLogging(); err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
 }
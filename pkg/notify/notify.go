@@ -0,0 +1,42 @@
+// Package notify 注册自定义的通知方式。
+//
+// 内置的通知流程对每笔新交易依次执行过滤规则、成交额下限、小额刷单合并与脚本钩子，
+// 需要通知时推送到配置的 Bark、Telegram、外部命令，并调用通过 Register 注册的 Handler：
+//
+//	notify.Register("slack", func(ctx context.Context, alert notify.Alert) error {
+//		return postToSlack(ctx, alert.Message)
+//	})
+//
+// 需要完全替换通知流程时，把自己的 Notifier 设置到 Watcher.Notifier，
+// 它会收到每一笔未处理过的交易。返回错误时该交易不记为已处理，处理进度停在它所在的区块之前，
+// 下一轮重新获取并再次交给 Notifier；同一区块及之后已成功通知的交易不会重复通知。
+package notify
+
+import "messag-push/logic"
+
+type (
+	// Alert 一笔需要通知的交易，包含归一化后的成交数据、推送级别与默认语言的消息
+	Alert = logic.Alert
+	// Handler 自定义的通知方式，同步调用，耗时操作应自行异步执行；
+	// 返回的错误只记录到日志与渠道状态，不影响其他渠道，也不会重发
+	Handler = logic.AlertHandler
+	// Notifier Watcher 对每笔新交易的处理
+	Notifier = logic.Notifier
+	// NotifierFunc 把函数用作 Notifier
+	NotifierFunc = logic.NotifierFunc
+)
+
+// Register 注册自定义的通知方式，同名的会被替换。多个 Handler 按名称顺序调用
+func Register(name string, handler Handler) {
+	logic.RegisterAlertHandler(name, handler)
+}
+
+// Unregister 取消注册
+func Unregister(name string) {
+	logic.UnregisterAlertHandler(name)
+}
+
+// Default 返回内置的通知流程，panic 时恢复并返回错误
+func Default() Notifier {
+	return logic.DefaultNotifier()
+}
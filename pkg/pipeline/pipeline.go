@@ -0,0 +1,90 @@
+// Package pipeline 在其他 Go 程序中运行 Swap 监控流程。
+//
+// 导入时不读写任何文件，未指定配置时各项使用默认值。
+// 嵌入时通常用 LoadConfig 或 Configure 指定配置，再用 New 创建 Watcher 并按间隔运行：
+//
+//	if err := pipeline.LoadConfig("/etc/message-push/config.json"); err != nil {
+//		return err
+//	}
+//	notify.Register("audit", myHandler)
+//	w := pipeline.New()
+//	w.Sources = append(w.Sources, mySource)
+//	defer pipeline.Close()
+//	return pipeline.Run(ctx, w, time.Second)
+//
+// Watcher 的字段都可以替换：数据源（见 source 包）、存储、每笔交易的处理（见 notify 包）与时钟。
+// 需要定时汇总、告警、管理接口等全部功能时使用 Start 和 Stop，与独立运行时相同。
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"messag-push/logic"
+)
+
+type (
+	// Config 完整的配置，字段说明见 app_config.json
+	Config = logic.Config
+	// Watcher 获取各数据源的新交易，跳过已处理的交易后逐笔通知并推进处理进度
+	Watcher = logic.Watcher
+	// Clock Watcher 使用的当前时间
+	Clock = logic.Clock
+)
+
+// 停止时等待推送队列与外部命令的最长时间
+const drainTimeout = 30 * time.Second
+
+// LoadConfig 读取并应用配置文件，之后对文件的修改不会自动重新加载
+func LoadConfig(path string) error {
+	cfg, err := logic.ReadConfig(path)
+	if err != nil {
+		return err
+	}
+	return logic.ApplyConfig(cfg)
+}
+
+// Configure 应用配置，配置无效时返回错误且不应用。已创建的 Watcher 仍使用创建时的数据源与存储，需要重新创建
+func Configure(cfg Config) error {
+	return logic.ApplyConfig(cfg)
+}
+
+// New 按当前配置创建 Watcher：配置的 Graph 数据源、存储和内置的通知流程
+func New() *Watcher {
+	return logic.NewWatcher()
+}
+
+// Run 读取代币元数据后每隔 interval 执行一轮 w.Run，直到 ctx 取消，
+// 之后等待推送队列与外部命令完成。单轮的错误已记录到日志，不会中止运行，
+// 返回最后一轮的错误，最后一轮成功时返回 nil
+func Run(ctx context.Context, w *Watcher, interval time.Duration) error {
+	logic.Prepare(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var err error
+	for ctx.Err() == nil {
+		err = w.Run(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+		}
+	}
+	logic.Drain(drainTimeout)
+	return err
+}
+
+// Close 写入尚未持久化的处理进度并关闭存储
+func Close() error {
+	logic.FlushStore()
+	return logic.CloseStore()
+}
+
+// Start 按配置启动全部定时任务与服务，与不带子命令运行时相同
+func Start() {
+	logic.StartTasks()
+}
+
+// Stop 停止定时任务，等待发送中的通知后关闭存储
+func Stop() {
+	logic.StopTasks()
+}
@@ -0,0 +1,41 @@
+// Package source 定义 Watcher 的 Swap 数据源。
+//
+// 数据源按区块号获取新交易，每个数据源在存储中有独立的处理进度（Cursor）。
+// 除配置中的 Graph 数据源外，可以实现 Source 接口接入其他来源，例如自建的索引服务：
+//
+//	type mySource struct{}
+//
+//	func (mySource) Name() string       { return "indexer" }
+//	func (mySource) CursorName() string { return "swap:indexer" }
+//	func (s mySource) LoadCursor(ctx context.Context, store source.Store) (source.Cursor, error) {
+//		cursor, _, err := store.LoadCursor(ctx, s.CursorName())
+//		return cursor, err
+//	}
+//	func (mySource) FetchSwaps(ctx context.Context, from string) ([]source.Swap, error) {
+//		// 返回区块号大于 from 的交易，按区块从新到旧
+//	}
+package source
+
+import "messag-push/logic"
+
+type (
+	// Swap Graph API 返回的一笔 Swap，数量为未按精度换算的整数字符串
+	Swap = logic.Swap
+	// Cursor 数据源的处理进度
+	Cursor = logic.Cursor
+	// Store 处理进度、已处理交易与历史记录的存储
+	Store = logic.Store
+	// Source Swap 数据源，FetchSwaps 返回的交易需按区块从新到旧排列
+	Source = logic.Source
+)
+
+// Graph 返回当前配置中的 Graph 数据源，第一个为 graphAPIURLs 对应的主数据源，之后为 graphSources。
+// 同一数据源的多个地址按顺序故障转移
+func Graph() []Source {
+	return logic.GraphSources()
+}
+
+// Static 返回固定交易的数据源，swaps 按区块从新到旧，每轮只返回处理进度之后的部分，用于测试与回放
+func Static(name string, swaps []Swap) Source {
+	return logic.NewStaticSource(name, swaps)
+}
@@ -1,21 +1,258 @@
 package utils
 
-import "log/slog"
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// 所有任务共用的根 context，停止时取消正在执行的任务
+var jobsCtx, cancelJobs = context.WithCancel(context.Background())
+
+// 连续失败时的处理策略
+type FailurePolicy struct {
+	BackoffAfter   int           // 连续失败多少次后开始退避，0 表示不退避
+	MaxBackoff     time.Duration // 退避时间上限，退避时间从 1 秒开始每次翻倍
+	AlertThreshold int           // 连续失败多少次后触发告警，0 表示不告警
+}
+
+// 上一轮尚未结束时新一轮调度的处理方式
+const (
+	OverlapSkip  = "skip"  // 跳过本轮
+	OverlapQueue = "queue" // 上一轮结束后立即再执行一次，最多排队一轮
+)
+
+// 连续失败达到告警阈值时调用，恢复成功时以 failures=0 再调用一次
+type FailureHandler func(name string, failures int, err error)
+
+// 任务发生 panic 时调用
+type PanicHandler func(name string, recovered interface{}, stack []byte)
 
 type JobWrapper struct {
-	name   string
-	runner func() error
+	name      string
+	runner    func(ctx context.Context) error
+	timeout   func() time.Duration
+	policy    func() FailurePolicy
+	onFailure FailureHandler
+	overlap   func() string
+	onPanic   PanicHandler
+
+	mu          sync.Mutex
+	lastRun     time.Time
+	duration    time.Duration
+	lastErr     error
+	failures    int
+	panics      int
+	nextAttempt time.Time
+	alerted     bool
+	running     bool
+	pending     bool
+	skipped     int64
 }
 
-func WrapJob(name string, runner func() error) *JobWrapper {
+// 任务最近一次执行的结果
+type JobStatus struct {
+	LastRun     time.Time
+	Duration    time.Duration
+	LastErr     error
+	Failures    int       // 连续失败次数
+	Panics      int       // 累计 panic 次数
+	NextAttempt time.Time // 退避结束时间，之前的调度会被跳过
+	Running     bool
+	Skipped     int64 // 因上一轮未结束而跳过的次数
+}
+
+func WrapJob(name string, runner func(ctx context.Context) error) *JobWrapper {
 	return &JobWrapper{
 		name:   name,
 		runner: runner,
 	}
 }
 
+// WithTimeout 设置单次执行的超时时间，每次执行前读取，便于配置热更新
+func (w *JobWrapper) WithTimeout(timeout func() time.Duration) *JobWrapper {
+	w.timeout = timeout
+	return w
+}
+
+// WithFailurePolicy 设置连续失败时的退避与告警策略，每次失败时读取
+func (w *JobWrapper) WithFailurePolicy(policy func() FailurePolicy, onFailure FailureHandler) *JobWrapper {
+	w.policy = policy
+	w.onFailure = onFailure
+	return w
+}
+
+// WithPanicHandler 设置 panic 的处理函数，用于上报错误
+func (w *JobWrapper) WithPanicHandler(onPanic PanicHandler) *JobWrapper {
+	w.onPanic = onPanic
+	return w
+}
+
+// WithOverlap 设置上一轮未结束时的处理方式，默认跳过
+func (w *JobWrapper) WithOverlap(overlap func() string) *JobWrapper {
+	w.overlap = overlap
+	return w
+}
+
+func (w *JobWrapper) Name() string {
+	return w.name
+}
+
+// Run 同一任务同时只执行一轮，避免并发修改状态
 func (w *JobWrapper) Run() {
-	if err := w.runner(); err != nil {
+	w.mu.Lock()
+	if w.running {
+		if w.overlapMode() == OverlapQueue && !w.pending {
+			w.pending = true
+		} else {
+			w.skipped++
+			slog.Debug("job still running, skipped", "name", w.name, "skipped", w.skipped)
+		}
+		w.mu.Unlock()
+		return
+	}
+	w.running = true
+	w.mu.Unlock()
+
+	for {
+		w.runOnce()
+
+		w.mu.Lock()
+		if !w.pending {
+			w.running = false
+			w.mu.Unlock()
+			return
+		}
+		w.pending = false
+		w.mu.Unlock()
+	}
+}
+
+func (w *JobWrapper) overlapMode() string {
+	if w.overlap == nil {
+		return OverlapSkip
+	}
+	return w.overlap()
+}
+
+func (w *JobWrapper) runOnce() {
+	w.mu.Lock()
+	backingOff := time.Now().Before(w.nextAttempt)
+	w.mu.Unlock()
+	if backingOff {
+		return
+	}
+
+	ctx := jobsCtx
+	if w.timeout != nil {
+		if timeout := w.timeout(); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	start := time.Now()
+	err, panicked := w.runSafely(ctx)
+	if err != nil {
 		slog.Error("exec job failed", "name", w.name, "err", err)
 	}
+	w.record(start, err, panicked)
+}
+
+// 执行任务并把 panic 转换为错误，避免整个进程退出
+func (w *JobWrapper) runSafely(ctx context.Context) (err error, panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			slog.Error("job panicked", "name", w.name, "panic", r, "stack", string(stack))
+			err, panicked = fmt.Errorf("panic: %v", r), true
+			if w.onPanic != nil {
+				w.onPanic(w.name, r, stack)
+			}
+		}
+	}()
+	return w.runner(ctx), false
+}
+
+func (w *JobWrapper) record(start time.Time, err error, panicked bool) {
+	w.mu.Lock()
+	w.lastRun = start
+	w.duration = time.Since(start)
+	w.lastErr = err
+	if panicked {
+		w.panics++
+	}
+
+	var notify bool
+	var failures int
+	if err == nil {
+		notify = w.alerted
+		w.failures, w.nextAttempt, w.alerted = 0, time.Time{}, false
+	} else {
+		w.failures++
+		failures = w.failures
+		var policy FailurePolicy
+		if w.policy != nil {
+			policy = w.policy()
+		}
+		if policy.BackoffAfter > 0 && w.failures >= policy.BackoffAfter {
+			backoff := backoffDuration(w.failures-policy.BackoffAfter, policy.MaxBackoff)
+			w.nextAttempt = time.Now().Add(backoff)
+			slog.Warn("job backing off", "name", w.name, "failures", w.failures, "backoff", backoff)
+		}
+		if policy.AlertThreshold > 0 && w.failures >= policy.AlertThreshold && !w.alerted {
+			notify, w.alerted = true, true
+		}
+	}
+	w.mu.Unlock()
+
+	if notify && w.onFailure != nil {
+		w.onFailure(w.name, failures, err)
+	}
+}
+
+// 退避时间从 1 秒开始每次翻倍，不超过上限
+func backoffDuration(attempt int, max time.Duration) time.Duration {
+	backoff := time.Second
+	for i := 0; i < attempt && (max <= 0 || backoff < max); i++ {
+		backoff *= 2
+	}
+	if max > 0 && backoff > max {
+		backoff = max
+	}
+	return backoff
+}
+
+func (w *JobWrapper) Status() JobStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return JobStatus{
+		LastRun:     w.lastRun,
+		Duration:    w.duration,
+		LastErr:     w.lastErr,
+		Failures:    w.failures,
+		Panics:      w.panics,
+		NextAttempt: w.nextAttempt,
+		Running:     w.running,
+		Skipped:     w.skipped,
+	}
+}
+
+// ResetBackoff 清除退避状态，手动触发任务时使用
+func (w *JobWrapper) ResetBackoff() {
+	w.mu.Lock()
+	w.nextAttempt = time.Time{}
+	w.mu.Unlock()
+}
+
+// CancelJobs 取消所有正在执行的任务，之后触发的任务不再执行
+func CancelJobs() {
+	cancelJobs()
 }